@@ -1,129 +1,380 @@
 package cmd
 
 import (
+	"context"
+	"database/sql"
+	"errors"
 	"fmt"
-	"os"
+	"log/slog"
+	"strings"
+	"time"
 
 	"goprojects/cluster-auditor/internal/audit"
+	"goprojects/cluster-auditor/internal/logging"
 
 	"goprojects/services/server"
 
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/push"
 	"github.com/spf13/cobra"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 	"k8s.io/client-go/kubernetes"
 
 	"goprojects/findings"
 )
 
 var (
-	namespace  string
-	outputJSON bool
-	outputYAML bool
-	outputFile string
+	namespace   string
+	outputJSON  bool
+	outputYAML  bool
+	outputSARIF bool
+	outputJUnit bool
+	outputHTML  bool
+	outputFile  string
+	manifests   []string
+	dbPath      string
+	cluster     string
+	team        string
+	checkIDs    []string
+
+	metricsPushURL  string
+	metricsTextfile string
+	policyReports   bool
 )
+var tracer = otel.Tracer("goprojects/cluster-auditor/cmd")
+
 var rootCmd = &cobra.Command{
 	Use: "audit",
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		// Errors from here on are about the run, not the usage
+		cmd.SilenceUsage = true
+	},
+	// Execute reports errors
+	SilenceErrors: true,
 }
 var auditCmd = &cobra.Command{
 	Use:   "run",
 	Short: "Audit Kubernetes deployments for best practices",
-	Run: func(cmd *cobra.Command, args []string) {
-
-		db, err := server.InitDB("audit.db")
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := setupLogging(); err != nil {
+			return fmt.Errorf("invalid logging flags: %w", err)
+		}
+		formats, err := selectedFormats(cmd)
 		if err != nil {
-			fmt.Println("Failed to init DB:", err)
-			os.Exit(1)
+			return fmt.Errorf("invalid output flags: %w", err)
 		}
-		defer db.Close()
-
-		auditor := findings.NewAuditor()
-
-		clientset, err := audit.GetKubernetesClient()
+		streams, err := openStreams(cmd.OutOrStdout(), formats)
 		if err != nil {
-			fmt.Println("Failed to get Kubernetes client:", err)
-			os.Exit(1)
-		}
-
-		var allErrors []error
-
-		checks := []struct {
-			name string
-			fn   func(*findings.Auditor, kubernetes.Interface, string) error
-		}{
-			{"MissingResourceLimits", audit.CheckMissingResourceLimits},
-			{"MissingReadinessProbes", audit.CheckMissingReadinessProbes},
-			{"MissingLivenessProbes", audit.CheckMissingLivenessProbes},
-			{"Docker tag check", audit.DockerTagCheck},
-			{"HPA conflict check", audit.CheckHPAConflict},
-			{"NetworkPolicy check", audit.CheckMissingNetworkPolicy},
-			{"PortConflict check", audit.CheckPortTargetConflicts},
-			{"PVCcheck", audit.PVCcheck},
-			{"UnclaimedPV", audit.UnclaimedPV},
-		}
-		for _, check := range checks {
-			err := check.fn(auditor, clientset, namespace)
-			if err != nil {
-				allErrors = append(allErrors, fmt.Errorf("check %s failed: %w", check.name, err))
-			}
+			return fmt.Errorf("failed to open audit report: %w", err)
+		}
+		checks, err := audit.SelectChecks(checkIDs)
+		if err != nil {
+			return fmt.Errorf("invalid --checks flag: %w", err)
 		}
+		// The report is only needed in full by the formats not streamed
+		keep := policyReports || !streams.all(formats)
+		ctx := context.Background()
+		flushTraces, err := setupTracing(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to set up tracing: %w", err)
+		}
+		defer flushTraces()
 
-		for _, f := range auditor.Findings {
-			err := server.InsertFinding(db, f)
+		var (
+			report *audit.Report
+			teams  *audit.TeamResolver
+			db     *sql.DB // nil for offline audits
+			locate func(findings.Finding) (audit.ManifestSource, bool)
+			// Findings covered by exceptions are stored and marked as skipped in
+			// policy reports, but left out of every output
+			exceptions []findings.Exception
+		)
+		if len(manifests) > 0 {
+			// Offline audits only read files, nothing is stored
+			if policyReports {
+				return errors.New("--policy-reports writes into the audited cluster, it cannot be used with --manifests")
+			}
+			m, err := audit.LoadManifests(manifests...)
 			if err != nil {
-				fmt.Printf("Failed to insert finding into DB: %v\n", err)
+				return fmt.Errorf("failed to load manifests: %w", err)
 			}
-		}
-
-		jsonDefault := "audit_report.json"
-		yamlDefault := "audit_report.yaml"
-
-		if outputJSON {
-			filename := outputFile
-			if filename == "" {
-				filename = jsonDefault
+			if teams, err = newTeamResolver(nil); err != nil {
+				return fmt.Errorf("failed to load team mapping: %w", err)
+			}
+			if exceptions, err = loadExceptions(ctx, nil); err != nil {
+				return err
 			}
-			err := audit.OutputFindingsAsJSON(auditor.Findings, filename)
+			report = auditManifests(ctx, m, teams, checks, namespace, exceptStream(exceptions, streams.write), keep)
+			locate = m.Locate
+		} else {
+			db, err = server.InitDB(dbPath)
 			if err != nil {
-				fmt.Println("Failed to write JSON audit report:", err)
-				os.Exit(1)
+				return fmt.Errorf("failed to init DB: %w", err)
+			}
+			defer db.Close()
+			if exceptions, err = loadExceptions(ctx, db); err != nil {
+				return err
 			}
-		}
 
-		if outputYAML {
-			filename := outputFile
-			if filename == "" {
-				filename = yamlDefault
+			clientset, err := audit.GetKubernetesClient()
+			if err != nil {
+				return fmt.Errorf("failed to get Kubernetes client: %w", err)
 			}
-			err := audit.OutputFindingsAsYAML(auditor.Findings, filename)
+			if teams, err = newTeamResolver(clientset); err != nil {
+				return fmt.Errorf("failed to load team mapping: %w", err)
+			}
+			alerts, err := newAlerter(db, clientset)
 			if err != nil {
-				fmt.Println("Failed to write YAML audit report:", err)
-				os.Exit(1)
+				return fmt.Errorf("failed to set up alerts: %w", err)
 			}
-		}
 
-		if !outputJSON && !outputYAML {
-			fmt.Println("No output format specified. Use --json and/or --yaml.")
+			report = auditAndStore(ctx, db, clientset, teams, alerts, checks, clusterName(), namespace, exceptStream(exceptions, streams.write), keep)
+		}
+		if err := streams.close(); err != nil {
+			return fmt.Errorf("failed to output audit report: %w", err)
+		}
+		// Policy reports get every finding, like the DB, so --team does not prune
+		// the reports of other teams
+		if policyReports {
+			if err := writePolicyReports(ctx, report, exceptions); err != nil {
+				return fmt.Errorf("failed to write policy reports: %w", err)
+			}
+		}
+		report.Filter(func(ref findings.ResourceRef) bool { return team == "" || teams.Team(ctx, ref.Namespace) == team })
+		if err := writeOutputs(cmd.OutOrStdout(), formats, report, locate, exceptions); err != nil {
+			return fmt.Errorf("failed to output audit report: %w", err)
 		}
 
-		if len(allErrors) > 0 {
-			fmt.Println("One or more checks encountered errors:")
-			for _, e := range allErrors {
-				fmt.Println("-", e)
+		if db != nil {
+			if err := exportMetrics(db, clusterName()); err != nil {
+				return fmt.Errorf("failed to export metrics: %w", err)
 			}
-			os.Exit(1) // Exit with error if any check failed
 		}
+
+		if errs := report.Errors(); len(errs) > 0 {
+			return checkErrors(errs) // Exit with error if any check failed
+		}
+		return nil
 	},
 }
 
-func Execute() {
-	if err := rootCmd.Execute(); err != nil {
-		fmt.Println("Error:", err)
-		os.Exit(1)
+// checkErrors lists the errors of the checks that failed, one per line
+func checkErrors(errs []error) error {
+	var b strings.Builder
+	b.WriteString("one or more checks encountered errors:")
+	for _, err := range errs {
+		b.WriteString("\n- " + err.Error())
+	}
+	return errors.New(b.String())
+}
+
+// clusterName returns the --cluster flag, defaulting to the current kubeconfig cluster
+func clusterName() string {
+	if cluster != "" {
+		return cluster
+	}
+	name, err := audit.CurrentClusterName()
+	if err != nil {
+		return ""
+	}
+	return name
+}
+
+// auditAndStore runs the given checks, records the findings in the DB and
+// the run with its per-check results. Findings are stored with the team owning
+// their namespace. Findings of a check that failed are left untouched, so they
+// are not resolved by a check that could not see them. If stream is not nil, it is
+// called with each finding as soon as its check adds it. Unless keep is set, the
+// report only has the outcome of each check, not its findings and resources, so
+// they are not all held in memory. Findings that opened in this run are alerted
+// about once every check has run.
+func auditAndStore(ctx context.Context, db *sql.DB, clientset kubernetes.Interface, teams *audit.TeamResolver, alerts *alerter, checks []audit.Check, clusterName, namespace string, stream func(findings.Finding), keep bool) *audit.Report {
+	ctx, span := tracer.Start(ctx, "audit run", trace.WithAttributes(
+		attribute.String("audit.cluster", clusterName),
+		attribute.String("audit.namespace", namespace),
+	))
+	defer span.End()
+
+	auditor := newStreamingAuditor(ctx, teams, stream)
+	auditor.Cluster = clusterName
+	report := &audit.Report{Cluster: clusterName, Namespace: namespace, StartedAt: time.Now()}
+
+	run := server.Run{Cluster: clusterName, Namespace: namespace, StartedAt: report.StartedAt}
+	if err := server.BeginRun(ctx, db, &run); err != nil {
+		slog.ErrorContext(ctx, "Failed to record audit run in DB", "err", err)
+	}
+	ctx = logging.With(ctx, "run_id", run.ID, "cluster", clusterName, "namespace", namespace)
+	span.SetAttributes(attribute.Int64("audit.run_id", run.ID))
+	slog.InfoContext(ctx, "Audit started")
+
+	var opened []findings.Finding
+	total := 0
+	for _, check := range checks {
+		checkCtx := logging.With(ctx, "check_id", check.ID)
+		cr := audit.RunCheckReport(checkCtx, auditor, clientset, namespace, check)
+		teams.AssignTeams(checkCtx, cr.Findings)
+		total += len(cr.Findings)
+		opened = append(opened, storeCheck(checkCtx, db, &run, cr)...)
+		if !keep {
+			cr.Findings, cr.Resources = nil, nil
+			auditor.Findings, auditor.Audited = auditor.Findings[:0], auditor.Audited[:0]
+		}
+		report.Checks = append(report.Checks, cr)
 	}
+
+	report.FinishedAt = time.Now()
+	run.FinishedAt = report.FinishedAt
+	errs := report.Errors()
+	span.SetAttributes(attribute.Int("audit.findings", total), attribute.Int("audit.errors", len(errs)))
+	if len(errs) > 0 {
+		span.SetStatus(codes.Error, "one or more checks failed")
+	}
+	if run.ID != 0 {
+		if err := server.FinishRun(ctx, db, run); err != nil {
+			slog.ErrorContext(ctx, "Failed to record audit run in DB", "err", err)
+		}
+	}
+	slog.InfoContext(ctx, "Audit finished", "findings", total, "errors", len(errs),
+		"duration", run.FinishedAt.Sub(run.StartedAt))
+	alerts.opened(ctx, clusterName, opened)
+	return report
+}
+
+// storeCheck adds the outcome of a check to the run and stores its findings,
+// unless it failed. It returns the findings that opened.
+func storeCheck(ctx context.Context, db *sql.DB, run *server.Run, cr audit.CheckReport) []findings.Finding {
+	result := server.CheckRun{CheckID: cr.Check.ID, Duration: cr.Duration, Findings: len(cr.Findings)}
+	if cr.Err != nil {
+		slog.ErrorContext(ctx, "Check failed", "err", cr.Err)
+		result.Error = cr.Err.Error()
+	}
+	run.Checks = append(run.Checks, result)
+	if cr.Err != nil {
+		return nil
+	}
+
+	opened, err := server.ReplaceFindings(db, run.Cluster, run.Namespace, cr.Check.ID, cr.Findings)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to store findings in DB", "err", err)
+		return nil
+	}
+	// The run's findings are kept so it can be compared with audit diff
+	if run.ID != 0 {
+		if err := server.RecordRunFindings(ctx, db, run.ID, run.Cluster, cr.Findings); err != nil {
+			slog.ErrorContext(ctx, "Failed to record run findings in DB", "err", err)
+		}
+	}
+	return opened
+}
+
+// auditManifests runs the given checks against the objects of manifest
+// files. Findings carry no cluster, and their teams come from the mapping only.
+// Like auditAndStore, it streams findings and keeps them in the report if asked to.
+func auditManifests(ctx context.Context, m *audit.Manifests, teams *audit.TeamResolver, checks []audit.Check, namespace string, stream func(findings.Finding), keep bool) *audit.Report {
+	ctx = logging.With(ctx, "namespace", namespace)
+	slog.InfoContext(ctx, "Offline audit started", "objects", len(m.Objects))
+
+	auditor, client := newStreamingAuditor(ctx, teams, stream), m.Client()
+	report := &audit.Report{Namespace: namespace, StartedAt: time.Now()}
+	total := 0
+	for _, check := range checks {
+		cr := audit.RunCheckReport(ctx, auditor, client, namespace, check)
+		teams.AssignTeams(ctx, cr.Findings)
+		total += len(cr.Findings)
+		if !keep {
+			cr.Findings, cr.Resources = nil, nil
+			auditor.Findings, auditor.Audited = auditor.Findings[:0], auditor.Audited[:0]
+		}
+		report.Checks = append(report.Checks, cr)
+	}
+	report.FinishedAt = time.Now()
+
+	slog.InfoContext(ctx, "Offline audit finished", "findings", total, "errors", len(report.Errors()))
+	return report
+}
+
+// exceptStream returns a stream leaving out the findings covered by exceptions
+func exceptStream(exceptions []findings.Exception, stream func(findings.Finding)) func(findings.Finding) {
+	now := time.Now()
+	return func(f findings.Finding) {
+		if _, excepted := findings.MatchException(f, exceptions, now); !excepted {
+			stream(f)
+		}
+	}
+}
+
+// newStreamingAuditor returns an auditor calling stream, if not nil, with each
+// finding added and the team owning its namespace
+func newStreamingAuditor(ctx context.Context, teams *audit.TeamResolver, stream func(findings.Finding)) *findings.Auditor {
+	auditor := findings.NewAuditor()
+	if stream != nil {
+		auditor.OnFinding = func(f findings.Finding) {
+			f.Team = teams.Team(ctx, f.Namespace)
+			stream(f)
+		}
+	}
+	return auditor
+}
+
+// exportMetrics pushes the metrics of the stored findings and runs to a Pushgateway
+// and/or writes them for the node exporter's textfile collector, as requested
+func exportMetrics(db *sql.DB, clusterName string) error {
+	if metricsPushURL == "" && metricsTextfile == "" {
+		return nil
+	}
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(&server.MetricsCollector{DB: db})
+
+	if metricsPushURL != "" {
+		err := push.New(metricsPushURL, "cluster_auditor").
+			Gatherer(registry).
+			Grouping("cluster", clusterName).
+			Push()
+		if err != nil {
+			return fmt.Errorf("failed to push metrics: %w", err)
+		}
+	}
+	if metricsTextfile != "" {
+		if err := prometheus.WriteToTextfile(metricsTextfile, registry); err != nil {
+			return fmt.Errorf("failed to write metrics file: %w", err)
+		}
+	}
+	return nil
+}
+
+// writePolicyReports writes the report into the cluster as PolicyReports, with the
+// findings suppressed by exceptions marked as skipped
+func writePolicyReports(ctx context.Context, report *audit.Report, exceptions []findings.Exception) error {
+	client, err := audit.GetDynamicClient()
+	if err != nil {
+		return err
+	}
+	return audit.WritePolicyReports(ctx, client, report, exceptions)
+}
+
+// Execute runs the command line and reports its error, if any
+func Execute() error {
+	err := rootCmd.Execute()
+	reportError(err)
+	return err
 }
 func init() {
 	auditCmd.Flags().StringVarP(&namespace, "namespace", "n", "", "Namespace to audit (leave empty for all)")
-	auditCmd.Flags().BoolVarP(&outputJSON, "json", "j", false, "Output findings as JSON")
-	auditCmd.Flags().BoolVarP(&outputYAML, "yaml", "y", false, "Output findings as YAML")
-	auditCmd.Flags().StringVarP(&outputFile, "output", "o", "", "Output file for findings")
+	auditCmd.Flags().StringSliceVarP(&manifests, "manifests", "f", nil, "Audit these manifest files or directories instead of a cluster (nothing is stored)")
+	auditCmd.Flags().StringVar(&dbPath, "db", "audit.db", "SQLite database path or DSN, only read for its exceptions by offline audits")
+	auditCmd.Flags().StringVar(&cluster, "cluster", "", "Cluster name stored with findings (default: current kubeconfig cluster)")
+	auditCmd.Flags().StringSliceVar(&checkIDs, "checks", nil, "IDs of the checks to run, or all (default: every check but the opt-in privileged-container and risky-rbac)")
+	auditCmd.Flags().StringVar(&team, "team", "", "Only report the findings of this team (all findings are still stored)")
+	auditCmd.Flags().StringVar(&metricsPushURL, "metrics-push", "", "Push metrics to this Prometheus Pushgateway URL after the run")
+	auditCmd.Flags().StringVar(&metricsTextfile, "metrics-textfile", "", "Write metrics to this file for the node exporter textfile collector (*.prom)")
+	auditCmd.Flags().BoolVar(&policyReports, "policy-reports", false, "Write the findings into the cluster as wgpolicyk8s.io PolicyReports, one per namespace (needs the PolicyReport CRDs)")
+	addOutputFlags(auditCmd)
+	addTeamFlags(auditCmd)
+	addNotifyFlags(auditCmd)
+	addLoggingFlags(auditCmd)
+	addTracingFlags(auditCmd)
 	rootCmd.AddCommand(auditCmd)
 }
@@ -0,0 +1,171 @@
+package cmd
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"goprojects/cluster-auditor/internal/audit"
+	"goprojects/findings"
+	"goprojects/services/server"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	diffFormat    string
+	diffOutput    string
+	diffFailOn    string
+	diffUnchanged bool
+)
+
+var diffCmd = &cobra.Command{
+	Use:   "diff <old> <new>",
+	Short: "Show the findings that appeared and were resolved between two audits",
+	Long: `Compare the findings of two audits by fingerprint. Each side is either a JSON
+report written by "run --output-format=json" or the ID of a run stored in --db.
+
+Findings of checks that failed in either stored run are left out, since they
+would show up as resolved or new although nothing changed.`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := setupLogging(); err != nil {
+			return fmt.Errorf("invalid logging flags: %w", err)
+		}
+		if !slices.Contains(audit.DiffFormats, diffFormat) {
+			return fmt.Errorf("invalid output format %q (want %s)", diffFormat, strings.Join(audit.DiffFormats, ", "))
+		}
+		var failOn findings.Severity
+		if diffFailOn != "" {
+			var err error
+			if failOn, err = findings.ParseSeverity(diffFailOn); err != nil {
+				return fmt.Errorf("invalid --fail-on severity: %w", err)
+			}
+		}
+
+		ctx := context.Background()
+		sides := &diffSides{}
+		defer sides.close()
+		before, err := sides.load(ctx, args[0])
+		if err != nil {
+			return fmt.Errorf("failed to load old audit: %w", err)
+		}
+		after, err := sides.load(ctx, args[1])
+		if err != nil {
+			return fmt.Errorf("failed to load new audit: %w", err)
+		}
+		if len(sides.failedChecks) > 0 {
+			slog.Warn("Leaving out the findings of checks that failed in one of the runs", "checks", sides.failedChecks)
+			failed := func(f findings.Finding) bool { return slices.Contains(sides.failedChecks, f.RuleID) }
+			before, after = slices.DeleteFunc(before, failed), slices.DeleteFunc(after, failed)
+		}
+		d := audit.DiffFindings(before, after)
+
+		opts := audit.DiffOptions{Format: diffFormat, Unchanged: diffUnchanged}
+		var w io.Writer = cmd.OutOrStdout()
+		if diffOutput != "" && diffOutput != "-" {
+			file, err := os.Create(diffOutput)
+			if err != nil {
+				return fmt.Errorf("failed to create diff file: %w", err)
+			}
+			defer file.Close()
+			w = file
+		} else {
+			opts.Table = tableOptions(false)
+		}
+		if err := audit.WriteDiff(w, d, opts); err != nil {
+			return fmt.Errorf("failed to write diff: %w", err)
+		}
+
+		if failOn != "" {
+			if n := len(d.NewAtOrAbove(failOn)); n > 0 {
+				return fmt.Errorf("%d new findings at or above the --fail-on severity %s", n, failOn)
+			}
+		}
+		return nil
+	},
+}
+
+// diffSides loads the findings of both sides of a diff, opening the DB for the
+// sides that are run IDs
+type diffSides struct {
+	db           *sql.DB
+	failedChecks []string
+}
+
+func (s *diffSides) load(ctx context.Context, arg string) ([]findings.Finding, error) {
+	if _, err := os.Stat(arg); err == nil {
+		return loadReportFile(arg)
+	}
+	id, err := strconv.ParseInt(arg, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%s is neither a report file nor a run ID", arg)
+	}
+
+	if s.db == nil {
+		// Opening a missing database would create an empty one
+		if !dbExists(dbPath) {
+			return nil, fmt.Errorf("run %d not found: %s does not exist", id, dbPath)
+		}
+		if s.db, err = server.InitDB(dbPath); err != nil {
+			return nil, fmt.Errorf("failed to init DB: %w", err)
+		}
+	}
+	run, err := server.GetRun(ctx, s.db, id)
+	if err != nil {
+		return nil, err
+	}
+	fs, err := server.RunFindings(ctx, s.db, id)
+	if err != nil {
+		return nil, err
+	}
+
+	reported := 0
+	for _, c := range run.Checks {
+		reported += c.Findings
+		if c.Error != "" && !slices.Contains(s.failedChecks, c.CheckID) {
+			s.failedChecks = append(s.failedChecks, c.CheckID)
+		}
+	}
+	if len(fs) == 0 && reported > 0 {
+		return nil, fmt.Errorf("run %d predates stored run findings, compare JSON reports instead", id)
+	}
+	return fs, nil
+}
+
+func (s *diffSides) close() {
+	if s.db != nil {
+		s.db.Close()
+	}
+}
+
+// loadReportFile reads the findings of a JSON report
+func loadReportFile(filename string) ([]findings.Finding, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var fs []findings.Finding
+	if err := json.Unmarshal(data, &fs); err != nil {
+		return nil, fmt.Errorf("%s is not a JSON report: %w", filename, err)
+	}
+	return fs, nil
+}
+
+func init() {
+	diffCmd.Flags().StringVar(&diffFormat, "output-format", "table", "Output format: "+strings.Join(audit.DiffFormats, ", "))
+	diffCmd.Flags().StringVarP(&diffOutput, "output", "o", "", "Write the diff to this file instead of stdout")
+	diffCmd.Flags().StringVar(&diffFailOn, "fail-on", "", "Exit with an error if new findings at least this severe appear: critical, high, medium, low or info")
+	diffCmd.Flags().BoolVar(&diffUnchanged, "show-unchanged", false, "List unchanged findings too, instead of only counting them")
+	diffCmd.Flags().BoolVar(&noColor, "no-color", false, "Do not color the table (also set by NO_COLOR)")
+	diffCmd.Flags().StringVar(&dbPath, "db", "audit.db", "SQLite database path or DSN, for run IDs")
+	addLoggingFlags(diffCmd)
+	rootCmd.AddCommand(diffCmd)
+}
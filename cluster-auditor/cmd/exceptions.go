@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"strings"
+
+	"goprojects/findings"
+	"goprojects/services/server"
+)
+
+// dbExists reports whether the SQLite database of a --db path or file: DSN
+// exists, so commands that only read it do not create an empty one
+func dbExists(path string) bool {
+	name, _, _ := strings.Cut(strings.TrimPrefix(path, "file:"), "?")
+	if name == "" || name == ":memory:" {
+		return false
+	}
+	_, err := os.Stat(name)
+	return err == nil
+}
+
+// loadExceptions returns the active exceptions stored in db. Without db, they are
+// read from --db if it exists, else they are the default exceptions a new
+// database starts with.
+func loadExceptions(ctx context.Context, db *sql.DB) ([]findings.Exception, error) {
+	if db == nil {
+		if !dbExists(dbPath) {
+			return findings.DefaultExceptions, nil
+		}
+		var err error
+		if db, err = server.InitDB(dbPath); err != nil {
+			return nil, fmt.Errorf("failed to init DB: %w", err)
+		}
+		defer db.Close()
+	}
+	exceptions, err := server.ListExceptions(ctx, db, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load exceptions: %w", err)
+	}
+	return exceptions, nil
+}
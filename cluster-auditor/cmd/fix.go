@@ -0,0 +1,294 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"goprojects/cluster-auditor/internal/audit"
+	"goprojects/findings"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/dynamic"
+)
+
+var (
+	fixRules        []string
+	fixDryRun       bool
+	fixApply        bool
+	fixFieldManager string
+	fixForce        bool
+	fixRequests     map[string]string
+	fixLimits       map[string]string
+)
+
+var fixCmd = &cobra.Command{
+	Use:   "fix",
+	Short: "Generate patches for the findings that have a mechanical fix",
+	Long: `Audit with the checks that have mechanical fixes and generate a patch per finding:
+default requests and limits for containers without them, images on the latest tag
+pinned to the digest their pods run, spec.replicas removed from the manifests of
+workloads scaled by an HPA, and privileged set to false. Privileged containers are
+only fixed when named with --rule privileged-container, since system DaemonSets
+like kube-proxy and CNI agents need the privilege. Findings covered by the
+exceptions stored in --db, or by the default exceptions without one, are not fixed.
+
+The patches are printed as YAML, or with --manifests the patched objects. --dry-run
+prints the diff of each object instead, and --apply applies the patches in the
+cluster with server-side apply.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := setupLogging(); err != nil {
+			return fmt.Errorf("invalid logging flags: %w", err)
+		}
+		if fixDryRun && fixApply {
+			return errors.New("--dry-run and --apply are mutually exclusive")
+		}
+		if fixApply && len(manifests) > 0 {
+			return errors.New("--apply changes the cluster, it cannot be used with --manifests")
+		}
+		checks, err := fixChecks()
+		if err != nil {
+			return fmt.Errorf("invalid --rule: %w", err)
+		}
+		opts := audit.FixOptions{}
+		if opts.Requests, err = parseResourceList(audit.DefaultFixRequests, fixRequests); err != nil {
+			return fmt.Errorf("invalid --default-requests: %w", err)
+		}
+		if opts.Limits, err = parseResourceList(audit.DefaultFixLimits, fixLimits); err != nil {
+			return fmt.Errorf("invalid --default-limits: %w", err)
+		}
+
+		ctx := context.Background()
+		f := &fixer{opts: opts}
+		if len(manifests) > 0 {
+			m, err := audit.LoadManifests(manifests...)
+			if err != nil {
+				return fmt.Errorf("failed to load manifests: %w", err)
+			}
+			f.manifests = m
+			f.report = audit.RunReport(ctx, findings.NewAuditor(), m.Client(), namespace, checks)
+		} else {
+			clientset, err := audit.GetKubernetesClient()
+			if err != nil {
+				return fmt.Errorf("failed to get Kubernetes client: %w", err)
+			}
+			if f.dynamic, err = audit.GetDynamicClient(); err != nil {
+				return fmt.Errorf("failed to get Kubernetes client: %w", err)
+			}
+			f.opts.Client = clientset
+			auditor := findings.NewAuditor()
+			auditor.Cluster = clusterName()
+			f.report = audit.RunReport(ctx, auditor, clientset, namespace, checks)
+		}
+		for _, err := range f.report.Errors() {
+			slog.Warn("Check failed, its findings are not fixed", "err", err)
+		}
+		exceptions, err := loadExceptions(ctx, nil)
+		if err != nil {
+			return err
+		}
+		if excepted := f.report.Except(exceptions, time.Now()); excepted > 0 {
+			slog.Info("Findings covered by exceptions are not fixed", "findings", excepted)
+		}
+
+		if err := f.run(ctx, cmd.OutOrStdout()); err != nil {
+			return fmt.Errorf("failed to fix findings: %w", err)
+		}
+		return nil
+	},
+}
+
+// fixChecks returns the registered checks with fixes, limited to --rule. Opt-in
+// checks are only included when named with --rule, so neither printing nor
+// --apply de-privileges containers unless asked to explicitly.
+func fixChecks() ([]audit.Check, error) {
+	var checks []audit.Check
+	for _, check := range audit.Registry {
+		if !audit.Fixable(check.ID) {
+			continue
+		}
+		if named := slices.Contains(fixRules, check.ID); named || (len(fixRules) == 0 && !check.OptIn) {
+			checks = append(checks, check)
+		}
+	}
+	for _, rule := range fixRules {
+		if !audit.Fixable(rule) {
+			return nil, fmt.Errorf("%s has no fix", rule)
+		}
+	}
+	return checks, nil
+}
+
+// parseResourceList returns defaults with the quantities of a --default-* flag
+// set over them
+func parseResourceList(defaults corev1.ResourceList, values map[string]string) (corev1.ResourceList, error) {
+	list := defaults.DeepCopy()
+	for name, value := range values {
+		if err := validateResourceName(name); err != nil {
+			return nil, err
+		}
+		q, err := resource.ParseQuantity(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		list[corev1.ResourceName(name)] = q
+	}
+	return list, nil
+}
+
+// validateResourceName accepts the compute resources of containers: cpu, memory,
+// ephemeral-storage, hugepages and extended resources like nvidia.com/gpu
+func validateResourceName(name string) error {
+	switch corev1.ResourceName(name) {
+	case corev1.ResourceCPU, corev1.ResourceMemory, corev1.ResourceEphemeralStorage:
+		return nil
+	}
+	if strings.HasPrefix(name, corev1.ResourceHugePagesPrefix) {
+		return nil
+	}
+	if strings.Contains(name, "/") && !strings.HasPrefix(name, "kubernetes.io/") && len(validation.IsQualifiedName(name)) == 0 {
+		return nil
+	}
+	return fmt.Errorf("unknown resource %q, use cpu, memory, ephemeral-storage, hugepages-<size> or an extended resource like example.com/gpu", name)
+}
+
+// fixer plans the fixes of an audit's findings and prints or applies them
+type fixer struct {
+	report    *audit.Report
+	opts      audit.FixOptions
+	manifests *audit.Manifests // offline
+	dynamic   dynamic.Interface
+}
+
+// fixedObject is an object with the fixes planned for it, in order
+type fixedObject struct {
+	original, patched *unstructured.Unstructured
+	fixes             []audit.Fix
+}
+
+func (f *fixer) object(ctx context.Context, ref findings.ResourceRef) (*unstructured.Unstructured, error) {
+	if f.manifests != nil {
+		obj, ok := f.manifests.Object(ref)
+		if !ok {
+			return nil, fmt.Errorf("%s %s/%s not found in the manifests", ref.Kind, ref.Namespace, ref.Name)
+		}
+		return obj, nil
+	}
+	gvr, namespaced, ok := audit.ResourceOfKind(ref.Kind)
+	if !ok {
+		return nil, fmt.Errorf("unknown kind %s", ref.Kind)
+	}
+	if namespaced {
+		return f.dynamic.Resource(gvr).Namespace(ref.Namespace).Get(ctx, ref.Name, metav1.GetOptions{})
+	}
+	return f.dynamic.Resource(gvr).Get(ctx, ref.Name, metav1.GetOptions{})
+}
+
+// plan returns the objects with fixes in the order of their first finding. Each
+// fix is planned against the object patched by the previous ones.
+func (f *fixer) plan(ctx context.Context) ([]*fixedObject, error) {
+	var objects []*fixedObject
+	byRef := map[findings.ResourceRef]*fixedObject{}
+	skipped := 0
+	for _, finding := range f.report.Findings() {
+		ref := audit.ResourceOf(finding)
+		o, ok := byRef[ref]
+		if !ok {
+			obj, err := f.object(ctx, ref)
+			if err != nil {
+				return nil, err
+			}
+			o = &fixedObject{original: obj, patched: obj}
+			byRef[ref] = o
+			objects = append(objects, o)
+		}
+
+		fix, err := audit.PlanFix(ctx, o.patched, finding, f.opts)
+		if errors.Is(err, audit.ErrNotFixable) {
+			slog.Warn("Finding has no fix", "rule", finding.RuleID, "kind", finding.Kind, "namespace", finding.Namespace,
+				"name", finding.Resource, "container", finding.Container, "reason", err)
+			skipped++
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s %s/%s: %w", finding.Kind, finding.Namespace, finding.Resource, err)
+		}
+		if o.patched, err = fix.ApplyTo(o.patched); err != nil {
+			return nil, fmt.Errorf("%s %s/%s: failed to apply %s patch: %w", finding.Kind, finding.Namespace, finding.Resource, fix.Type, err)
+		}
+		o.fixes = append(o.fixes, fix)
+	}
+	slog.Info("Planned fixes", "findings", len(f.report.Findings()), "skipped", skipped)
+	return slices.DeleteFunc(objects, func(o *fixedObject) bool { return len(o.fixes) == 0 }), nil
+}
+
+func (f *fixer) run(ctx context.Context, w io.Writer) error {
+	objects, err := f.plan(ctx)
+	if err != nil {
+		return err
+	}
+	for i, o := range objects {
+		name := strings.ToLower(o.original.GetKind()) + "/" + o.original.GetName()
+		if ns := o.original.GetNamespace(); ns != "" {
+			name = ns + "/" + name
+		}
+		if f.manifests != nil {
+			if source, ok := f.manifests.Locate(o.fixes[0].Finding); ok {
+				name = fmt.Sprintf("%s:%d (%s)", source.File, source.Line, name)
+			}
+		}
+
+		switch {
+		case fixDryRun:
+			err = audit.WriteManifestDiff(w, name, o.original, o.patched)
+		case fixApply:
+			if err = audit.ApplyFixes(ctx, f.dynamic, o.original, o.fixes, fixFieldManager, fixForce); err != nil {
+				return fmt.Errorf("failed to apply fixes to %s: %w", name, err)
+			}
+			for _, fix := range o.fixes {
+				slog.Info("Applied fix", "object", name, "fix", fix.Description)
+			}
+		case f.manifests != nil:
+			if i > 0 {
+				fmt.Fprintln(w, "---")
+			}
+			fmt.Fprintf(w, "# %s\n", name)
+			err = audit.WriteManifest(w, o.patched)
+		default:
+			if i > 0 {
+				fmt.Fprintln(w, "---")
+			}
+			err = audit.WriteFixes(w, o.fixes)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func init() {
+	fixCmd.Flags().StringVarP(&namespace, "namespace", "n", "", "Namespace to fix (leave empty for all)")
+	fixCmd.Flags().StringSliceVarP(&manifests, "manifests", "f", nil, "Fix these manifest files or directories instead of a cluster, printing the patched objects")
+	fixCmd.Flags().StringVar(&cluster, "cluster", "", "Cluster name of the findings (default: current kubeconfig cluster)")
+	fixCmd.Flags().StringSliceVar(&fixRules, "rule", nil, "Only fix the findings of these checks (default: every check with a fix, except privileged-container)")
+	fixCmd.Flags().StringVar(&dbPath, "db", "audit.db", "SQLite database path or DSN, only read for its exceptions")
+	fixCmd.Flags().BoolVar(&fixDryRun, "dry-run", false, "Print the diff of each object instead of the patches")
+	fixCmd.Flags().BoolVar(&fixApply, "apply", false, "Apply the patches in the cluster with server-side apply")
+	fixCmd.Flags().StringVar(&fixFieldManager, "field-manager", "cluster-auditor", "Field manager of the fields set by --apply")
+	fixCmd.Flags().BoolVar(&fixForce, "force-conflicts", false, "With --apply, take over fields owned by other field managers")
+	fixCmd.Flags().StringToStringVar(&fixRequests, "default-requests", nil, "Requests added to containers without any, set over the defaults cpu=100m,memory=128Mi")
+	fixCmd.Flags().StringToStringVar(&fixLimits, "default-limits", nil, "Limits added to containers without any, set over the defaults cpu=500m,memory=256Mi")
+	addLoggingFlags(fixCmd)
+	rootCmd.AddCommand(fixCmd)
+}
@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+
+	"goprojects/cluster-auditor/internal/logging"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	logLevel  string
+	logFormat string
+)
+
+func addLoggingFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&logLevel, "log-level", "info", "Log level: debug, info, warn or error")
+	cmd.Flags().StringVar(&logFormat, "log-format", "text", "Log format: text or json")
+}
+
+// setupLogging installs the logger configured by the flags as the default, which
+// also receives the output of the standard log package
+func setupLogging() error {
+	logger, err := logging.New(os.Stderr, logLevel, logFormat)
+	if err != nil {
+		return err
+	}
+	slog.SetDefault(logger)
+	return nil
+}
+
+// reportError prints the error a command returned. Multi-line errors, like the
+// list of failed checks, are printed as they are rather than as one log attribute.
+func reportError(err error) {
+	if err == nil {
+		return
+	}
+	if msg := err.Error(); strings.Contains(msg, "\n") {
+		fmt.Fprintln(os.Stderr, "Error:", msg)
+		return
+	}
+	slog.Error("Command failed", "err", err)
+}
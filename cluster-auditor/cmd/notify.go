@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"time"
+
+	"goprojects/cluster-auditor/internal/audit"
+	"goprojects/cluster-auditor/internal/notify"
+	"goprojects/findings"
+	"goprojects/services/server"
+
+	"github.com/spf13/cobra"
+	"k8s.io/client-go/kubernetes"
+)
+
+var (
+	notifyConfig string
+	emitEvents   bool
+)
+
+func addNotifyFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&notifyConfig, "notify-config", "", "YAML file configuring webhook, Slack and email notifications about new findings")
+	cmd.Flags().BoolVar(&emitEvents, "events", false, "Record a Warning Event on the object of each new finding, shown by kubectl describe")
+}
+
+// alerter tells about findings as they open, through the notifier and events
+// enabled by the flags. Findings suppressed by an exception are left out.
+type alerter struct {
+	db       *sql.DB
+	notifier *notify.Notifier
+	events   *notify.EventEmitter
+	queued   bool // notifications are sent by a background goroutine
+}
+
+// newAlerter returns the alerter configured by the flags, nil if all are disabled
+func newAlerter(db *sql.DB, clientset kubernetes.Interface) (*alerter, error) {
+	a := &alerter{db: db}
+	if notifyConfig != "" {
+		var err error
+		if a.notifier, err = notify.LoadConfig(notifyConfig); err != nil {
+			return nil, err
+		}
+	}
+	if emitEvents {
+		client, err := audit.GetDynamicClient()
+		if err != nil {
+			return nil, err
+		}
+		a.events = &notify.EventEmitter{Client: clientset, Dynamic: client}
+	}
+	if a.notifier == nil && a.events == nil {
+		return nil, nil
+	}
+	return a, nil
+}
+
+// background sends notifications from a goroutine until ctx is done, so long
+// running modes are not blocked by slow sinks, and resends the ones that failed
+func (a *alerter) background(ctx context.Context) {
+	if a == nil || a.notifier == nil {
+		return
+	}
+	a.queued = true
+	go a.notifier.Run(ctx)
+}
+
+// opened alerts about the findings that opened in a cluster, logging failures
+func (a *alerter) opened(ctx context.Context, clusterName string, fs []findings.Finding) {
+	if a == nil || len(fs) == 0 {
+		return
+	}
+	exceptions, err := server.ListExceptions(ctx, a.db, false)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to load exceptions, not alerting about new findings", "err", err)
+		return
+	}
+	fs = findings.ApplyExceptions(fs, exceptions, time.Now())
+	if a.queued {
+		a.notifier.Enqueue(clusterName, fs)
+	} else {
+		if err := a.notifier.Notify(ctx, clusterName, fs); err != nil {
+			slog.ErrorContext(ctx, "Failed to send notifications", "err", err)
+		}
+		if pending := a.notifier.Pending(); pending > 0 {
+			slog.WarnContext(ctx, "Notifications not sent are lost when the audit exits", "findings", pending)
+		}
+	}
+	if err := a.events.Emit(ctx, fs); err != nil {
+		slog.ErrorContext(ctx, "Failed to record events", "err", err)
+	}
+}
@@ -0,0 +1,274 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+	"text/template"
+	"time"
+
+	"goprojects/cluster-auditor/internal/audit"
+	"goprojects/findings"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+var (
+	outputFormats   []string
+	noColor         bool
+	markdownMaxSize int
+	templateSpec    string
+
+	outputTemplate *template.Template // parsed from --template
+)
+
+// fileFormats are the output formats written to a file, with their default file
+// names. The other formats are printed on stdout.
+var fileFormats = map[string]string{
+	"json":     "audit_report.json",
+	"yaml":     "audit_report.yaml",
+	"sarif":    "audit_report.sarif",
+	"junit":    "audit_report.xml",
+	"html":     "audit_report.html",
+	"markdown": "audit_report.md",
+	"csv":      "audit_report.csv",
+	"ndjson":   "audit_report.ndjson",
+}
+
+// streamFormats write each finding as soon as its check adds it
+var streamFormats = map[string]func(io.Writer) audit.FindingWriter{
+	"csv":    audit.NewCSVWriter,
+	"ndjson": audit.NewNDJSONWriter,
+}
+
+var outputFormatNames = []string{"table", "wide", "json", "yaml", "sarif", "junit", "html", "markdown", "csv", "ndjson", "template"}
+
+func addOutputFlags(cmd *cobra.Command) {
+	cmd.Flags().StringSliceVar(&outputFormats, "output-format", []string{"table"},
+		"Output formats, comma separated: "+strings.Join(outputFormatNames, ", ")+". Tables are printed, the others written to files")
+	cmd.Flags().StringVarP(&outputFile, "output", "o", "", "Output file, for a single file format (default: audit_report.<ext>), - streams csv or ndjson to stdout")
+	cmd.Flags().BoolVar(&noColor, "no-color", false, "Do not color the table (also set by NO_COLOR)")
+	cmd.Flags().IntVar(&markdownMaxSize, "markdown-max-size", audit.DefaultMarkdownMaxSize,
+		"Maximum size in bytes of the Markdown output, the least severe findings are left out to fit (0 for no limit)")
+	cmd.Flags().StringVar(&templateSpec, "template", "",
+		"Render the report through this text/template file, or a built-in template: "+builtinTemplateNames()+". Printed unless --output is set")
+
+	// The boolean flags predate --output-format and select the same formats
+	cmd.Flags().BoolVarP(&outputJSON, "json", "j", false, "Output findings as JSON")
+	cmd.Flags().BoolVarP(&outputYAML, "yaml", "y", false, "Output findings as YAML")
+	cmd.Flags().BoolVar(&outputSARIF, "sarif", false, "Output findings as SARIF 2.1.0, for code scanning")
+	cmd.Flags().BoolVar(&outputJUnit, "junit", false, "Output the results of each check as JUnit XML, for CI test reports")
+	cmd.Flags().BoolVar(&outputHTML, "html", false, "Output a self-contained HTML report that works offline")
+	for _, name := range []string{"json", "yaml", "sarif", "junit", "html"} {
+		cmd.Flags().MarkDeprecated(name, "use --output-format="+name+" instead")
+	}
+}
+
+func builtinTemplateNames() string {
+	var names []string
+	for _, name := range audit.BuiltinTemplates() {
+		names = append(names, audit.BuiltinTemplatePrefix+name)
+	}
+	return strings.Join(names, ", ")
+}
+
+// selectedFormats returns the requested output formats. The deprecated boolean
+// flags replace the default table, as they did before it existed.
+func selectedFormats(cmd *cobra.Command) ([]string, error) {
+	var formats []string
+	if cmd.Flags().Changed("output-format") {
+		formats = slices.Clone(outputFormats)
+	}
+	for name, set := range map[string]bool{"json": outputJSON, "yaml": outputYAML, "sarif": outputSARIF, "junit": outputJUnit, "html": outputHTML} {
+		if set {
+			formats = append(formats, name)
+		}
+	}
+	if templateSpec != "" {
+		formats = append(formats, "template")
+	}
+	if len(formats) == 0 {
+		formats = outputFormats
+	}
+	slices.SortStableFunc(formats, func(a, b string) int {
+		return slices.Index(outputFormatNames, a) - slices.Index(outputFormatNames, b)
+	})
+	formats = slices.Compact(formats)
+
+	files := 0
+	for _, f := range formats {
+		if !slices.Contains(outputFormatNames, f) {
+			return nil, fmt.Errorf("unknown output format %q (want %s)", f, strings.Join(outputFormatNames, ", "))
+		}
+		if _, ok := fileFormats[f]; ok || (f == "template" && outputFile != "") {
+			files++
+		}
+	}
+	if outputFile != "" && files > 1 {
+		return nil, fmt.Errorf("--output names one file but %d file formats were requested", files)
+	}
+	if outputFile == "-" {
+		if _, ok := streamFormats[formats[0]]; len(formats) > 1 || !ok && formats[0] != "template" {
+			return nil, fmt.Errorf("--output - prints a single csv, ndjson or template output")
+		}
+	}
+	if slices.Contains(formats, "template") {
+		if templateSpec == "" {
+			return nil, fmt.Errorf("the template output needs --template")
+		}
+		t, err := audit.LoadTemplate(templateSpec)
+		if err != nil {
+			return nil, err
+		}
+		outputTemplate = t
+	}
+	return formats, nil
+}
+
+// reported tells whether a finding is reported, all findings are still stored
+func reported(f findings.Finding) bool {
+	return team == "" || f.Team == team
+}
+
+// findingStreams writes the findings of the stream formats as checks add them
+type findingStreams struct {
+	writers []audit.FindingWriter
+	files   []*os.File
+	formats []string
+}
+
+// openStreams opens the output of every selected stream format
+func openStreams(stdout io.Writer, formats []string) (*findingStreams, error) {
+	s := &findingStreams{}
+	for _, format := range formats {
+		newWriter, ok := streamFormats[format]
+		if !ok {
+			continue
+		}
+		if outputFile == "-" {
+			s.writers = append(s.writers, newWriter(stdout))
+			s.formats = append(s.formats, format)
+			continue
+		}
+		filename := outputFile
+		if filename == "" {
+			filename = fileFormats[format]
+		}
+		file, err := os.Create(filename)
+		if err != nil {
+			s.close()
+			return nil, fmt.Errorf("failed to create %s file: %w", format, err)
+		}
+		s.writers = append(s.writers, newWriter(file))
+		s.files = append(s.files, file)
+		s.formats = append(s.formats, format)
+	}
+	return s, nil
+}
+
+// write writes a finding if it is reported. Write errors stick to the writers
+// and are reported by close.
+func (s *findingStreams) write(f findings.Finding) {
+	if !reported(f) {
+		return
+	}
+	for _, w := range s.writers {
+		w.Write(f)
+		w.Flush()
+	}
+}
+
+// all reports whether every format is streamed, so no output needs the report
+func (s *findingStreams) all(formats []string) bool {
+	return len(s.formats) == len(formats)
+}
+
+func (s *findingStreams) close() error {
+	var errs []error
+	for i, w := range s.writers {
+		if err := w.Flush(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to write %s audit report: %w", s.formats[i], err))
+		}
+	}
+	for _, file := range s.files {
+		if err := file.Close(); err != nil {
+			errs = append(errs, err)
+		}
+		slog.Info("Wrote audit report", "file", file.Name())
+	}
+	return errors.Join(errs...)
+}
+
+// tableOptions colors and fits the table to stdout when it is a terminal
+func tableOptions(wide bool) audit.TableOptions {
+	opts := audit.TableOptions{Wide: wide}
+	fd := int(os.Stdout.Fd())
+	if !term.IsTerminal(fd) {
+		return opts
+	}
+	opts.Color = !noColor && os.Getenv("NO_COLOR") == ""
+	if width, _, err := term.GetSize(fd); err == nil {
+		opts.Width = width
+	}
+	return opts
+}
+
+// writeOutputs prints or writes the report in every selected format, without the
+// findings covered by exceptions. The health score is computed once for all of them.
+func writeOutputs(stdout io.Writer, formats []string, report *audit.Report, locate func(findings.Finding) (audit.ManifestSource, bool), exceptions []findings.Exception) error {
+	excepted := report.Except(exceptions, time.Now())
+	if excepted > 0 {
+		slog.Info("Left findings covered by exceptions out of the report", "findings", excepted)
+	}
+	score := audit.ScoreReport(report, excepted)
+	reportFindings := report.Findings()
+	for _, format := range formats {
+		if _, ok := streamFormats[format]; ok {
+			continue // written while auditing
+		}
+		if format == "table" || format == "wide" {
+			if err := audit.WriteTable(stdout, report, score, tableOptions(format == "wide")); err != nil {
+				return fmt.Errorf("failed to print audit report: %w", err)
+			}
+			continue
+		}
+		if format == "template" && (outputFile == "" || outputFile == "-") {
+			if err := audit.WriteTemplate(stdout, outputTemplate, report, score, tableOptions(false).Color); err != nil {
+				return fmt.Errorf("failed to render template: %w", err)
+			}
+			continue
+		}
+
+		filename := outputFile
+		if filename == "" {
+			filename = fileFormats[format]
+		}
+		var err error
+		switch format {
+		case "json":
+			err = audit.OutputFindingsAsJSON(reportFindings, filename)
+		case "yaml":
+			err = audit.OutputFindingsAsYAML(reportFindings, filename)
+		case "sarif":
+			err = audit.OutputFindingsAsSARIF(reportFindings, filename, locate)
+		case "junit":
+			err = audit.OutputReportAsJUnit(report, filename)
+		case "html":
+			err = audit.OutputReportAsHTML(report, score, filename)
+		case "markdown":
+			err = audit.OutputReportAsMarkdown(report, score, filename, markdownMaxSize)
+		case "template":
+			err = audit.OutputReportWithTemplate(report, score, outputTemplate, filename)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to write %s audit report: %w", format, err)
+		}
+		slog.Info("Wrote audit report", "format", format, "file", filename)
+	}
+	return nil
+}
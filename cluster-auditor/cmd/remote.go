@@ -0,0 +1,229 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"goprojects/findings"
+	"goprojects/services/client"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	remoteOpts     client.Options
+	remoteOutput   string
+	remoteStream   bool
+	remoteFilter   client.Filter
+	remoteSeverity string
+)
+
+var remoteCmd = &cobra.Command{
+	Use:   "remote",
+	Short: "Query and control a running auditor server over gRPC",
+	Long: `Query and control a running auditor server over gRPC, without direct access
+to its database or to the cluster. Every flag can also be set through its
+AUDITOR_* environment variable, e.g. AUDITOR_TOKEN.`,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		// Errors from here on are about the call, not the usage; Execute reports them
+		cmd.SilenceUsage, cmd.SilenceErrors = true, true
+		return applyEnvOverrides(cmd.Flags())
+	},
+}
+
+func newRemoteClient() (*client.Client, error) {
+	return client.New(remoteOpts)
+}
+
+var remoteFindingsCmd = &cobra.Command{
+	Use:   "findings",
+	Short: "List the open findings of a server",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if remoteSeverity != "" {
+			sev, err := findings.ParseSeverity(remoteSeverity)
+			if err != nil {
+				return err
+			}
+			remoteFilter.MinSeverity = sev
+		}
+		c, err := newRemoteClient()
+		if err != nil {
+			return err
+		}
+		defer c.Close()
+		ctx := context.Background()
+
+		// Table rows are not kept as findings, but the tabwriter still holds them until
+		// the stream ends to align the columns. The other formats need the full list.
+		if remoteStream && remoteOutput == "table" {
+			w := newFindingsTable(cmd.OutOrStdout())
+			err := c.Stream(ctx, remoteFilter, func(f findings.Finding) error {
+				writeFindingRow(w, f)
+				return nil
+			})
+			w.Flush()
+			return err
+		}
+
+		var fs []findings.Finding
+		if remoteStream {
+			err = c.Stream(ctx, remoteFilter, func(f findings.Finding) error {
+				fs = append(fs, f)
+				return nil
+			})
+		} else {
+			fs, err = c.Findings(ctx, remoteFilter)
+		}
+		if err != nil {
+			return err
+		}
+		return printRemote(cmd.OutOrStdout(), fs, func(w io.Writer) {
+			t := newFindingsTable(w)
+			for _, f := range fs {
+				writeFindingRow(t, f)
+			}
+			t.Flush()
+		})
+	},
+}
+
+var remoteScoreCmd = &cobra.Command{
+	Use:   "score",
+	Short: "Show the health score of a server",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := newRemoteClient()
+		if err != nil {
+			return err
+		}
+		defer c.Close()
+
+		score, err := c.HealthScore(context.Background())
+		if err != nil {
+			return err
+		}
+		out := struct {
+			Score  float32 `json:"score" yaml:"score"`
+			Status string  `json:"status" yaml:"status"`
+		}{score.GetScore(), score.GetStatus()}
+		return printRemote(cmd.OutOrStdout(), out, func(w io.Writer) {
+			fmt.Fprintf(w, "Health score: %.1f (%s)\n", out.Score, out.Status)
+		})
+	},
+}
+
+var remoteTeamsCmd = &cobra.Command{
+	Use:   "teams",
+	Short: "Show the health score of each team",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := newRemoteClient()
+		if err != nil {
+			return err
+		}
+		defer c.Close()
+
+		scores, err := c.TeamScores(context.Background(), remoteFilter.Team)
+		if err != nil {
+			return err
+		}
+		type teamScore struct {
+			Team         string  `json:"team" yaml:"team"`
+			Score        float32 `json:"score" yaml:"score"`
+			Status       string  `json:"status" yaml:"status"`
+			OpenFindings int32   `json:"openFindings" yaml:"openFindings"`
+		}
+		out := make([]teamScore, 0, len(scores))
+		for _, s := range scores {
+			out = append(out, teamScore{s.GetTeam(), s.GetScore(), s.GetStatus(), s.GetOpenFindings()})
+		}
+		return printRemote(cmd.OutOrStdout(), out, func(w io.Writer) {
+			t := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(t, "TEAM\tSCORE\tSTATUS\tOPEN FINDINGS")
+			for _, s := range out {
+				fmt.Fprintf(t, "%s\t%.1f\t%s\t%d\n", s.Team, s.Score, s.Status, s.OpenFindings)
+			}
+			t.Flush()
+		})
+	},
+}
+
+var remoteTriggerCmd = &cobra.Command{
+	Use:   "trigger",
+	Short: "Start an audit on a server",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := newRemoteClient()
+		if err != nil {
+			return err
+		}
+		defer c.Close()
+
+		resp, err := c.TriggerAudit(context.Background(), remoteFilter.Namespace)
+		if err != nil {
+			return err
+		}
+		if !resp.GetAccepted() {
+			return fmt.Errorf("audit not started: %s", resp.GetMessage())
+		}
+		fmt.Fprintln(cmd.OutOrStdout(), resp.GetMessage())
+		return nil
+	},
+}
+
+// printRemote writes v as JSON or YAML, or calls table for the table output
+func printRemote(w io.Writer, v any, table func(io.Writer)) error {
+	switch remoteOutput {
+	case "table":
+		table(w)
+		return nil
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	case "yaml":
+		return yaml.NewEncoder(w).Encode(v)
+	}
+	return fmt.Errorf("unknown output %q (want table, json or yaml)", remoteOutput)
+}
+
+func newFindingsTable(w io.Writer) *tabwriter.Writer {
+	t := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(t, "SEVERITY\tCLUSTER\tNAMESPACE\tTEAM\tKIND\tRESOURCE\tRULE\tISSUE")
+	return t
+}
+
+func writeFindingRow(t *tabwriter.Writer, f findings.Finding) {
+	issue := strings.ReplaceAll(f.Issue, "\n", " ")
+	fmt.Fprintf(t, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", f.Severity, f.Cluster, f.Namespace, f.Team, f.Kind, f.Resource, f.RuleID, issue)
+}
+
+func init() {
+	pf := remoteCmd.PersistentFlags()
+	pf.StringVar(&remoteOpts.Address, "server", "localhost:50051", "Address of the auditor gRPC server")
+	pf.StringVar(&remoteOpts.Token, "token", "", "API token (prefer AUDITOR_TOKEN, flags are visible in the process list)")
+	pf.BoolVar(&remoteOpts.TLS, "tls", false, "Connect with TLS")
+	pf.StringVar(&remoteOpts.CAFile, "ca-file", "", "CA bundle verifying the server certificate (implies --tls)")
+	pf.StringVar(&remoteOpts.CertFile, "cert", "", "Client certificate for mutual TLS (implies --tls)")
+	pf.StringVar(&remoteOpts.KeyFile, "key", "", "Client private key for mutual TLS")
+	pf.StringVar(&remoteOpts.ServerName, "server-name", "", "Name to verify in the server certificate")
+	pf.BoolVar(&remoteOpts.InsecureSkipVerify, "insecure-skip-verify", false, "Do not verify the server certificate (implies --tls)")
+	pf.DurationVar(&remoteOpts.Timeout, "timeout", 30*time.Second, "Deadline of each call")
+	pf.IntVar(&remoteOpts.Retries, "retries", 3, "Retries of calls that fail because the server is unavailable")
+	pf.StringVarP(&remoteOutput, "output", "o", "table", "Output format: table, json or yaml")
+
+	remoteFindingsCmd.Flags().StringVarP(&remoteFilter.Namespace, "namespace", "n", "", "Only findings in this namespace")
+	remoteFindingsCmd.Flags().StringVar(&remoteFilter.Cluster, "cluster", "", "Only findings of this cluster")
+	remoteFindingsCmd.Flags().StringVar(&remoteFilter.RuleID, "rule", "", "Only findings of this rule")
+	remoteFindingsCmd.Flags().StringVar(&remoteFilter.Team, "team", "", "Only findings in the namespaces of this team")
+	remoteFindingsCmd.Flags().StringVar(&remoteSeverity, "min-severity", "", "Only findings at least this severe: critical, high, medium, low or info")
+	remoteFindingsCmd.Flags().BoolVar(&remoteStream, "stream", false, "Stream findings instead of paging through them")
+	remoteTeamsCmd.Flags().StringVar(&remoteFilter.Team, "team", "", "Only this team (leave empty for every team with open findings)")
+	remoteTriggerCmd.Flags().StringVarP(&remoteFilter.Namespace, "namespace", "n", "", "Namespace to audit (leave empty for all)")
+
+	remoteCmd.AddCommand(remoteFindingsCmd, remoteScoreCmd, remoteTeamsCmd, remoteTriggerCmd)
+	rootCmd.AddCommand(remoteCmd)
+}
@@ -0,0 +1,325 @@
+package cmd
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"goprojects/cluster-auditor/internal/audit"
+	"goprojects/services/generated/auditorpb"
+	"goprojects/services/server"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+)
+
+// envPrefix is prepended to flag names to build their environment overrides,
+// e.g. --tls-cert can be set with AUDITOR_TLS_CERT
+const envPrefix = "AUDITOR_"
+
+var (
+	listenAddr    string
+	httpAddr      string
+	metricsAddr   string
+	tlsCert       string
+	tlsKey        string
+	tlsClientCA   string
+	authTokenFile string
+	enableReflect bool
+	drainTimeout  time.Duration
+)
+
+func newServerCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "server",
+		Short: "Serve stored findings over gRPC",
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if err := applyEnvOverrides(cmd.Flags()); err != nil {
+				return err
+			}
+			if tlsClientCA != "" && tlsCert == "" {
+				return errors.New("--tls-client-ca requires --tls-cert, mutual TLS needs a server certificate")
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runServer()
+		},
+		SilenceUsage: true,
+	}
+
+	cmd.Flags().StringVar(&listenAddr, "addr", ":50051", "Address the gRPC server listens on")
+	cmd.Flags().StringVar(&httpAddr, "http-addr", "", "Address of the REST/JSON gateway, e.g. :8080 (disabled when empty)")
+	cmd.Flags().StringVar(&metricsAddr, "metrics-addr", "", "Address serving Prometheus metrics on /metrics without authentication, e.g. localhost:9090 (disabled when empty)")
+	cmd.Flags().StringVar(&dbPath, "db", "audit.db", "SQLite database path or DSN")
+	cmd.Flags().StringVar(&cluster, "cluster", "", "Cluster name stored with triggered audits' findings (default: current kubeconfig cluster)")
+	cmd.Flags().StringVar(&tlsCert, "tls-cert", "", "Server TLS certificate file (enables TLS)")
+	cmd.Flags().StringVar(&tlsKey, "tls-key", "", "Server TLS private key file")
+	cmd.Flags().StringVar(&tlsClientCA, "tls-client-ca", "", "CA bundle used to verify client certificates (enables mutual TLS)")
+	cmd.Flags().StringVar(&authTokenFile, "auth-token-file", "", "Static token file with token,name,role[,team] lines (enables token auth, team limits a token to that team's findings)")
+	cmd.Flags().BoolVar(&enableReflect, "reflection", false, "Register the gRPC reflection service")
+	cmd.Flags().DurationVar(&drainTimeout, "drain-timeout", 25*time.Second, "How long in-flight RPCs may run after SIGTERM before they are cancelled")
+	addTeamFlags(cmd)
+	addNotifyFlags(cmd)
+	addLoggingFlags(cmd)
+	addTracingFlags(cmd)
+	return cmd
+}
+
+// applyEnvOverrides sets every flag that was not given on the command line from
+// its AUDITOR_* environment variable, so flags win over the environment
+func applyEnvOverrides(flags *pflag.FlagSet) error {
+	var err error
+	flags.VisitAll(func(f *pflag.Flag) {
+		if f.Changed || err != nil {
+			return
+		}
+		env := envPrefix + strings.ToUpper(strings.ReplaceAll(f.Name, "-", "_"))
+		if v, ok := os.LookupEnv(env); ok {
+			if setErr := flags.Set(f.Name, v); setErr != nil {
+				err = fmt.Errorf("invalid value for %s: %w", env, setErr)
+			}
+		}
+	})
+	return err
+}
+
+func runServer() error {
+	if err := setupLogging(); err != nil {
+		return err
+	}
+
+	flushTraces, err := setupTracing(context.Background())
+	if err != nil {
+		return err
+	}
+	defer flushTraces()
+
+	db, err := server.InitDB(dbPath)
+	if err != nil {
+		return fmt.Errorf("failed to open DB: %w", err)
+	}
+	defer db.Close()
+
+	var tlsConfig *tls.Config
+	if tlsCert != "" {
+		tlsConfig, err = server.ServerTLSConfig(tlsCert, tlsKey, tlsClientCA)
+		if err != nil {
+			return err
+		}
+	} else {
+		slog.Warn("TLS is disabled, findings are served in plaintext")
+	}
+
+	registry := prometheus.NewRegistry()
+	grpcMetrics := server.NewGRPCMetrics()
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		&server.MetricsCollector{DB: db},
+		grpcMetrics,
+	)
+
+	// Interceptors are shared by the network server and the in-process server
+	// behind the REST gateway, so both enforce the same rules. Metrics come first
+	// so rejected calls are counted too.
+	// Server spans continue the caller's trace, and triggered audits continue the
+	// RPC's trace, since they keep the request context's values
+	interceptors := []grpc.ServerOption{
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
+		grpc.ChainUnaryInterceptor(grpcMetrics.UnaryInterceptor()),
+		grpc.ChainStreamInterceptor(grpcMetrics.StreamInterceptor()),
+	}
+	if authTokenFile != "" {
+		auth, err := server.LoadTokenFile(authTokenFile)
+		if err != nil {
+			return err
+		}
+		interceptors = append(interceptors,
+			grpc.ChainUnaryInterceptor(auth.UnaryInterceptor()),
+			grpc.ChainStreamInterceptor(auth.StreamInterceptor()),
+		)
+	} else {
+		slog.Warn("token authentication is disabled, every caller has full access")
+	}
+
+	srv := &server.AuditorServer{DB: db}
+	defer srv.Wait() // triggered audits store their findings before the DB is closed
+	var alerts *alerter
+	if clientset, err := audit.GetKubernetesClient(); err != nil {
+		slog.Warn("no Kubernetes client, triggering audits is disabled", "err", err)
+	} else {
+		teams, err := newTeamResolver(clientset)
+		if err != nil {
+			return err
+		}
+		if alerts, err = newAlerter(db, clientset); err != nil {
+			return err
+		}
+		checks, err := audit.SelectChecks(nil)
+		if err != nil {
+			return err
+		}
+		srv.RunAudit = func(ctx context.Context, namespace string) error {
+			report := auditAndStore(ctx, db, clientset, teams, alerts, checks, clusterName(), namespace, nil, false)
+			return errors.Join(report.Errors()...)
+		}
+	}
+
+	opts := append([]grpc.ServerOption{}, interceptors...)
+	if tlsConfig != nil {
+		opts = append(opts, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	}
+	grpcServer := grpc.NewServer(opts...)
+	auditorpb.RegisterClusterAuditorServer(grpcServer, srv)
+
+	healthServer := health.NewServer()
+	healthpb.RegisterHealthServer(grpcServer, healthServer)
+	healthServer.SetServingStatus(auditorpb.ClusterAuditor_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_SERVING)
+
+	if enableReflect {
+		reflection.Register(grpcServer)
+	}
+
+	listener, err := net.Listen("tcp", listenAddr)
+	if err != nil {
+		return fmt.Errorf("failed to listen: %w", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	alerts.background(ctx)
+
+	// Listeners failing stop the server, which then exits with their errors
+	var listenErrs []error
+	var listenErrsMu sync.Mutex
+	listenFailed := func(err error) {
+		listenErrsMu.Lock()
+		defer listenErrsMu.Unlock()
+		listenErrs = append(listenErrs, err)
+		stop()
+	}
+
+	var httpServer *http.Server
+	if httpAddr != "" {
+		gateway, closeGateway, err := server.NewGateway(ctx, srv, interceptors...)
+		if err != nil {
+			return err
+		}
+		defer closeGateway()
+
+		httpServer = &http.Server{
+			Addr:              httpAddr,
+			Handler:           gateway,
+			TLSConfig:         tlsConfig,
+			ReadHeaderTimeout: 10 * time.Second,
+		}
+		go func() {
+			var err error
+			if tlsConfig != nil {
+				err = httpServer.ListenAndServeTLS("", "")
+			} else {
+				err = httpServer.ListenAndServe()
+			}
+			if err != nil && !errors.Is(err, http.ErrServerClosed) {
+				listenFailed(fmt.Errorf("REST gateway failed: %w", err))
+			}
+		}()
+		slog.Info("REST gateway listening", "addr", httpAddr)
+	}
+
+	var metricsServer *http.Server
+	if metricsAddr != "" {
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
+		metricsServer = &http.Server{
+			Addr:              metricsAddr,
+			Handler:           mux,
+			ReadHeaderTimeout: 10 * time.Second,
+		}
+		go func() {
+			if err := metricsServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				listenFailed(fmt.Errorf("metrics server failed: %w", err))
+			}
+		}()
+		slog.Info("metrics listening", "addr", metricsAddr)
+	}
+
+	drained := make(chan struct{})
+	go func() {
+		defer close(drained)
+		<-ctx.Done()
+		slog.Info("shutting down, draining in-flight RPCs", "timeout", drainTimeout)
+		healthServer.Shutdown()
+
+		drainCtx, cancel := context.WithTimeout(context.Background(), drainTimeout)
+		defer cancel()
+		if httpServer != nil {
+			if err := httpServer.Shutdown(drainCtx); err != nil {
+				slog.Warn("REST gateway did not drain in time", "err", err)
+			}
+		}
+		if metricsServer != nil {
+			if err := metricsServer.Shutdown(drainCtx); err != nil {
+				slog.Warn("metrics server did not drain in time", "err", err)
+			}
+		}
+
+		done := make(chan struct{})
+		go func() {
+			grpcServer.GracefulStop()
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-drainCtx.Done():
+			slog.Warn("drain timeout exceeded, cancelling remaining RPCs")
+			grpcServer.Stop()
+		}
+	}()
+
+	slog.Info("gRPC server listening", "addr", listener.Addr().String(), "db", dbPath, "tls", tlsCert != "", "reflection", enableReflect)
+	if err := grpcServer.Serve(listener); err != nil {
+		return fmt.Errorf("failed to serve: %w", err)
+	}
+	<-drained
+	listenErrsMu.Lock()
+	defer listenErrsMu.Unlock()
+	if len(listenErrs) > 0 {
+		return errors.Join(listenErrs...)
+	}
+	slog.Info("server stopped")
+	return nil
+}
+
+// ExecuteServer runs the server command on its own, for the standalone server binary
+func ExecuteServer() error {
+	cmd := newServerCmd()
+	cmd.SilenceErrors = true // reported here
+	err := cmd.Execute()
+	reportError(err)
+	return err
+}
+
+func init() {
+	rootCmd.AddCommand(newServerCmd())
+}
@@ -0,0 +1,31 @@
+package cmd
+
+import (
+	"goprojects/cluster-auditor/internal/audit"
+
+	"github.com/spf13/cobra"
+	"k8s.io/client-go/kubernetes"
+)
+
+var (
+	teamKey         string
+	teamMappingFile string
+)
+
+func addTeamFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&teamKey, "team-label", audit.DefaultTeamKey, "Namespace label or annotation naming the team that owns the namespace")
+	cmd.Flags().StringVar(&teamMappingFile, "team-mapping", "", "YAML file mapping teams to namespace names or patterns, takes precedence over the label")
+}
+
+// newTeamResolver resolves the owning teams of namespaces as configured by the flags
+func newTeamResolver(clientset kubernetes.Interface) (*audit.TeamResolver, error) {
+	resolver := &audit.TeamResolver{Client: clientset, Key: teamKey}
+	if teamMappingFile != "" {
+		mapping, err := audit.LoadTeamMapping(teamMappingFile)
+		if err != nil {
+			return nil, err
+		}
+		resolver.Mapping = mapping
+	}
+	return resolver, nil
+}
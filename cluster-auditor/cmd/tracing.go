@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"goprojects/cluster-auditor/internal/tracing"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	traceExporter string
+	otlpEndpoint  string
+	otlpInsecure  bool
+)
+
+func addTracingFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&traceExporter, "trace-exporter", tracing.ExporterNone, "Export OpenTelemetry traces: none, otlp or stdout (printed to stderr)")
+	cmd.Flags().StringVar(&otlpEndpoint, "otlp-endpoint", "", "OTLP gRPC endpoint for traces, e.g. otel-collector:4317 (default: OTEL_EXPORTER_OTLP_ENDPOINT)")
+	cmd.Flags().BoolVar(&otlpInsecure, "otlp-insecure", false, "Connect to the OTLP endpoint without TLS")
+}
+
+// setupTracing configures tracing from the flags and returns the function that
+// flushes the remaining spans on exit
+func setupTracing(ctx context.Context) (func(), error) {
+	shutdown, err := tracing.Setup(ctx, tracing.Config{
+		Exporter:    traceExporter,
+		Endpoint:    otlpEndpoint,
+		Insecure:    otlpInsecure,
+		ServiceName: "cluster-auditor",
+	})
+	if err != nil {
+		return nil, err
+	}
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdown(ctx); err != nil {
+			slog.Warn("Failed to flush traces", "err", err)
+		}
+	}, nil
+}
@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"goprojects/cluster-auditor/internal/audit"
+	"goprojects/findings"
+	"goprojects/services/server"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	watchResync   time.Duration
+	watchDebounce time.Duration
+)
+
+var watchCmd = &cobra.Command{
+	Use:   "watch",
+	Short: "Continuously audit the cluster, re-running checks as resources change",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := setupLogging(); err != nil {
+			return fmt.Errorf("invalid logging flags: %w", err)
+		}
+
+		db, err := server.InitDB(dbPath)
+		if err != nil {
+			return fmt.Errorf("failed to init DB: %w", err)
+		}
+		defer db.Close()
+
+		checks, err := audit.SelectChecks(checkIDs)
+		if err != nil {
+			return err
+		}
+
+		clientset, err := audit.GetKubernetesClient()
+		if err != nil {
+			return fmt.Errorf("failed to get Kubernetes client: %w", err)
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		flushTraces, err := setupTracing(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to set up tracing: %w", err)
+		}
+		defer flushTraces()
+
+		teams, err := newTeamResolver(clientset)
+		if err != nil {
+			return fmt.Errorf("failed to load team mapping: %w", err)
+		}
+		alerts, err := newAlerter(db, clientset)
+		if err != nil {
+			return fmt.Errorf("failed to set up alerts: %w", err)
+		}
+		alerts.background(ctx)
+
+		clusterName := clusterName()
+		watcher := &audit.Watcher{
+			Client:    clientset,
+			Checks:    checks,
+			Cluster:   clusterName,
+			Namespace: namespace,
+			Resync:    watchResync,
+			Debounce:  watchDebounce,
+			Update: func(ns, checkID string, fs []findings.Finding) error {
+				teams.AssignTeams(ctx, fs)
+				opened, err := server.ReplaceFindings(db, clusterName, ns, checkID, fs)
+				if err != nil {
+					return err
+				}
+				alerts.opened(ctx, clusterName, opened)
+				return nil
+			},
+			Swept: func(report *audit.Report) { recordSweep(ctx, db, report) },
+		}
+
+		slog.Info("Watching cluster for changes", "cluster", clusterName, "namespace", namespace)
+		if err := watcher.Run(ctx); err != nil {
+			return fmt.Errorf("watch failed: %w", err)
+		}
+		return nil
+	},
+}
+
+// recordSweep records a sweep of the watcher as an audit run, so the runs, and
+// the metrics built from them, stay current in watch mode
+func recordSweep(ctx context.Context, db *sql.DB, report *audit.Report) {
+	run := server.Run{Cluster: report.Cluster, Namespace: report.Namespace, StartedAt: report.StartedAt, FinishedAt: report.FinishedAt}
+	if err := server.BeginRun(ctx, db, &run); err != nil {
+		slog.ErrorContext(ctx, "Failed to record audit run in DB", "err", err)
+		return
+	}
+	for _, cr := range report.Checks {
+		result := server.CheckRun{CheckID: cr.Check.ID, Duration: cr.Duration, Findings: len(cr.Findings)}
+		if cr.Err != nil {
+			result.Error = cr.Err.Error()
+		} else if err := server.RecordRunFindings(ctx, db, run.ID, run.Cluster, cr.Findings); err != nil {
+			slog.ErrorContext(ctx, "Failed to record run findings in DB", "err", err, "check_id", cr.Check.ID)
+		}
+		run.Checks = append(run.Checks, result)
+	}
+	if err := server.FinishRun(ctx, db, run); err != nil {
+		slog.ErrorContext(ctx, "Failed to record audit run in DB", "err", err)
+	}
+}
+
+func init() {
+	watchCmd.Flags().StringVarP(&namespace, "namespace", "n", "", "Namespace to watch (leave empty for all)")
+	watchCmd.Flags().StringVar(&dbPath, "db", "audit.db", "SQLite database path or DSN")
+	watchCmd.Flags().StringVar(&cluster, "cluster", "", "Cluster name stored with findings (default: current kubeconfig cluster)")
+	watchCmd.Flags().StringSliceVar(&checkIDs, "checks", nil, "IDs of the checks to keep up to date, or all (default: every check but the opt-in privileged-container and risky-rbac)")
+	watchCmd.Flags().DurationVar(&watchResync, "resync", 10*time.Minute, "Period of the full re-evaluations from the cache, recorded as audit runs, which also re-evaluate time based checks")
+	watchCmd.Flags().DurationVar(&watchDebounce, "debounce", 2*time.Second, "Delay used to coalesce bursts of changes before re-running checks")
+	addTeamFlags(watchCmd)
+	addNotifyFlags(watchCmd)
+	addLoggingFlags(watchCmd)
+	addTracingFlags(watchCmd)
+	rootCmd.AddCommand(watchCmd)
+}
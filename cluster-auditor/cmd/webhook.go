@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"goprojects/cluster-auditor/internal/audit"
+	"goprojects/cluster-auditor/internal/webhook"
+
+	"github.com/spf13/cobra"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+)
+
+var (
+	webhookAddr        string
+	webhookCertFile    string
+	webhookKeyFile     string
+	webhookDefaultMode string
+	webhookRuleModes   map[string]string
+	webhookDryRun      bool
+)
+
+var webhookCmd = &cobra.Command{
+	Use:   "webhook",
+	Short: "Serve the audit checks as a validating admission webhook",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := setupLogging(); err != nil {
+			return fmt.Errorf("invalid logging flags: %w", err)
+		}
+
+		defaultMode, err := webhook.ParseMode(webhookDefaultMode)
+		if err != nil {
+			return fmt.Errorf("invalid --default-mode: %w", err)
+		}
+		rules := make(map[string]webhook.Mode, len(webhookRuleModes))
+		for rule, m := range webhookRuleModes {
+			if _, ok := audit.LookupCheck(rule); !ok {
+				return fmt.Errorf("invalid --enforce: unknown check %q", rule)
+			}
+			mode, err := webhook.ParseMode(m)
+			if err != nil {
+				return fmt.Errorf("invalid --enforce for %s: %w", rule, err)
+			}
+			rules[rule] = mode
+		}
+
+		tlsConfig, err := webhook.LoadTLSConfig(webhookCertFile, webhookKeyFile)
+		if err != nil {
+			return fmt.Errorf("failed to load TLS certificate: %w", err)
+		}
+
+		flushTraces, err := setupTracing(context.Background())
+		if err != nil {
+			return fmt.Errorf("failed to set up tracing: %w", err)
+		}
+		defer flushTraces()
+
+		mux := http.NewServeMux()
+		mux.Handle("/validate", otelhttp.NewHandler(&webhook.Handler{Config: webhook.Config{
+			DefaultMode: defaultMode,
+			Rules:       rules,
+			DryRun:      webhookDryRun,
+		}}, "admission review"))
+		mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		})
+
+		srv := &http.Server{
+			Addr:              webhookAddr,
+			Handler:           mux,
+			TLSConfig:         tlsConfig,
+			ReadHeaderTimeout: 10 * time.Second,
+		}
+
+		slog.Info("Admission webhook listening", "addr", webhookAddr, "dry_run", webhookDryRun)
+		if err := srv.ListenAndServeTLS("", ""); err != nil {
+			return fmt.Errorf("webhook server failed: %w", err)
+		}
+		return nil
+	},
+}
+
+func init() {
+	webhookCmd.Flags().StringVar(&webhookAddr, "addr", ":8443", "Address the webhook listens on")
+	webhookCmd.Flags().StringVar(&webhookCertFile, "tls-cert", "/etc/webhook/certs/tls.crt", "TLS certificate file")
+	webhookCmd.Flags().StringVar(&webhookKeyFile, "tls-key", "/etc/webhook/certs/tls.key", "TLS private key file")
+	webhookCmd.Flags().StringVar(&webhookDefaultMode, "default-mode", "warn", "Enforcement mode for rules without an override: deny, warn or ignore")
+	webhookCmd.Flags().StringToStringVar(&webhookRuleModes, "enforce", nil, "Per-rule enforcement mode, e.g. --enforce risky-rbac=deny,latest-image-tag=warn")
+	webhookCmd.Flags().BoolVar(&webhookDryRun, "dry-run", false, "Audit only: never deny requests, report would-be denials as warnings")
+	addLoggingFlags(webhookCmd)
+	addTracingFlags(webhookCmd)
+	rootCmd.AddCommand(webhookCmd)
+}
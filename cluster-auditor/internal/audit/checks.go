@@ -0,0 +1,214 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"goprojects/findings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"k8s.io/client-go/kubernetes"
+)
+
+var tracer = otel.Tracer("goprojects/cluster-auditor/internal/audit")
+
+// CheckFunc is the signature shared by every audit check
+type CheckFunc func(context.Context, *findings.Auditor, kubernetes.Interface, string) error
+
+// Check describes a registered audit check. Kinds lists every resource kind the
+// check reads, so a change to any of them may change the check's findings.
+// Category, Description and Help document the rule in reports: Description is
+// one sentence on what the check detects, Help explains why it matters and how
+// to fix it. OptIn checks only run in an audit when selected explicitly.
+// PerNamespace checks report about namespaces, so across all namespaces they
+// report once for the whole cluster unless run for each namespace.
+type Check struct {
+	ID           string
+	Name         string
+	Severity     findings.Severity
+	Category     string // reliability, security, networking or storage
+	Kinds        []string
+	Run          CheckFunc
+	Description  string
+	Help         string
+	OptIn        bool
+	PerNamespace bool
+}
+
+var workloadKinds = []string{
+	string(Deployment), string(StatefulSet), string(DaemonSet), string(Job),
+	string(CronJob), string(ReplicaSet), string(Pod), string(ReplicationController),
+}
+
+// Registry holds all checks run by a full audit, in execution order
+var Registry = []Check{
+	{
+		ID: "missing-resource-limits", Name: "MissingResourceLimits", Severity: findings.SeverityMedium, Category: "reliability",
+		Kinds: []string{"Deployment"}, Run: CheckMissingResourceLimits,
+		Description: "Container has no CPU or memory requests or limits.",
+		Help: "Without requests the scheduler cannot place pods reliably, and without limits a single " +
+			"container can starve its node. Set `resources.requests` and `resources.limits` for CPU and " +
+			"memory on every container.",
+	},
+	{
+		ID: "missing-readiness-probe", Name: "MissingReadinessProbes", Severity: findings.SeverityLow, Category: "reliability",
+		Kinds: []string{"Deployment"}, Run: CheckMissingReadinessProbes,
+		Description: "Container has no readiness probe.",
+		Help: "Without a readiness probe a pod receives traffic as soon as it starts, before it can serve " +
+			"it, and keeps receiving it while unhealthy. Add a `readinessProbe` that checks the container " +
+			"can handle requests.",
+	},
+	{
+		ID: "missing-liveness-probe", Name: "MissingLivenessProbes", Severity: findings.SeverityLow, Category: "reliability",
+		Kinds: []string{"Deployment"}, Run: CheckMissingLivenessProbes,
+		Description: "Container has no liveness probe.",
+		Help: "Without a liveness probe a hung container is never restarted. Add a `livenessProbe` that " +
+			"fails when the process can no longer make progress.",
+	},
+	{
+		ID: "latest-image-tag", Name: "Docker tag check", Severity: findings.SeverityMedium, Category: "reliability",
+		Kinds: []string{"Deployment"}, Run: DockerTagCheck,
+		Description: "Container image uses the latest tag or no tag.",
+		Help: "Mutable tags make rollouts unreproducible and rollbacks unreliable, since the same manifest " +
+			"can run different images. Pin images to a version tag or a digest.",
+	},
+	{
+		ID: "hpa-replicas-conflict", Name: "HPA conflict check", Severity: findings.SeverityMedium, Category: "reliability",
+		Kinds: []string{"HorizontalPodAutoscaler", "Deployment", "StatefulSet"}, Run: CheckHPAConflict,
+		Description: "Workload sets spec.replicas while a HorizontalPodAutoscaler scales it.",
+		Help: "Every apply of the manifest resets the replica count chosen by the autoscaler, which can " +
+			"scale a loaded workload down. Remove `spec.replicas` from workloads targeted by an HPA.",
+	},
+	{
+		ID: "missing-network-policy", Name: "NetworkPolicy check", Severity: findings.SeverityMedium, Category: "networking",
+		Kinds: []string{"NetworkPolicy"}, Run: CheckMissingNetworkPolicy, PerNamespace: true,
+		Description: "Namespace has no NetworkPolicy.",
+		Help: "Without a NetworkPolicy every pod accepts traffic from anywhere in the cluster. Add a " +
+			"default deny policy to the namespace and allow the required traffic explicitly.",
+	},
+	{
+		ID: "service-port-conflict", Name: "PortConflict check", Severity: findings.SeverityLow, Category: "networking",
+		Kinds: []string{"Service"}, Run: CheckPortTargetConflicts,
+		Description: "Several Services use the same target port and protocol.",
+		Help: "Services sharing a target port often select the same pods by mistake. Check the selectors " +
+			"and target ports, and keep them unique unless the overlap is intended.",
+	},
+	{
+		ID: "pvc-not-bound", Name: "PVCcheck", Severity: findings.SeverityHigh, Category: "storage",
+		Kinds: []string{"PersistentVolumeClaim"}, Run: PVCcheck,
+		Description: "PersistentVolumeClaim is Pending or Lost.",
+		Help: "Pods using an unbound claim cannot start. Check that a matching PersistentVolume or storage " +
+			"class exists, and recreate claims whose volume was lost.",
+	},
+	{
+		ID: "unclaimed-pv", Name: "UnclaimedPV", Severity: findings.SeverityLow, Category: "storage",
+		Kinds: []string{"PersistentVolume"}, Run: UnclaimedPV,
+		Description: "PersistentVolume has been available without a claim.",
+		Help: "Unclaimed volumes keep costing storage. Delete volumes that are no longer needed, or reuse " +
+			"them by binding a claim.",
+	},
+	{
+		ID: "privileged-container", Name: "Privileged container check", Severity: findings.SeverityCritical, Category: "security",
+		Kinds: workloadKinds, Run: SecurityPrivilegeCheck, OptIn: true,
+		Description: "Container runs in privileged mode.",
+		Help: "A privileged container has full access to its node, so a compromise of the container is a " +
+			"compromise of the node. Remove `securityContext.privileged` and grant only the capabilities " +
+			"the container needs.",
+	},
+	{
+		ID: "risky-rbac", Name: "RBAC check", Severity: findings.SeverityHigh, Category: "security",
+		Kinds: []string{"Role", "ClusterRole", "RoleBinding", "ClusterRoleBinding"}, Run: RBACcheck, OptIn: true,
+		Description: "Role grants risky permissions, like wildcards, secret access or privilege escalation.",
+		Help: "Broad RBAC permissions let the bound subjects read secrets or escalate their own access. " +
+			"Grant only the verbs and resources the subjects need, and review who is bound to the role.",
+	},
+}
+
+// LookupCheck returns the registered check with the given ID
+func LookupCheck(id string) (Check, bool) {
+	for _, c := range Registry {
+		if c.ID == id {
+			return c, true
+		}
+	}
+	return Check{}, false
+}
+
+// SelectChecks returns the checks an audit runs: the given check IDs, every
+// check for "all", or the checks that are not opt-in if ids is empty
+func SelectChecks(ids []string) ([]Check, error) {
+	var out []Check
+	switch {
+	case len(ids) == 0:
+		for _, c := range Registry {
+			if !c.OptIn {
+				out = append(out, c)
+			}
+		}
+	case len(ids) == 1 && ids[0] == "all":
+		out = append(out, Registry...)
+	default:
+		for _, id := range ids {
+			c, ok := LookupCheck(id)
+			if !ok {
+				return nil, fmt.Errorf("unknown check %q", id)
+			}
+			out = append(out, c)
+		}
+	}
+	return out, nil
+}
+
+// ChecksForKind returns the registered checks whose results depend on the given kind
+func ChecksForKind(kind string) []Check {
+	var out []Check
+	for _, c := range Registry {
+		for _, k := range c.Kinds {
+			if k == kind {
+				out = append(out, c)
+				break
+			}
+		}
+	}
+	return out
+}
+
+// RunCheck runs a single check in its own trace span and tags every finding it
+// adds with the check's ID and severity
+func RunCheck(ctx context.Context, a *findings.Auditor, client kubernetes.Interface, namespace string, check Check) error {
+	ctx, span := tracer.Start(ctx, "check "+check.ID, trace.WithAttributes(
+		attribute.String("audit.check.id", check.ID),
+		attribute.String("audit.namespace", namespace),
+	))
+	defer span.End()
+
+	ruleID, severity := a.RuleID, a.Severity
+	a.RuleID, a.Severity = check.ID, check.Severity
+	before, start := len(a.Findings), time.Now()
+	err := check.Run(ctx, a, client, namespace)
+	a.RuleID, a.Severity = ruleID, severity
+	span.SetAttributes(attribute.Int("audit.findings", len(a.Findings)-before))
+	slog.DebugContext(ctx, "Check finished", "check", check.Name, "findings", len(a.Findings)-before, "duration", time.Since(start))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "check failed")
+		return fmt.Errorf("check %s failed: %w", check.Name, err)
+	}
+	return nil
+}
+
+// RunChecks runs the given checks in order and collects their errors
+func RunChecks(ctx context.Context, a *findings.Auditor, client kubernetes.Interface, namespace string, checks []Check) []error {
+	var errs []error
+	for _, check := range checks {
+		if err := RunCheck(ctx, a, client, namespace, check); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
@@ -0,0 +1,85 @@
+package audit_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"goprojects/cluster-auditor/internal/audit"
+	"goprojects/findings"
+)
+
+func TestRunCheckRecordsSpan(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	otel.SetTracerProvider(provider)
+	defer otel.SetTracerProvider(sdktrace.NewTracerProvider())
+
+	check, ok := audit.LookupCheck("latest-image-tag")
+	require.True(t, ok)
+
+	client := fake.NewSimpleClientset(newDeployment("web", "default", "nginx:latest"))
+	a := findings.NewAuditor()
+	require.NoError(t, audit.RunCheck(context.Background(), a, client, "default", check))
+	require.Len(t, a.Findings, 1)
+	require.Equal(t, "latest-image-tag", a.Findings[0].RuleID)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	require.Equal(t, "check latest-image-tag", spans[0].Name())
+	require.Contains(t, spans[0].Attributes(), attribute.String("audit.namespace", "default"))
+	require.Contains(t, spans[0].Attributes(), attribute.Int("audit.findings", 1))
+}
+
+func TestSelectChecks(t *testing.T) {
+	ids := func(checks []audit.Check) []string {
+		var out []string
+		for _, c := range checks {
+			out = append(out, c.ID)
+		}
+		return out
+	}
+
+	checks, err := audit.SelectChecks(nil)
+	require.NoError(t, err)
+	require.NotContains(t, ids(checks), "privileged-container")
+	require.NotContains(t, ids(checks), "risky-rbac")
+	require.Contains(t, ids(checks), "missing-resource-limits")
+
+	checks, err = audit.SelectChecks([]string{"all"})
+	require.NoError(t, err)
+	require.Len(t, checks, len(audit.Registry))
+
+	checks, err = audit.SelectChecks([]string{"risky-rbac", "pvc-not-bound"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"risky-rbac", "pvc-not-bound"}, ids(checks))
+
+	_, err = audit.SelectChecks([]string{"nope"})
+	require.EqualError(t, err, `unknown check "nope"`)
+}
+
+func TestRunCheckStreamsFindings(t *testing.T) {
+	check, ok := audit.LookupCheck("latest-image-tag")
+	require.True(t, ok)
+
+	client := fake.NewSimpleClientset(newDeployment("web", "default", "nginx:latest"), newDeployment("api", "default", "nginx"))
+	var streamed []findings.Finding
+	a := findings.NewAuditor()
+	a.OnFinding = func(f findings.Finding) {
+		// Findings are complete when added, before the check returns
+		require.Equal(t, "latest-image-tag", f.RuleID)
+		require.Equal(t, check.Severity, f.Severity)
+		require.Equal(t, findings.ComputeFingerprint(f), f.Fingerprint)
+		streamed = append(streamed, f)
+	}
+	require.NoError(t, audit.RunCheck(context.Background(), a, client, "default", check))
+	require.Len(t, streamed, 2)
+	require.Equal(t, a.Findings, streamed)
+	require.Empty(t, a.RuleID)
+}
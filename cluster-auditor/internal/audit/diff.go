@@ -0,0 +1,227 @@
+package audit
+
+import (
+	"cmp"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"goprojects/findings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Change of a finding between two audits
+type Change string
+
+const (
+	ChangeNew       Change = "new"
+	ChangeResolved  Change = "resolved"
+	ChangeUnchanged Change = "unchanged"
+)
+
+// Diff compares the findings of two audits by fingerprint
+type Diff struct {
+	New       []findings.Finding `json:"new" yaml:"new"`
+	Resolved  []findings.Finding `json:"resolved" yaml:"resolved"`
+	Unchanged []findings.Finding `json:"unchanged" yaml:"unchanged"`
+}
+
+func fingerprintOf(f findings.Finding) string {
+	if f.Fingerprint != "" {
+		return f.Fingerprint
+	}
+	return findings.ComputeFingerprint(f)
+}
+
+// DiffFindings compares the findings of an old and a new audit. Findings with a
+// fingerprint in both are unchanged and reported as they are in the new audit.
+// Each list is sorted by severity, then namespace, kind and resource.
+func DiffFindings(old, new []findings.Finding) Diff {
+	inOld := map[string]bool{}
+	for _, f := range old {
+		inOld[fingerprintOf(f)] = true
+	}
+	inNew := map[string]bool{}
+	d := Diff{New: []findings.Finding{}, Resolved: []findings.Finding{}, Unchanged: []findings.Finding{}}
+	for _, f := range new {
+		fp := fingerprintOf(f)
+		if inNew[fp] {
+			continue
+		}
+		inNew[fp] = true
+		if inOld[fp] {
+			d.Unchanged = append(d.Unchanged, f)
+		} else {
+			d.New = append(d.New, f)
+		}
+	}
+	for _, f := range old {
+		fp := fingerprintOf(f)
+		if !inNew[fp] {
+			inNew[fp] = true // reports each resolved fingerprint once
+			d.Resolved = append(d.Resolved, f)
+		}
+	}
+	for _, fs := range [][]findings.Finding{d.New, d.Resolved, d.Unchanged} {
+		slices.SortStableFunc(fs, func(a, b findings.Finding) int {
+			return cmp.Or(b.Severity.Rank()-a.Severity.Rank(), cmp.Compare(a.Namespace, b.Namespace), compareFindings(a, b))
+		})
+	}
+	return d
+}
+
+// NewAtOrAbove returns the new findings at least as severe as min
+func (d Diff) NewAtOrAbove(min findings.Severity) []findings.Finding {
+	var out []findings.Finding
+	for _, f := range d.New {
+		if f.Severity.Rank() >= min.Rank() {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+// DiffEntry is a finding with its change, the record of the streaming diff formats
+type DiffEntry struct {
+	Change Change `json:"Change"` // named like the fields of the finding
+	findings.Finding
+}
+
+// Entries lists the changes, new findings first. Unchanged findings are only
+// included if unchanged is true.
+func (d Diff) Entries(unchanged bool) []DiffEntry {
+	var out []DiffEntry
+	add := func(c Change, fs []findings.Finding) {
+		for _, f := range fs {
+			out = append(out, DiffEntry{Change: c, Finding: f})
+		}
+	}
+	add(ChangeNew, d.New)
+	add(ChangeResolved, d.Resolved)
+	if unchanged {
+		add(ChangeUnchanged, d.Unchanged)
+	}
+	return out
+}
+
+func (d Diff) summary() string {
+	return fmt.Sprintf("%d new, %d resolved, %d unchanged", len(d.New), len(d.Resolved), len(d.Unchanged))
+}
+
+// DiffFormats are the formats WriteDiff supports
+var DiffFormats = []string{"table", "wide", "json", "yaml", "markdown", "csv", "ndjson"}
+
+// DiffOptions configures WriteDiff
+type DiffOptions struct {
+	Format    string // one of DiffFormats
+	Unchanged bool   // list unchanged findings, they are only counted otherwise
+	Table     TableOptions
+}
+
+// WriteDiff writes a diff in one of DiffFormats. JSON and YAML always include the
+// unchanged findings.
+func WriteDiff(w io.Writer, d Diff, opts DiffOptions) error {
+	switch opts.Format {
+	case "table", "wide":
+		opts.Table.Wide = opts.Format == "wide"
+		return writeDiffTable(w, d, opts)
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(d)
+	case "yaml":
+		return yaml.NewEncoder(w).Encode(d)
+	case "markdown":
+		return writeDiffMarkdown(w, d, opts.Unchanged)
+	case "csv":
+		cw := csv.NewWriter(w)
+		cw.Write(append([]string{"change"}, CSVColumns...))
+		for _, e := range d.Entries(opts.Unchanged) {
+			cw.Write(append([]string{string(e.Change)}, csvRecord(e.Finding)...))
+		}
+		cw.Flush()
+		return cw.Error()
+	case "ndjson":
+		enc := json.NewEncoder(w)
+		for _, e := range d.Entries(opts.Unchanged) {
+			if err := enc.Encode(e); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown diff format %q (want %s)", opts.Format, strings.Join(DiffFormats, ", "))
+}
+
+var changeStyles = map[Change]struct{ mark, color string }{
+	ChangeNew:       {"+", "\x1b[31m"},
+	ChangeResolved:  {"-", "\x1b[32m"},
+	ChangeUnchanged: {"=", ansiDim},
+}
+
+func writeDiffTable(w io.Writer, d Diff, opts DiffOptions) error {
+	header := []string{"", "SEVERITY", "NAMESPACE", "KIND", "RESOURCE", "ISSUE"}
+	cells := func(e DiffEntry) []string {
+		return []string{changeStyles[e.Change].mark, string(e.Severity), e.Namespace, e.Kind, e.Resource, strings.ReplaceAll(e.Issue, "\n", " ")}
+	}
+	if opts.Table.Wide {
+		header = []string{"", "SEVERITY", "NAMESPACE", "KIND", "RESOURCE", "CONTAINER", "RULE", "TEAM", "ISSUE"}
+		cells = func(e DiffEntry) []string {
+			return []string{changeStyles[e.Change].mark, string(e.Severity), e.Namespace, e.Kind, e.Resource,
+				e.Container, e.RuleID, e.Team, strings.ReplaceAll(e.Issue, "\n", " ")}
+		}
+		opts.Table.Width = 0
+	}
+
+	entries := d.Entries(opts.Unchanged)
+	t := &tableWriter{w: w, opts: opts.Table, widths: make([]int, len(header))}
+	for i, h := range header {
+		t.widths[i] = len(h)
+	}
+	for _, e := range entries {
+		for i, cell := range cells(e) {
+			t.widths[i] = max(t.widths[i], utf8.RuneCountInString(cell))
+		}
+	}
+	if len(entries) > 0 {
+		t.row(header, slices.Repeat([]string{ansiDim}, len(header))...)
+		for _, e := range entries {
+			t.row(cells(e), slices.Repeat([]string{changeStyles[e.Change].color}, len(header))...)
+		}
+		fmt.Fprintln(w)
+	}
+	_, err := fmt.Fprintf(w, "%s\n", d.summary())
+	return err
+}
+
+func writeDiffMarkdown(w io.Writer, d Diff, unchanged bool) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "## Audit diff\n\n%s\n", d.summary())
+	section := func(title string, fs []findings.Finding, open bool) {
+		if len(fs) == 0 {
+			return
+		}
+		details := "<details>"
+		if open {
+			details = "<details open>"
+		}
+		fmt.Fprintf(&b, "\n%s\n<summary><b>%s</b>: %s (%s)</summary>\n\n", details, title, plural(len(fs), "finding"), severityList(severityCounts(fs)))
+		b.WriteString("| Severity | Namespace | Resource | Issue |\n|---|---|---|---|\n")
+		for _, f := range fs {
+			fmt.Fprintf(&b, "| %s | %s | %s | %s |\n", f.Severity, markdownCell(f.Namespace), markdownCell(f.Kind+"/"+f.Resource), markdownCell(f.Issue))
+		}
+		b.WriteString("\n</details>\n")
+	}
+	section("New", d.New, true)
+	section("Resolved", d.Resolved, false)
+	if unchanged {
+		section("Unchanged", d.Unchanged, false)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,47 @@
+package audit_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"goprojects/cluster-auditor/internal/audit"
+	"goprojects/findings"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiff(t *testing.T) {
+	finding := func(resource string, severity findings.Severity) findings.Finding {
+		return findings.Finding{Namespace: "shop", Kind: "Deployment", Resource: resource,
+			Issue: "Missing Liveness Probe", RuleID: "missing-liveness-probe", Severity: severity}
+	}
+	before := []findings.Finding{finding("web", findings.SeverityLow), finding("api", findings.SeverityLow)}
+	after := []findings.Finding{finding("web", findings.SeverityLow), finding("worker", findings.SeverityHigh), finding("cron", findings.SeverityLow)}
+
+	d := audit.DiffFindings(before, after)
+	require.Equal(t, []findings.Finding{finding("worker", findings.SeverityHigh), finding("cron", findings.SeverityLow)}, d.New)
+	require.Equal(t, []findings.Finding{finding("api", findings.SeverityLow)}, d.Resolved)
+	require.Equal(t, []findings.Finding{finding("web", findings.SeverityLow)}, d.Unchanged)
+	require.Len(t, d.NewAtOrAbove(findings.SeverityHigh), 1)
+	require.Empty(t, d.NewAtOrAbove(findings.SeverityCritical))
+
+	var buf bytes.Buffer
+	require.NoError(t, audit.WriteDiff(&buf, d, audit.DiffOptions{Format: "table"}))
+	require.Equal(t, strings.Join([]string{
+		"   SEVERITY  NAMESPACE  KIND        RESOURCE  ISSUE",
+		"+  high      shop       Deployment  worker    Missing Liveness Probe",
+		"+  low       shop       Deployment  cron      Missing Liveness Probe",
+		"-  low       shop       Deployment  api       Missing Liveness Probe",
+		"",
+		"2 new, 1 resolved, 1 unchanged",
+		"",
+	}, "\n"), buf.String())
+
+	buf.Reset()
+	require.NoError(t, audit.WriteDiff(&buf, d, audit.DiffOptions{Format: "csv", Unchanged: true}))
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 5)
+	require.True(t, strings.HasPrefix(lines[0], "change,cluster,namespace,"))
+	require.True(t, strings.HasPrefix(lines[4], "unchanged,,shop,Deployment,web,"))
+}
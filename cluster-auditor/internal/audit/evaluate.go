@@ -0,0 +1,50 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+
+	"goprojects/findings"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+)
+
+// EvaluateObjects runs the registered checks that read any of the given objects'
+// kinds against those objects alone, without contacting a cluster. This is how
+// admission requests are audited.
+func EvaluateObjects(ctx context.Context, namespace string, objects ...runtime.Object) (*findings.Auditor, []error) {
+	seen := map[string]bool{}
+	var checks []Check
+	for _, obj := range objects {
+		kind, err := KindOf(obj)
+		if err != nil {
+			return nil, []error{err}
+		}
+		for _, c := range ChecksForKind(kind) {
+			if !seen[c.ID] {
+				seen[c.ID] = true
+				checks = append(checks, c)
+			}
+		}
+	}
+
+	list, err := objectsLister(objects)
+	if err != nil {
+		return nil, []error{err}
+	}
+	a := findings.NewAuditor()
+	return a, RunChecks(ctx, a, listerClient(list), namespace, checks)
+}
+
+// KindOf returns the kind of a typed Kubernetes object
+func KindOf(obj runtime.Object) (string, error) {
+	if kind := obj.GetObjectKind().GroupVersionKind().Kind; kind != "" {
+		return kind, nil
+	}
+	gvks, _, err := scheme.Scheme.ObjectKinds(obj)
+	if err != nil || len(gvks) == 0 {
+		return "", fmt.Errorf("unable to determine kind of %T: %w", obj, err)
+	}
+	return gvks[0].Kind, nil
+}
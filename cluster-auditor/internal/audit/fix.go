@@ -0,0 +1,470 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"goprojects/findings"
+
+	"github.com/pmezard/go-difflib/difflib"
+	jsonpatch "gopkg.in/evanphx/json-patch.v4"
+	"gopkg.in/yaml.v3"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	appsv1ac "k8s.io/client-go/applyconfigurations/apps/v1"
+	batchv1ac "k8s.io/client-go/applyconfigurations/batch/v1"
+	corev1ac "k8s.io/client-go/applyconfigurations/core/v1"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+)
+
+// Patch types of fixes, named like the --type of kubectl patch
+const (
+	PatchStrategic = "strategic"
+	PatchJSON      = "json"
+)
+
+// ErrNotFixable is wrapped by PlanFix errors for findings without a mechanical fix
+var ErrNotFixable = errors.New("not fixable")
+
+func notFixable(format string, args ...any) error {
+	return fmt.Errorf("%w: %s", ErrNotFixable, fmt.Sprintf(format, args...))
+}
+
+// Fix is a patch remediating one finding
+type Fix struct {
+	Finding     findings.Finding
+	Description string
+	Type        string // PatchStrategic or PatchJSON
+	Patch       []byte // JSON
+}
+
+// FixOptions configures PlanFix
+type FixOptions struct {
+	// Client looks up the image digests running in the cluster. Without it,
+	// offline, images on the latest tag cannot be pinned.
+	Client kubernetes.Interface
+	// Requests and Limits are added to containers missing them
+	Requests corev1.ResourceList
+	Limits   corev1.ResourceList
+}
+
+// DefaultFixRequests and DefaultFixLimits are the resources added to containers
+// without requests or limits, unless FixOptions sets others
+var (
+	DefaultFixRequests = corev1.ResourceList{
+		corev1.ResourceCPU:    resource.MustParse("100m"),
+		corev1.ResourceMemory: resource.MustParse("128Mi"),
+	}
+	DefaultFixLimits = corev1.ResourceList{
+		corev1.ResourceCPU:    resource.MustParse("500m"),
+		corev1.ResourceMemory: resource.MustParse("256Mi"),
+	}
+)
+
+type fixer func(ctx context.Context, obj *unstructured.Unstructured, f findings.Finding, opts FixOptions) (Fix, error)
+
+// fixers by check ID
+var fixers = map[string]fixer{
+	"missing-resource-limits": fixResourceLimits,
+	"latest-image-tag":        fixLatestTag,
+	"hpa-replicas-conflict":   fixHPAReplicas,
+	"privileged-container":    fixPrivileged,
+}
+
+// Fixable reports whether PlanFix can fix the findings of a check
+func Fixable(checkID string) bool {
+	_, ok := fixers[checkID]
+	return ok
+}
+
+// PlanFix returns the fix of a finding about obj, an error wrapping ErrNotFixable
+// if it has none. Objects managed by a controller are not fixed, since the
+// controller would revert the change; their owner is.
+func PlanFix(ctx context.Context, obj *unstructured.Unstructured, f findings.Finding, opts FixOptions) (Fix, error) {
+	fix, ok := fixers[f.RuleID]
+	if !ok {
+		return Fix{}, notFixable("no fix for %s findings", f.RuleID)
+	}
+	if owner := metav1.GetControllerOfNoCopy(obj); owner != nil {
+		return Fix{}, notFixable("managed by %s %s, fix that instead", owner.Kind, owner.Name)
+	}
+	if obj.GetKind() == "Pod" {
+		return Fix{}, notFixable("the pod spec cannot be changed, fix the workload that creates the pod")
+	}
+	return fix(ctx, obj, f, opts)
+}
+
+// podSpecPath returns the fields holding the pod spec of a workload kind
+func podSpecPath(kind string) []string {
+	switch kind {
+	case "Pod":
+		return []string{"spec"}
+	case "CronJob":
+		return []string{"spec", "jobTemplate", "spec", "template", "spec"}
+	}
+	return []string{"spec", "template", "spec"}
+}
+
+// findContainer returns the container of a finding, and whether it is one of the
+// pod's init containers
+func findContainer(obj *unstructured.Unstructured, name string) (map[string]any, bool, error) {
+	for _, field := range []string{"containers", "initContainers"} {
+		containers, _, err := unstructured.NestedSlice(obj.Object, append(podSpecPath(obj.GetKind()), field)...)
+		if err != nil {
+			return nil, false, err
+		}
+		for _, c := range containers {
+			if c, ok := c.(map[string]any); ok && c["name"] == name {
+				return c, field == "initContainers", nil
+			}
+		}
+	}
+	return nil, false, notFixable("container %q not found in %s %s", name, obj.GetKind(), obj.GetName())
+}
+
+// containerPatch returns a strategic merge patch changing one container, which
+// containers are merged by name
+func containerPatch(obj *unstructured.Unstructured, container map[string]any, init bool) ([]byte, error) {
+	field := "containers"
+	if init {
+		field = "initContainers"
+	}
+	var patch any = map[string]any{field: []any{container}}
+	path := podSpecPath(obj.GetKind())
+	for i := len(path) - 1; i >= 0; i-- {
+		patch = map[string]any{path[i]: patch}
+	}
+	return json.Marshal(patch)
+}
+
+func fixPrivileged(_ context.Context, obj *unstructured.Unstructured, f findings.Finding, _ FixOptions) (Fix, error) {
+	_, init, err := findContainer(obj, f.Container)
+	if err != nil {
+		return Fix{}, err
+	}
+	patch, err := containerPatch(obj, map[string]any{
+		"name":            f.Container,
+		"securityContext": map[string]any{"privileged": false},
+	}, init)
+	return Fix{Finding: f, Description: fmt.Sprintf("Set securityContext.privileged to false in container %s", f.Container),
+		Type: PatchStrategic, Patch: patch}, err
+}
+
+// fixHPAReplicas removes spec.replicas from manifests. Live objects are not fixed:
+// the API server defaults a removed spec.replicas to 1, scaling the workload down
+// until the HPA scales it up again, and the finding would come back.
+func fixHPAReplicas(_ context.Context, obj *unstructured.Unstructured, f findings.Finding, opts FixOptions) (Fix, error) {
+	if opts.Client != nil {
+		return Fix{}, notFixable("the API server defaults spec.replicas, remove it from the manifests of %s %s", obj.GetKind(), obj.GetName())
+	}
+	if _, found, _ := unstructured.NestedFieldNoCopy(obj.Object, "spec", "replicas"); !found {
+		return Fix{}, notFixable("spec.replicas is not set")
+	}
+	return Fix{Finding: f, Description: "Remove spec.replicas, the HorizontalPodAutoscaler sets the replica count",
+		Type: PatchJSON, Patch: []byte(`[{"op":"remove","path":"/spec/replicas"}]`)}, nil
+}
+
+func fixResourceLimits(_ context.Context, obj *unstructured.Unstructured, f findings.Finding, opts FixOptions) (Fix, error) {
+	container, init, err := findContainer(obj, f.Container)
+	if err != nil {
+		return Fix{}, err
+	}
+	var current corev1.ResourceRequirements
+	if resources, ok := container["resources"]; ok {
+		data, _ := json.Marshal(resources)
+		if err := json.Unmarshal(data, &current); err != nil {
+			return Fix{}, fmt.Errorf("invalid resources of container %s: %w", f.Container, err)
+		}
+	}
+	requests, limits := opts.Requests, opts.Limits
+	if requests == nil {
+		requests = DefaultFixRequests
+	}
+	if limits == nil {
+		limits = DefaultFixLimits
+	}
+
+	// A limit below the request is invalid, so the defaults give way to the
+	// values the container already has
+	added := corev1.ResourceRequirements{}
+	var what []string
+	if current.Limits == nil {
+		added.Limits = corev1.ResourceList{}
+		for name, limit := range limits {
+			if request, ok := current.Requests[name]; ok && request.Cmp(limit) > 0 {
+				limit = request
+			}
+			added.Limits[name] = limit
+		}
+		what = append(what, "limits")
+	}
+	if current.Requests == nil {
+		added.Requests = corev1.ResourceList{}
+		for name, request := range requests {
+			if limit, ok := current.Limits[name]; ok && limit.Cmp(request) < 0 {
+				request = limit
+			}
+			added.Requests[name] = request
+		}
+		what = append(what, "requests")
+	}
+	if len(what) == 0 {
+		return Fix{}, notFixable("container %s already has requests and limits", f.Container)
+	}
+
+	data, err := json.Marshal(added)
+	if err != nil {
+		return Fix{}, err
+	}
+	var resources map[string]any
+	if err := json.Unmarshal(data, &resources); err != nil {
+		return Fix{}, err
+	}
+	patch, err := containerPatch(obj, map[string]any{"name": f.Container, "resources": resources}, init)
+	return Fix{Finding: f, Description: fmt.Sprintf("Add default resource %s to container %s", strings.Join(what, " and "), f.Container),
+		Type: PatchStrategic, Patch: patch}, err
+}
+
+// imageRepository strips the tag and digest of an image reference. Registry
+// ports are not mistaken for tags.
+func imageRepository(image string) string {
+	image, _, _ = strings.Cut(image, "@")
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		return image[:i]
+	}
+	return image
+}
+
+// fixLatestTag pins an image to the digest the workload's pods run. Pods of
+// different digests, e.g. during a rollout, leave the finding unfixed.
+func fixLatestTag(ctx context.Context, obj *unstructured.Unstructured, f findings.Finding, opts FixOptions) (Fix, error) {
+	if opts.Client == nil {
+		return Fix{}, notFixable("the running image digest is only known in a cluster")
+	}
+	container, init, err := findContainer(obj, f.Container)
+	if err != nil {
+		return Fix{}, err
+	}
+	image, _ := container["image"].(string)
+	matchLabels, _, _ := unstructured.NestedStringMap(obj.Object, "spec", "selector", "matchLabels")
+	if len(matchLabels) == 0 {
+		return Fix{}, notFixable("%s %s has no selector to find its pods", obj.GetKind(), obj.GetName())
+	}
+	selector := metav1.FormatLabelSelector(&metav1.LabelSelector{MatchLabels: matchLabels})
+	pods, err := opts.Client.CoreV1().Pods(obj.GetNamespace()).List(ctx, metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return Fix{}, fmt.Errorf("failed to list pods: %w", err)
+	}
+
+	digests := map[string]bool{}
+	for _, pod := range pods.Items {
+		statuses := pod.Status.ContainerStatuses
+		if init {
+			statuses = pod.Status.InitContainerStatuses
+		}
+		for _, status := range statuses {
+			if status.Name != f.Container || !strings.Contains(status.ImageID, "@sha256:") {
+				continue
+			}
+			digests[status.ImageID[strings.LastIndex(status.ImageID, "@")+1:]] = true
+		}
+	}
+	if len(digests) != 1 {
+		return Fix{}, notFixable("found %d running digests of container %s, need exactly one", len(digests), f.Container)
+	}
+	var digest string
+	for d := range digests {
+		digest = d
+	}
+
+	pinned := imageRepository(image) + "@" + digest
+	patch, err := containerPatch(obj, map[string]any{"name": f.Container, "image": pinned}, init)
+	return Fix{Finding: f, Description: fmt.Sprintf("Pin image %s of container %s to %s", image, f.Container, pinned),
+		Type: PatchStrategic, Patch: patch}, err
+}
+
+// ApplyTo returns obj with the patch of the fix applied, without a cluster
+func (fix Fix) ApplyTo(obj *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	original, err := obj.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	var patched []byte
+	switch fix.Type {
+	case PatchStrategic:
+		typed, err := scheme.Scheme.New(obj.GroupVersionKind())
+		if err != nil {
+			return nil, err
+		}
+		patched, err = strategicpatch.StrategicMergePatch(original, fix.Patch, typed)
+		if err != nil {
+			return nil, err
+		}
+	case PatchJSON:
+		p, err := jsonpatch.DecodePatch(fix.Patch)
+		if err != nil {
+			return nil, err
+		}
+		if patched, err = p.Apply(original); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unknown patch type %q", fix.Type)
+	}
+	out := &unstructured.Unstructured{}
+	return out, out.UnmarshalJSON(patched)
+}
+
+// ApplyFixes applies the strategic merge fixes of an object in the cluster with
+// server-side apply by fieldManager. They are applied together with the fields
+// fieldManager applied before, since fields a manager leaves out of an apply are
+// removed. JSON patches are only planned for manifests and are refused.
+func ApplyFixes(ctx context.Context, client dynamic.Interface, obj *unstructured.Unstructured, fixes []Fix, fieldManager string, force bool) error {
+	gvr, namespaced, ok := ResourceOfKind(obj.GetKind())
+	if !ok {
+		return fmt.Errorf("unknown kind %s", obj.GetKind())
+	}
+	var resources dynamic.ResourceInterface = client.Resource(gvr)
+	if namespaced {
+		resources = client.Resource(gvr).Namespace(obj.GetNamespace())
+	}
+	typed, err := scheme.Scheme.New(obj.GroupVersionKind())
+	if err != nil {
+		return err
+	}
+	config, err := appliedConfig(obj, fieldManager)
+	if err != nil {
+		return err
+	}
+	for _, fix := range fixes {
+		if fix.Type != PatchStrategic {
+			return fmt.Errorf("cannot apply %s patch in the cluster: %s", fix.Type, fix.Description)
+		}
+		if config, err = strategicpatch.StrategicMergePatch(config, fix.Patch, typed); err != nil {
+			return err
+		}
+	}
+	_, err = resources.Patch(ctx, obj.GetName(), types.ApplyPatchType, config, metav1.PatchOptions{FieldManager: fieldManager, Force: &force})
+	return err
+}
+
+// appliedConfig returns the fields fieldManager applied to obj, as an apply
+// configuration of the object
+func appliedConfig(obj *unstructured.Unstructured, fieldManager string) ([]byte, error) {
+	typed, err := scheme.Scheme.New(obj.GroupVersionKind())
+	if err != nil {
+		return nil, err
+	}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, typed); err != nil {
+		return nil, err
+	}
+	var applied any
+	switch o := typed.(type) {
+	case *appsv1.Deployment:
+		applied, err = appsv1ac.ExtractDeployment(o, fieldManager)
+	case *appsv1.StatefulSet:
+		applied, err = appsv1ac.ExtractStatefulSet(o, fieldManager)
+	case *appsv1.DaemonSet:
+		applied, err = appsv1ac.ExtractDaemonSet(o, fieldManager)
+	case *appsv1.ReplicaSet:
+		applied, err = appsv1ac.ExtractReplicaSet(o, fieldManager)
+	case *batchv1.Job:
+		applied, err = batchv1ac.ExtractJob(o, fieldManager)
+	case *batchv1.CronJob:
+		applied, err = batchv1ac.ExtractCronJob(o, fieldManager)
+	case *corev1.ReplicationController:
+		applied, err = corev1ac.ExtractReplicationController(o, fieldManager)
+	default:
+		return nil, fmt.Errorf("cannot apply fixes to %s", obj.GetKind())
+	}
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(applied)
+}
+
+type fixDocument struct {
+	Rule        string `yaml:"rule"`
+	Severity    string `yaml:"severity"`
+	Kind        string `yaml:"kind"`
+	Namespace   string `yaml:"namespace,omitempty"`
+	Name        string `yaml:"name"`
+	Container   string `yaml:"container,omitempty"`
+	Description string `yaml:"description"`
+	PatchType   string `yaml:"patchType"`
+	Patch       any    `yaml:"patch"`
+}
+
+// WriteFixes writes fixes as a stream of YAML documents, one per fix
+func WriteFixes(w io.Writer, fixes []Fix) error {
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	for _, fix := range fixes {
+		doc := fixDocument{
+			Rule: fix.Finding.RuleID, Severity: string(fix.Finding.Severity), Kind: fix.Finding.Kind,
+			Namespace: fix.Finding.Namespace, Name: fix.Finding.Resource, Container: fix.Finding.Container,
+			Description: fix.Description, PatchType: fix.Type,
+		}
+		if err := json.Unmarshal(fix.Patch, &doc.Patch); err != nil {
+			return err
+		}
+		if err := enc.Encode(doc); err != nil {
+			return err
+		}
+	}
+	return enc.Close()
+}
+
+// manifestFields drops the fields of a live object that are not part of its
+// manifest, like the status, so manifests and diffs only show what users write
+func manifestFields(obj *unstructured.Unstructured) map[string]any {
+	out := obj.DeepCopy()
+	unstructured.RemoveNestedField(out.Object, "status")
+	for _, field := range []string{"managedFields", "resourceVersion", "uid", "generation", "creationTimestamp", "selfLink"} {
+		unstructured.RemoveNestedField(out.Object, "metadata", field)
+	}
+	unstructured.RemoveNestedField(out.Object, "metadata", "annotations", corev1.LastAppliedConfigAnnotation)
+	if len(out.GetAnnotations()) == 0 {
+		unstructured.RemoveNestedField(out.Object, "metadata", "annotations")
+	}
+	return out.Object
+}
+
+// WriteManifest writes an object as a YAML manifest document
+func WriteManifest(w io.Writer, obj *unstructured.Unstructured) error {
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(manifestFields(obj)); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+// WriteManifestDiff writes the unified diff between the manifests of two
+// versions of an object, labelled with name
+func WriteManifestDiff(w io.Writer, name string, before, after *unstructured.Unstructured) error {
+	var a, b strings.Builder
+	if err := WriteManifest(&a, before); err != nil {
+		return err
+	}
+	if err := WriteManifest(&b, after); err != nil {
+		return err
+	}
+	return difflib.WriteUnifiedDiff(w, difflib.UnifiedDiff{
+		A: difflib.SplitLines(a.String()), B: difflib.SplitLines(b.String()),
+		FromFile: "a/" + name, ToFile: "b/" + name, Context: 3,
+	})
+}
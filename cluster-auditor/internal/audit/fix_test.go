@@ -0,0 +1,195 @@
+package audit_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"goprojects/cluster-auditor/internal/audit"
+	"goprojects/findings"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func fixDeployment() *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "apps/v1", "kind": "Deployment",
+		"metadata": map[string]any{"name": "web", "namespace": "shop"},
+		"spec": map[string]any{
+			"replicas": int64(3),
+			"selector": map[string]any{"matchLabels": map[string]any{"app": "web"}},
+			"template": map[string]any{
+				"metadata": map[string]any{"labels": map[string]any{"app": "web"}},
+				"spec": map[string]any{"containers": []any{
+					map[string]any{
+						"name": "app", "image": "nginx:latest",
+						"securityContext": map[string]any{"privileged": true},
+						"resources":       map[string]any{"requests": map[string]any{"cpu": "1", "memory": "64Mi"}},
+					},
+					map[string]any{"name": "sidecar", "image": "envoy:v1"},
+				}},
+			},
+		},
+	}}
+}
+
+func fixFinding(rule, container string) findings.Finding {
+	return findings.Finding{RuleID: rule, Kind: "Deployment", Namespace: "shop", Resource: "web", Container: container}
+}
+
+func fixedContainer(t *testing.T, obj *unstructured.Unstructured, name string) map[string]any {
+	containers, _, err := unstructured.NestedSlice(obj.Object, "spec", "template", "spec", "containers")
+	require.NoError(t, err)
+	require.Len(t, containers, 2)
+	for _, c := range containers {
+		if c := c.(map[string]any); c["name"] == name {
+			return c
+		}
+	}
+	t.Fatalf("container %s not found", name)
+	return nil
+}
+
+func TestPlanFix(t *testing.T) {
+	ctx := context.Background()
+	obj := fixDeployment()
+
+	fix, err := audit.PlanFix(ctx, obj, fixFinding("privileged-container", "app"), audit.FixOptions{})
+	require.NoError(t, err)
+	require.Equal(t, audit.PatchStrategic, fix.Type)
+	patched, err := fix.ApplyTo(obj)
+	require.NoError(t, err)
+	require.Equal(t, map[string]any{"privileged": false}, fixedContainer(t, patched, "app")["securityContext"])
+
+	// The limits default below the existing cpu request, so it is the limit
+	fix, err = audit.PlanFix(ctx, patched, fixFinding("missing-resource-limits", "app"), audit.FixOptions{})
+	require.NoError(t, err)
+	require.Equal(t, "Add default resource limits to container app", fix.Description)
+	patched, err = fix.ApplyTo(patched)
+	require.NoError(t, err)
+	require.Equal(t, map[string]any{
+		"requests": map[string]any{"cpu": "1", "memory": "64Mi"},
+		"limits":   map[string]any{"cpu": "1", "memory": "256Mi"},
+	}, fixedContainer(t, patched, "app")["resources"])
+	_, err = audit.PlanFix(ctx, patched, fixFinding("missing-resource-limits", "app"), audit.FixOptions{})
+	require.ErrorIs(t, err, audit.ErrNotFixable)
+
+	fix, err = audit.PlanFix(ctx, patched, fixFinding("missing-resource-limits", "sidecar"), audit.FixOptions{
+		Requests: corev1.ResourceList{}, Limits: corev1.ResourceList{}})
+	require.NoError(t, err)
+	require.Equal(t, "Add default resource limits and requests to container sidecar", fix.Description)
+
+	// The API server defaults a removed spec.replicas, so only manifests are fixed
+	_, err = audit.PlanFix(ctx, patched, fixFinding("hpa-replicas-conflict", ""), audit.FixOptions{Client: fake.NewSimpleClientset()})
+	require.ErrorIs(t, err, audit.ErrNotFixable)
+	fix, err = audit.PlanFix(ctx, patched, fixFinding("hpa-replicas-conflict", ""), audit.FixOptions{})
+	require.NoError(t, err)
+	require.Equal(t, audit.PatchJSON, fix.Type)
+	patched, err = fix.ApplyTo(patched)
+	require.NoError(t, err)
+	_, found, _ := unstructured.NestedFieldNoCopy(patched.Object, "spec", "replicas")
+	require.False(t, found)
+	_, err = audit.PlanFix(ctx, patched, fixFinding("hpa-replicas-conflict", ""), audit.FixOptions{})
+	require.ErrorIs(t, err, audit.ErrNotFixable)
+
+	// The original object is left as it was
+	require.Equal(t, fixDeployment(), obj)
+
+	var diff bytes.Buffer
+	require.NoError(t, audit.WriteManifestDiff(&diff, "shop/deployment/web", obj, patched))
+	require.Contains(t, diff.String(), "--- a/shop/deployment/web")
+	require.Contains(t, diff.String(), "-  replicas: 3\n")
+	require.Contains(t, diff.String(), "-            privileged: true\n")
+	require.Contains(t, diff.String(), "+            privileged: false\n")
+}
+
+func TestPlanFixLatestTag(t *testing.T) {
+	ctx := context.Background()
+	pod := func(name, imageID string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "shop", Labels: map[string]string{"app": "web"}},
+			Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{
+				{Name: "app", Image: "docker.io/library/nginx:latest", ImageID: imageID},
+			}},
+		}
+	}
+	finding := fixFinding("latest-image-tag", "app")
+
+	_, err := audit.PlanFix(ctx, fixDeployment(), finding, audit.FixOptions{})
+	require.ErrorIs(t, err, audit.ErrNotFixable)
+
+	client := fake.NewSimpleClientset(pod("web-1", "docker.io/library/nginx@sha256:abc"), pod("web-2", "docker.io/library/nginx@sha256:abc"))
+	fix, err := audit.PlanFix(ctx, fixDeployment(), finding, audit.FixOptions{Client: client})
+	require.NoError(t, err)
+	patched, err := fix.ApplyTo(fixDeployment())
+	require.NoError(t, err)
+	require.Equal(t, "nginx@sha256:abc", fixedContainer(t, patched, "app")["image"])
+
+	// A rollout in progress runs two digests
+	client = fake.NewSimpleClientset(pod("web-1", "docker.io/library/nginx@sha256:abc"), pod("web-2", "docker.io/library/nginx@sha256:def"))
+	_, err = audit.PlanFix(ctx, fixDeployment(), finding, audit.FixOptions{Client: client})
+	require.ErrorIs(t, err, audit.ErrNotFixable)
+}
+
+func TestPlanFixOwned(t *testing.T) {
+	obj := fixDeployment()
+	controller := true
+	obj.SetOwnerReferences([]metav1.OwnerReference{{APIVersion: "example.com/v1", Kind: "App", Name: "web", Controller: &controller}})
+	_, err := audit.PlanFix(context.Background(), obj, fixFinding("privileged-container", "app"), audit.FixOptions{})
+	require.ErrorIs(t, err, audit.ErrNotFixable)
+
+	_, err = audit.PlanFix(context.Background(), fixDeployment(), fixFinding("unclaimed-pv", ""), audit.FixOptions{})
+	require.ErrorIs(t, err, audit.ErrNotFixable)
+}
+
+func TestApplyFixes(t *testing.T) {
+	ctx := context.Background()
+	// The field manager applied a cpu request to the app container before
+	obj := fixDeployment()
+	obj.SetManagedFields([]metav1.ManagedFieldsEntry{{
+		Manager: "cluster-auditor", Operation: metav1.ManagedFieldsOperationApply, APIVersion: "apps/v1", FieldsType: "FieldsV1",
+		FieldsV1: &metav1.FieldsV1{Raw: []byte(`{"f:spec":{"f:template":{"f:spec":{"f:containers":{` +
+			`"k:{\"name\":\"app\"}":{".":{},"f:name":{},"f:resources":{"f:requests":{"f:cpu":{}}}}}}}}}`)},
+	}})
+
+	client := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), obj.DeepCopy())
+	var applied []k8stesting.PatchAction
+	client.PrependReactor("patch", "deployments", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		applied = append(applied, action.(k8stesting.PatchAction))
+		return true, obj, nil
+	})
+
+	fix, err := audit.PlanFix(ctx, obj, fixFinding("privileged-container", "app"), audit.FixOptions{})
+	require.NoError(t, err)
+	require.NoError(t, audit.ApplyFixes(ctx, client, obj, []audit.Fix{fix}, "cluster-auditor", true))
+	require.Len(t, applied, 1)
+	require.Equal(t, types.ApplyPatchType, applied[0].GetPatchType())
+	require.Equal(t, "shop", applied[0].GetNamespace())
+
+	// The fields applied before are applied again along with the fix
+	var config map[string]any
+	require.NoError(t, json.Unmarshal(applied[0].GetPatch(), &config))
+	require.Equal(t, "apps/v1", config["apiVersion"])
+	require.Equal(t, "Deployment", config["kind"])
+	require.Equal(t, map[string]any{"name": "web", "namespace": "shop"}, config["metadata"])
+	require.Equal(t, map[string]any{"template": map[string]any{"spec": map[string]any{"containers": []any{map[string]any{
+		"name":            "app",
+		"resources":       map[string]any{"requests": map[string]any{"cpu": "1"}},
+		"securityContext": map[string]any{"privileged": false},
+	}}}}}, config["spec"])
+
+	// Removing fields is left to the manifests
+	replicas, err := audit.PlanFix(ctx, obj, fixFinding("hpa-replicas-conflict", ""), audit.FixOptions{})
+	require.NoError(t, err)
+	require.Error(t, audit.ApplyFixes(ctx, client, obj, []audit.Fix{replicas}, "cluster-auditor", true))
+	require.Len(t, applied, 1)
+}
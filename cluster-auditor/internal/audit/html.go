@@ -0,0 +1,194 @@
+package audit
+
+import (
+	"cmp"
+	_ "embed"
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"goprojects/findings"
+)
+
+//go:embed templates/report.html
+var htmlReportTemplate string
+
+// The HTML report is one static file: styles and scripts are inlined so it can
+// be attached to a ticket or mailed and still work offline
+var htmlReport = template.Must(template.New("report.html").
+	Funcs(template.FuncMap{"join": strings.Join}).
+	Parse(htmlReportTemplate))
+
+// clusterScopedGroup names the group of findings on cluster-scoped resources
+const clusterScopedGroup = "(cluster-scoped)"
+
+type htmlPage struct {
+	Title       string
+	Cluster     string
+	Namespace   string
+	Generated   time.Time
+	Score       float64
+	Status      string
+	Total       int
+	Excepted    int // findings covered by exceptions, left out
+	Checks      int
+	BySeverity  []htmlCount
+	ByCategory  []htmlCount
+	ByNamespace []htmlCount
+	Namespaces  []htmlNamespace
+	CheckErrors []htmlCheckError
+}
+
+type htmlCount struct {
+	Name  string
+	Count int
+}
+
+type htmlNamespace struct {
+	Name      string
+	Count     int
+	Worst     findings.Severity
+	Resources []htmlResource
+}
+
+type htmlResource struct {
+	Kind     string
+	Name     string
+	Worst    findings.Severity
+	Findings []htmlFinding
+}
+
+type htmlFinding struct {
+	findings.Finding
+	Category string
+}
+
+type htmlCheckError struct {
+	ID    string
+	Error string
+}
+
+// countBy counts findings by key, sorted by count and then name
+func countBy(fs []htmlFinding, key func(htmlFinding) string) []htmlCount {
+	counts := map[string]int{}
+	for _, f := range fs {
+		counts[key(f)]++
+	}
+	out := make([]htmlCount, 0, len(counts))
+	for name, n := range counts {
+		out = append(out, htmlCount{Name: name, Count: n})
+	}
+	slices.SortFunc(out, func(a, b htmlCount) int {
+		return cmp.Or(cmp.Compare(b.Count, a.Count), cmp.Compare(a.Name, b.Name))
+	})
+	return out
+}
+
+func worstSeverity(fs []htmlFinding) findings.Severity {
+	worst := fs[0].Severity
+	for _, f := range fs[1:] {
+		if f.Severity.Rank() > worst.Rank() {
+			worst = f.Severity
+		}
+	}
+	return worst
+}
+
+// htmlGroups groups findings by namespace and resource. Namespaces with the
+// worst findings come first, resources are sorted by kind and name and their
+// findings by severity.
+func htmlGroups(fs []htmlFinding) []htmlNamespace {
+	byNamespace := map[string]map[findings.ResourceRef][]htmlFinding{}
+	for _, f := range fs {
+		ns := cmp.Or(f.Namespace, clusterScopedGroup)
+		if byNamespace[ns] == nil {
+			byNamespace[ns] = map[findings.ResourceRef][]htmlFinding{}
+		}
+		r := ResourceOf(f.Finding)
+		byNamespace[ns][r] = append(byNamespace[ns][r], f)
+	}
+
+	groups := make([]htmlNamespace, 0, len(byNamespace))
+	for ns, byResource := range byNamespace {
+		group := htmlNamespace{Name: ns}
+		var all []htmlFinding
+		for r, rfs := range byResource {
+			slices.SortStableFunc(rfs, func(a, b htmlFinding) int { return b.Severity.Rank() - a.Severity.Rank() })
+			group.Resources = append(group.Resources, htmlResource{Kind: r.Kind, Name: r.Name, Worst: rfs[0].Severity, Findings: rfs})
+			all = append(all, rfs...)
+		}
+		slices.SortFunc(group.Resources, func(a, b htmlResource) int {
+			return cmp.Or(cmp.Compare(a.Kind, b.Kind), cmp.Compare(a.Name, b.Name))
+		})
+		group.Count = len(all)
+		group.Worst = worstSeverity(all)
+		groups = append(groups, group)
+	}
+	slices.SortFunc(groups, func(a, b htmlNamespace) int {
+		return cmp.Or(b.Worst.Rank()-a.Worst.Rank(), cmp.Compare(b.Count, a.Count), cmp.Compare(a.Name, b.Name))
+	})
+	return groups
+}
+
+// WriteHTML writes the report as a self-contained HTML page: a summary with the
+// health score and counts by severity, category and namespace, then the findings
+// in collapsible groups by namespace and resource, filterable in the browser
+func WriteHTML(w io.Writer, r *Report, score Score) error {
+	var fs []htmlFinding
+	page := htmlPage{
+		Title:     "Cluster audit report",
+		Cluster:   r.Cluster,
+		Namespace: r.Namespace,
+		Generated: r.FinishedAt,
+		Checks:    len(r.Checks),
+		Score:     score.Value,
+		Status:    score.Status,
+		Excepted:  score.Excepted,
+	}
+	if page.Generated.IsZero() {
+		page.Generated = time.Now()
+	}
+	if r.Cluster != "" {
+		page.Title = "Cluster audit report: " + r.Cluster
+	}
+	for _, c := range r.Checks {
+		for _, f := range c.Findings {
+			fs = append(fs, htmlFinding{Finding: f, Category: cmp.Or(c.Check.Category, "other")})
+		}
+		if c.Err != nil {
+			page.CheckErrors = append(page.CheckErrors, htmlCheckError{ID: c.Check.ID, Error: c.Err.Error()})
+		}
+	}
+
+	page.Total = len(fs)
+	// Every severity is listed, in order, so the summary reads the same for every report
+	bySeverity := map[findings.Severity]int{}
+	for _, f := range fs {
+		bySeverity[f.Severity]++
+	}
+	for _, s := range findings.Severities {
+		page.BySeverity = append(page.BySeverity, htmlCount{Name: string(s), Count: bySeverity[s]})
+	}
+	page.ByCategory = countBy(fs, func(f htmlFinding) string { return f.Category })
+	page.ByNamespace = countBy(fs, func(f htmlFinding) string { return cmp.Or(f.Namespace, clusterScopedGroup) })
+	page.Namespaces = htmlGroups(fs)
+
+	return htmlReport.Execute(w, page)
+}
+
+func OutputReportAsHTML(r *Report, score Score, filename string) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return fmt.Errorf("failed to create HTML file: %w", err)
+	}
+	defer file.Close()
+
+	if err := WriteHTML(file, r, score); err != nil {
+		return fmt.Errorf("failed to write HTML file: %w", err)
+	}
+	return file.Close()
+}
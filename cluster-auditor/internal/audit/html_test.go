@@ -0,0 +1,50 @@
+package audit_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"goprojects/cluster-auditor/internal/audit"
+	"goprojects/findings"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTMLReport(t *testing.T) {
+	limits, _ := audit.LookupCheck("missing-resource-limits")
+	rbac, _ := audit.LookupCheck("risky-rbac")
+	report := &audit.Report{
+		Cluster: "prod",
+		Checks: []audit.CheckReport{
+			{Check: limits, Findings: []findings.Finding{{
+				Namespace: "shop", Kind: "Deployment", Resource: "web", Container: "app",
+				Issue: "Missing CPU limit", Suggestion: "Set resources.limits.cpu <e.g. 500m>",
+				RuleID: limits.ID, Severity: findings.SeverityMedium,
+			}}},
+			{Check: rbac, Err: errors.New("forbidden")},
+		},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, audit.WriteHTML(&buf, report, audit.ScoreReport(report, 0)))
+	page := buf.String()
+
+	require.Contains(t, page, "Cluster audit report: prod")
+	require.Contains(t, page, `<div class="score status-Healthy">98.0</div>`)
+	require.Contains(t, page, `<details class="namespace" data-namespace="shop" open>`)
+	require.Contains(t, page, `data-severity="medium" data-category="reliability"`)
+	require.Contains(t, page, "Set resources.limits.cpu &lt;e.g. 500m&gt;", "finding text is escaped")
+	require.Contains(t, page, "<code>risky-rbac</code> forbidden")
+	require.NotContains(t, page, `src="http`, "the report must not load anything")
+
+	// Excepted findings are left out and counted
+	excepted := report.Except([]findings.Exception{{Namespace: "shop", Reason: "accepted", Owner: "payments"}}, time.Now())
+	buf.Reset()
+	require.NoError(t, audit.WriteHTML(&buf, report, audit.ScoreReport(report, excepted)))
+	page = buf.String()
+	require.Contains(t, page, `<div class="score status-Healthy">100.0</div>`)
+	require.Contains(t, page, "1 more covered by exceptions")
+	require.NotContains(t, page, "Missing CPU limit")
+}
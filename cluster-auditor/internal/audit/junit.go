@@ -0,0 +1,176 @@
+package audit
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"goprojects/findings"
+)
+
+// JUnit XML as read by common CI systems: one testsuite per check and one
+// testcase per audited resource
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Errors   int              `xml:"errors,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name       string          `xml:"name,attr"`
+	Tests      int             `xml:"tests,attr"`
+	Failures   int             `xml:"failures,attr"`
+	Errors     int             `xml:"errors,attr"`
+	Time       string          `xml:"time,attr"`
+	Timestamp  string          `xml:"timestamp,attr,omitempty"`
+	Properties []junitProperty `xml:"properties>property,omitempty"`
+	Cases      []junitTestCase `xml:"testcase"`
+}
+
+type junitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type junitTestCase struct {
+	Name      string       `xml:"name,attr"`
+	Classname string       `xml:"classname,attr"`
+	Time      string       `xml:"time,attr"`
+	Failure   *junitResult `xml:"failure,omitempty"`
+	Error     *junitResult `xml:"error,omitempty"`
+}
+
+type junitResult struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",cdata"`
+}
+
+func junitSeconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
+
+// junitCaseName names the testcase of a resource, e.g. "Deployment shop/web"
+func junitCaseName(r findings.ResourceRef) string {
+	if r.Namespace == "" {
+		return r.Kind + " " + r.Name
+	}
+	return r.Kind + " " + r.Namespace + "/" + r.Name
+}
+
+// junitFailure reports every finding of one resource in a single failure, since
+// most CI systems only show the first failure of a testcase
+func junitFailure(fs []findings.Finding) *junitResult {
+	worst := fs[0].Severity
+	var text strings.Builder
+	for _, f := range fs {
+		if f.Severity.Rank() > worst.Rank() {
+			worst = f.Severity
+		}
+		fmt.Fprintf(&text, "[%s] %s\n", f.Severity, f.Issue)
+		if f.Container != "" {
+			fmt.Fprintf(&text, "Container: %s\n", f.Container)
+		}
+		if f.Suggestion != "" {
+			fmt.Fprintf(&text, "Suggestion: %s\n", f.Suggestion)
+		}
+	}
+
+	message := fs[0].Issue
+	if len(fs) > 1 {
+		message = fmt.Sprintf("%d findings, first: %s", len(fs), fs[0].Issue)
+	}
+	return &junitResult{Message: message, Type: string(worst), Text: text.String()}
+}
+
+func junitSuite(c CheckReport, timestamp time.Time) junitTestSuite {
+	suite := junitTestSuite{
+		Name: c.Check.ID,
+		Time: junitSeconds(c.Duration),
+		Properties: []junitProperty{
+			{Name: "check", Value: c.Check.Name},
+			{Name: "severity", Value: string(c.Check.Severity)},
+		},
+	}
+	if !timestamp.IsZero() {
+		suite.Timestamp = timestamp.UTC().Format(time.RFC3339)
+	}
+
+	// Resources with findings are audited too, even if the check did not mark them
+	byResource := map[findings.ResourceRef][]findings.Finding{}
+	resources := slices.Clone(c.Resources)
+	for _, f := range c.Findings {
+		r := ResourceOf(f)
+		if _, ok := byResource[r]; !ok && !slices.Contains(c.Resources, r) {
+			resources = append(resources, r)
+		}
+		byResource[r] = append(byResource[r], f)
+	}
+	slices.SortFunc(resources, compareResources)
+
+	for _, r := range resources {
+		tc := junitTestCase{Name: junitCaseName(r), Classname: c.Check.ID, Time: junitSeconds(0)}
+		if fs := byResource[r]; len(fs) > 0 {
+			tc.Failure = junitFailure(fs)
+			suite.Failures++
+		}
+		suite.Cases = append(suite.Cases, tc)
+	}
+	if c.Err != nil {
+		suite.Cases = append(suite.Cases, junitTestCase{
+			Name:      c.Check.Name,
+			Classname: c.Check.ID,
+			Time:      junitSeconds(c.Duration),
+			Error:     &junitResult{Message: c.Err.Error(), Type: "CheckError", Text: c.Err.Error()},
+		})
+		suite.Errors++
+	}
+	suite.Tests = len(suite.Cases)
+	return suite
+}
+
+// WriteJUnit writes the report as JUnit XML. Each check is a testsuite and each
+// audited resource a testcase, failed by the resource's findings. A check that
+// could not run has a testcase with an error element.
+func WriteJUnit(w io.Writer, r *Report) error {
+	doc := junitTestSuites{Name: "cluster-auditor", Time: junitSeconds(r.FinishedAt.Sub(r.StartedAt))}
+	for _, c := range r.Checks {
+		suite := junitSuite(c, r.StartedAt)
+		doc.Tests += suite.Tests
+		doc.Failures += suite.Failures
+		doc.Errors += suite.Errors
+		doc.Suites = append(doc.Suites, suite)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func OutputReportAsJUnit(r *Report, filename string) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return fmt.Errorf("failed to create JUnit file: %w", err)
+	}
+	defer file.Close()
+
+	if err := WriteJUnit(file, r); err != nil {
+		return fmt.Errorf("failed to write JUnit file: %w", err)
+	}
+	return file.Close()
+}
@@ -0,0 +1,110 @@
+package audit_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"testing"
+	"time"
+
+	"goprojects/cluster-auditor/internal/audit"
+	"goprojects/findings"
+
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestJUnitReport(t *testing.T) {
+	pvc := func(name string, phase v1.PersistentVolumeClaimPhase) *v1.PersistentVolumeClaim {
+		return &v1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "db"},
+			Status:     v1.PersistentVolumeClaimStatus{Phase: phase},
+		}
+	}
+	client := fake.NewSimpleClientset(pvc("data", v1.ClaimBound), pvc("logs", v1.ClaimPending))
+
+	check, ok := audit.LookupCheck("pvc-not-bound")
+	require.True(t, ok)
+	report := audit.RunReport(context.Background(), findings.NewAuditor(), client, "db", []audit.Check{check})
+	require.Equal(t, []findings.ResourceRef{
+		{Kind: "PersistentVolumeClaim", Namespace: "db", Name: "data"},
+		{Kind: "PersistentVolumeClaim", Namespace: "db", Name: "logs"},
+	}, report.Checks[0].Resources)
+
+	rbac, _ := audit.LookupCheck("risky-rbac")
+	report.Checks = append(report.Checks, audit.CheckReport{Check: rbac, Err: errors.New("check RBAC check failed: forbidden")})
+
+	var buf bytes.Buffer
+	require.NoError(t, audit.WriteJUnit(&buf, report))
+
+	type result struct {
+		Message string `xml:"message,attr"`
+		Type    string `xml:"type,attr"`
+		Text    string `xml:",chardata"`
+	}
+	var doc struct {
+		Tests    int `xml:"tests,attr"`
+		Failures int `xml:"failures,attr"`
+		Errors   int `xml:"errors,attr"`
+		Suites   []struct {
+			Name  string `xml:"name,attr"`
+			Cases []struct {
+				Name    string  `xml:"name,attr"`
+				Failure *result `xml:"failure"`
+				Error   *result `xml:"error"`
+			} `xml:"testcase"`
+		} `xml:"testsuite"`
+	}
+	require.NoError(t, xml.Unmarshal(buf.Bytes(), &doc))
+	require.Equal(t, 3, doc.Tests)
+	require.Equal(t, 1, doc.Failures)
+	require.Equal(t, 1, doc.Errors)
+
+	pvcSuite := doc.Suites[0]
+	require.Equal(t, "pvc-not-bound", pvcSuite.Name)
+	require.Len(t, pvcSuite.Cases, 2)
+	require.Equal(t, "PersistentVolumeClaim db/data", pvcSuite.Cases[0].Name)
+	require.Nil(t, pvcSuite.Cases[0].Failure, "resources without findings pass")
+	failure := pvcSuite.Cases[1].Failure
+	require.NotNil(t, failure)
+	require.Equal(t, "PersistentVolumeClaim is in a Pending state", failure.Message)
+	require.Equal(t, "high", failure.Type)
+	require.Contains(t, failure.Text, "Suggestion: Check if the PersistentVolumeClaim")
+
+	rbacSuite := doc.Suites[1]
+	require.Len(t, rbacSuite.Cases, 1)
+	require.NotNil(t, rbacSuite.Cases[0].Error)
+	require.Contains(t, rbacSuite.Cases[0].Error.Message, "forbidden")
+}
+
+func TestReportFilter(t *testing.T) {
+	pvc := func(ns, name string) *v1.PersistentVolumeClaim {
+		return &v1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: ns},
+			Status:     v1.PersistentVolumeClaimStatus{Phase: v1.ClaimPending},
+		}
+	}
+	client := fake.NewSimpleClientset(pvc("db", "data"), pvc("web", "cache"))
+	check, ok := audit.LookupCheck("pvc-not-bound")
+	require.True(t, ok)
+	report := audit.RunReport(context.Background(), findings.NewAuditor(), client, "", []audit.Check{check})
+	require.Len(t, report.Findings(), 2)
+
+	// The other namespace's failing claim must not show up as passing
+	report.Filter(func(ref findings.ResourceRef) bool { return ref.Namespace == "db" })
+	require.Equal(t, []findings.ResourceRef{{Kind: "PersistentVolumeClaim", Namespace: "db", Name: "data"}}, report.Checks[0].Resources)
+	require.Len(t, report.Findings(), 1)
+	require.Equal(t, "data", report.Findings()[0].Resource)
+}
+
+func TestReportExcept(t *testing.T) {
+	report := &audit.Report{Checks: []audit.CheckReport{
+		{Findings: []findings.Finding{{Namespace: "kube-system", Resource: "kube-proxy"}, {Namespace: "shop", Resource: "web"}}},
+		{Findings: []findings.Finding{{Namespace: "istio-system", Resource: "istiod"}}},
+	}}
+	require.Equal(t, 2, report.Except(findings.DefaultExceptions, time.Now()))
+	require.Equal(t, []findings.Finding{{Namespace: "shop", Resource: "web"}}, report.Findings())
+}
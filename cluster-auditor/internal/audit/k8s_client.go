@@ -1,10 +1,13 @@
 package audit
 
 import (
-	"log"
+	"fmt"
+	"net/http"
 	"os"
 	"path/filepath"
 
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
@@ -28,8 +31,55 @@ func ListAllKubeContexts() ([]string, error) {
 	return contexts, nil
 }
 
+// CurrentClusterName names the cluster GetKubernetesClient connects to: the cluster
+// of the current kubeconfig context, or "in-cluster" when running inside a pod
+func CurrentClusterName() (string, error) {
+	if _, exists := os.LookupEnv("KUBERNETES_SERVICE_HOST"); exists {
+		return "in-cluster", nil
+	}
+	kubeconfig := os.Getenv("KUBECONFIG")
+	if kubeconfig == "" {
+		kubeconfig = os.ExpandEnv("$HOME/.kube/config")
+	}
+	config, err := clientcmd.LoadFromFile(kubeconfig)
+	if err != nil {
+		return "", err
+	}
+	if ctx, ok := config.Contexts[config.CurrentContext]; ok && ctx.Cluster != "" {
+		return ctx.Cluster, nil
+	}
+	return config.CurrentContext, nil
+}
+
 // GetKubernetesClient initializes the client for interacting with the cluster
 func GetKubernetesClient() (kubernetes.Interface, error) {
+	config, err := restConfig()
+	if err != nil {
+		return nil, err
+	}
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+
+	return clientset, nil
+}
+
+// GetDynamicClient initializes a client for custom resources, like policy reports,
+// connecting to the same cluster as GetKubernetesClient
+func GetDynamicClient() (dynamic.Interface, error) {
+	config, err := restConfig()
+	if err != nil {
+		return nil, err
+	}
+	client, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create dynamic Kubernetes client: %w", err)
+	}
+	return client, nil
+}
+
+func restConfig() (*rest.Config, error) {
 	var config *rest.Config
 	var err error
 
@@ -38,7 +88,7 @@ func GetKubernetesClient() (kubernetes.Interface, error) {
 		// In-cluster configuration
 		config, err = rest.InClusterConfig()
 		if err != nil {
-			log.Fatalf("Failed to create in-cluster config: %v", err)
+			return nil, fmt.Errorf("failed to create in-cluster config: %w", err)
 		}
 	} else {
 		// Running locally - use KUBECONFIG
@@ -49,14 +99,16 @@ func GetKubernetesClient() (kubernetes.Interface, error) {
 		}
 		config, err = clientcmd.BuildConfigFromFlags("", kubeconfig)
 		if err != nil {
-			log.Fatalf("Failed to load kubeconfig: %v", err)
+			return nil, fmt.Errorf("failed to load kubeconfig: %w", err)
 		}
 	}
 
-	clientset, err := kubernetes.NewForConfig(config)
-	if err != nil {
-		log.Fatalf("Failed to create Kubernetes client: %v", err)
-	}
-
-	return clientset, nil
+	// Every API call gets a client span, named after the request so slow list
+	// calls stand out in a trace
+	config.Wrap(func(rt http.RoundTripper) http.RoundTripper {
+		return otelhttp.NewTransport(rt, otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
+			return "k8s " + r.Method + " " + r.URL.Path
+		}))
+	})
+	return config, nil
 }
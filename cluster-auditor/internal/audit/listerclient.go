@@ -0,0 +1,158 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+// objectLister lists the objects of a resource in a namespace, or in all
+// namespaces if namespace is empty
+type objectLister func(gvr schema.GroupVersionResource, namespace string) ([]runtime.Object, error)
+
+// listerClient returns a client whose list requests of the watched kinds are
+// served by list, so the checks run unchanged against informer caches or objects
+// in memory. Any other request fails.
+func listerClient(list objectLister) kubernetes.Interface {
+	return kubernetes.NewForConfigOrDie(&rest.Config{
+		Host:      "http://objects.invalid",
+		Transport: listerTransport{list},
+		QPS:       -1, // nothing to protect, no client-side throttling
+	})
+}
+
+// objectsLister lists from a fixed set of typed objects
+func objectsLister(objects []runtime.Object) (objectLister, error) {
+	byResource := map[schema.GroupVersionResource][]runtime.Object{}
+	for _, obj := range objects {
+		kind, err := KindOf(obj)
+		if err != nil {
+			return nil, err
+		}
+		if wk, ok := watchedKinds[kind]; ok {
+			byResource[wk.gvr] = append(byResource[wk.gvr], obj)
+		}
+	}
+	return func(gvr schema.GroupVersionResource, namespace string) ([]runtime.Object, error) {
+		var out []runtime.Object
+		for _, obj := range byResource[gvr] {
+			accessor, err := meta.Accessor(obj)
+			if err != nil {
+				return nil, err
+			}
+			if namespace == "" || accessor.GetNamespace() == namespace {
+				out = append(out, obj)
+			}
+		}
+		return out, nil
+	}, nil
+}
+
+type listerTransport struct {
+	list objectLister
+}
+
+func (t listerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+	if req.Method != http.MethodGet || req.URL.Query().Get("watch") == "true" {
+		return statusResponse(apierrors.NewMethodNotSupported(schema.GroupResource{}, req.Method))
+	}
+	gvr, namespace, ok := parseListPath(req.URL.Path)
+	if !ok {
+		return statusResponse(apierrors.NewNotFound(schema.GroupResource{}, req.URL.Path))
+	}
+	kind, ok := kindOfResource(gvr)
+	if !ok {
+		return statusResponse(apierrors.NewNotFound(gvr.GroupResource(), ""))
+	}
+	if req.URL.Query().Get("fieldSelector") != "" {
+		return statusResponse(apierrors.NewBadRequest("field selectors are not supported"))
+	}
+	selector, err := labels.Parse(req.URL.Query().Get("labelSelector"))
+	if err != nil {
+		return statusResponse(apierrors.NewBadRequest(err.Error()))
+	}
+
+	objects, err := t.list(gvr, namespace)
+	if err != nil {
+		return statusResponse(apierrors.NewInternalError(err))
+	}
+	items := []runtime.Object{}
+	for _, obj := range objects {
+		accessor, err := meta.Accessor(obj)
+		if err != nil {
+			return statusResponse(apierrors.NewInternalError(err))
+		}
+		if selector.Matches(labels.Set(accessor.GetLabels())) {
+			items = append(items, obj)
+		}
+	}
+	return jsonResponse(http.StatusOK, map[string]any{
+		"apiVersion": gvr.GroupVersion().String(),
+		"kind":       kind + "List",
+		"metadata":   map[string]any{},
+		"items":      items,
+	})
+}
+
+// parseListPath parses the path of a list request, e.g. /api/v1/pods or
+// /apis/apps/v1/namespaces/shop/deployments
+func parseListPath(path string) (gvr schema.GroupVersionResource, namespace string, ok bool) {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	switch {
+	case len(parts) >= 2 && parts[0] == "api":
+		gvr.Version, parts = parts[1], parts[2:]
+	case len(parts) >= 3 && parts[0] == "apis":
+		gvr.Group, gvr.Version, parts = parts[1], parts[2], parts[3:]
+	default:
+		return gvr, "", false
+	}
+	if len(parts) == 3 && parts[0] == "namespaces" {
+		namespace, parts = parts[1], parts[2:]
+	}
+	if len(parts) != 1 {
+		return gvr, "", false
+	}
+	gvr.Resource = parts[0]
+	return gvr, namespace, true
+}
+
+func kindOfResource(gvr schema.GroupVersionResource) (string, bool) {
+	for kind, wk := range watchedKinds {
+		if wk.gvr == gvr {
+			return kind, true
+		}
+	}
+	return "", false
+}
+
+func statusResponse(err *apierrors.StatusError) (*http.Response, error) {
+	status := err.Status()
+	status.APIVersion, status.Kind = "v1", "Status"
+	return jsonResponse(int(status.Code), status)
+}
+
+func jsonResponse(code int, body any) (*http.Response, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode response: %w", err)
+	}
+	return &http.Response{
+		StatusCode: code,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(bytes.NewReader(data)),
+	}, nil
+}
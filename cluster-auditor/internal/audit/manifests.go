@@ -0,0 +1,213 @@
+package audit
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"goprojects/findings"
+
+	"gopkg.in/yaml.v3"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+)
+
+// ManifestSource is where an object was defined in the manifest files
+type ManifestSource struct {
+	File string
+	Line int // line of the document's first field, 1-based
+}
+
+// Manifests are Kubernetes objects read from files, audited without a cluster
+type Manifests struct {
+	Objects []runtime.Object
+	sources map[manifestKey]ManifestSource
+	fields  map[manifestKey]*unstructured.Unstructured
+}
+
+type manifestKey struct{ kind, namespace, name string }
+
+// clusterScopedKinds are the kinds read by the checks that have no namespace
+var clusterScopedKinds = map[string]bool{
+	"Namespace": true, "PersistentVolume": true, "ClusterRole": true, "ClusterRoleBinding": true,
+}
+
+// LoadManifests reads the objects of the given YAML or JSON files. Directories are
+// searched recursively for *.yaml, *.yml and *.json files. Namespaced objects
+// without a namespace are placed in the default namespace, like kubectl apply
+// does. Documents of kinds the checks cannot read, e.g. custom resources, are
+// skipped.
+func LoadManifests(paths ...string) (*Manifests, error) {
+	m := &Manifests{sources: map[manifestKey]ManifestSource{}, fields: map[manifestKey]*unstructured.Unstructured{}}
+	for _, root := range paths {
+		err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				return nil
+			}
+			// Files named explicitly are read whatever their extension
+			switch strings.ToLower(filepath.Ext(path)) {
+			case ".yaml", ".yml", ".json":
+			default:
+				if path != root {
+					return nil
+				}
+			}
+			return m.loadFile(path)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+func (m *Manifests) loadFile(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open manifest: %w", err)
+	}
+	defer file.Close()
+
+	dec := yaml.NewDecoder(file)
+	for {
+		var doc yaml.Node
+		err := dec.Decode(&doc)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+		if len(doc.Content) == 0 {
+			continue // empty document, e.g. after a trailing ---
+		}
+		if err := m.addDocument(path, doc.Content[0]); err != nil {
+			return err
+		}
+	}
+}
+
+func (m *Manifests) addDocument(path string, node *yaml.Node) error {
+	source := ManifestSource{File: path, Line: node.Line}
+
+	var fields map[string]any
+	if err := node.Decode(&fields); err != nil {
+		return fmt.Errorf("%s:%d: %w", path, source.Line, err)
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return fmt.Errorf("%s:%d: %w", path, source.Line, err)
+	}
+
+	u := &unstructured.Unstructured{}
+	if err := u.UnmarshalJSON(data); err != nil {
+		return fmt.Errorf("%s:%d: %w", path, source.Line, err)
+	}
+	// Lists, as written by kubectl get -o yaml, contain the objects as items
+	if u.IsList() {
+		list, err := u.ToList()
+		if err != nil {
+			return fmt.Errorf("%s:%d: %w", path, source.Line, err)
+		}
+		items := mappingValue(node, "items")
+		for i := range list.Items {
+			itemSource := source
+			if items != nil && i < len(items.Content) {
+				itemSource.Line = items.Content[i].Line
+			}
+			if err := m.addObject(&list.Items[i], itemSource); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return m.addObject(u, source)
+}
+
+// mappingValue returns the value node of a key in a YAML mapping, nil if missing
+func mappingValue(mapping *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i+1]
+		}
+	}
+	return nil
+}
+
+func (m *Manifests) addObject(u *unstructured.Unstructured, source ManifestSource) error {
+	kind := u.GetKind()
+	written := u.DeepCopy()
+	if !clusterScopedKinds[kind] && u.GetNamespace() == "" {
+		u.SetNamespace("default")
+	}
+
+	data, err := u.MarshalJSON()
+	if err != nil {
+		return fmt.Errorf("%s:%d: %w", source.File, source.Line, err)
+	}
+	obj, _, err := scheme.Codecs.UniversalDeserializer().Decode(data, nil, nil)
+	if runtime.IsNotRegisteredError(err) {
+		slog.Debug("Skipping manifest of unknown kind", "file", source.File, "line", source.Line, "kind", kind)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("%s:%d: %w", source.File, source.Line, err)
+	}
+
+	key := manifestKey{kind, u.GetNamespace(), u.GetName()}
+	if other, ok := m.sources[key]; ok {
+		return fmt.Errorf("%s:%d: %s %s is already defined at %s:%d", source.File, source.Line, kind,
+			strings.TrimPrefix(u.GetNamespace()+"/"+u.GetName(), "/"), other.File, other.Line)
+	}
+	m.Objects = append(m.Objects, obj)
+	m.sources[key] = source
+	m.fields[key] = written
+	return nil
+}
+
+// Client returns a client serving the manifests' objects, for running checks
+func (m *Manifests) Client() kubernetes.Interface {
+	list, err := objectsLister(m.Objects)
+	if err != nil {
+		panic(err) // objects are only added once decoded into a registered type
+	}
+	return listerClient(list)
+}
+
+// Locate returns where the resource of a finding was defined
+func (m *Manifests) Locate(f findings.Finding) (ManifestSource, bool) {
+	if m == nil {
+		return ManifestSource{}, false
+	}
+	if s, ok := m.sources[manifestKey{f.Kind, f.Namespace, f.Resource}]; ok {
+		return s, true
+	}
+	// Findings about a namespace itself carry the namespace's name as their namespace
+	s, ok := m.sources[manifestKey{f.Kind, "", f.Resource}]
+	return s, ok
+}
+
+// Object returns the object of a resource as written in the manifests. Objects
+// placed in the default namespace are returned without one, so the namespace of
+// kubectl apply -n or kustomize still applies.
+func (m *Manifests) Object(ref findings.ResourceRef) (*unstructured.Unstructured, bool) {
+	u, ok := m.fields[manifestKey{ref.Kind, ref.Namespace, ref.Name}]
+	if !ok {
+		return nil, false
+	}
+	return u.DeepCopy(), true
+}
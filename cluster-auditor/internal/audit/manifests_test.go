@@ -0,0 +1,42 @@
+package audit_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"goprojects/cluster-auditor/internal/audit"
+	"goprojects/findings"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestManifestsObject(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "app.yaml"), []byte(privilegedManifest), 0600))
+	m, err := audit.LoadManifests(dir)
+	require.NoError(t, err)
+
+	// The checks see the ConfigMap in the default namespace, the fixes see it as written
+	obj, ok := m.Object(findings.ResourceRef{Kind: "ConfigMap", Namespace: "default", Name: "settings"})
+	require.True(t, ok)
+	require.Empty(t, obj.GetNamespace())
+	_, found := obj.Object["metadata"].(map[string]any)["namespace"]
+	require.False(t, found)
+
+	obj, ok = m.Object(findings.ResourceRef{Kind: "Deployment", Namespace: "shop", Name: "web"})
+	require.True(t, ok)
+	require.Equal(t, "shop", obj.GetNamespace())
+
+	_, ok = m.Object(findings.ResourceRef{Kind: "Deployment", Namespace: "default", Name: "web"})
+	require.False(t, ok)
+}
+
+func TestLoadManifestsDuplicates(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.yaml"), []byte(privilegedManifest), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "b.yaml"), []byte(privilegedManifest), 0600))
+
+	_, err := audit.LoadManifests(dir)
+	require.EqualError(t, err, filepath.Join(dir, "b.yaml")+":1: ConfigMap default/settings is already defined at "+filepath.Join(dir, "a.yaml")+":1")
+}
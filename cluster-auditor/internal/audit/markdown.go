@@ -0,0 +1,157 @@
+package audit
+
+import (
+	"bytes"
+	"cmp"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+
+	"goprojects/findings"
+)
+
+// DefaultMarkdownMaxSize fits a GitHub comment, which is limited to 65536 characters
+const DefaultMarkdownMaxSize = 65000
+
+var markdownEscaper = strings.NewReplacer("|", `\|`, "<", "&lt;", ">", "&gt;", "\r\n", " ", "\n", " ")
+
+// markdownCell escapes text for a table cell, where pipes end the cell and
+// tags would be rendered as HTML
+func markdownCell(s string) string {
+	return markdownEscaper.Replace(s)
+}
+
+func severityCounts(fs []findings.Finding) map[findings.Severity]int {
+	counts := map[findings.Severity]int{}
+	for _, f := range fs {
+		counts[f.Severity]++
+	}
+	return counts
+}
+
+// severityList describes counts like "1 critical, 3 medium", skipping zeros
+func severityList(counts map[findings.Severity]int) string {
+	var parts []string
+	for _, s := range findings.Severities {
+		if counts[s] > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", counts[s], s))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+func writeMarkdownSummary(b *bytes.Buffer, r *Report, score Score, fs []findings.Finding) {
+	title := "Cluster audit"
+	if r.Cluster != "" {
+		title += ": " + r.Cluster
+	}
+	if r.Namespace != "" {
+		title += " (namespace " + r.Namespace + ")"
+	}
+	fmt.Fprintf(b, "## %s\n\n", markdownCell(title))
+	fmt.Fprintf(b, "**Health score %.1f** (%s), %s from %s\n\n", score.Value, score.Status, plural(len(fs), "finding"), plural(len(r.Checks), "check"))
+	if errs := r.Errors(); len(errs) > 0 {
+		ids := []string{}
+		for _, c := range r.Checks {
+			if c.Err != nil {
+				ids = append(ids, "`"+c.Check.ID+"`")
+			}
+		}
+		fmt.Fprintf(b, "> [!WARNING]\n> %s failed, their findings are missing: %s\n\n", plural(len(errs), "check"), strings.Join(ids, ", "))
+	}
+	if len(fs) == 0 {
+		return
+	}
+
+	counts := severityCounts(fs)
+	b.WriteString("| Severity | Findings |\n|---|---:|\n")
+	for _, s := range findings.Severities {
+		if counts[s] > 0 {
+			fmt.Fprintf(b, "| %s | %d |\n", s, counts[s])
+		}
+	}
+
+	type ruleCount struct {
+		rule     string
+		severity findings.Severity
+		count    int
+	}
+	var rules []ruleCount
+	for _, c := range r.Checks {
+		if len(c.Findings) > 0 {
+			rules = append(rules, ruleCount{c.Check.ID, c.Check.Severity, len(c.Findings)})
+		}
+	}
+	slices.SortStableFunc(rules, func(a, b ruleCount) int {
+		return cmp.Or(b.severity.Rank()-a.severity.Rank(), cmp.Compare(b.count, a.count))
+	})
+	b.WriteString("\n| Rule | Severity | Findings |\n|---|---|---:|\n")
+	for _, rc := range rules {
+		fmt.Fprintf(b, "| `%s` | %s | %d |\n", rc.rule, rc.severity, rc.count)
+	}
+	b.WriteString("\n")
+}
+
+func writeMarkdownFindings(b *bytes.Buffer, fs []findings.Finding) {
+	namespaces, byNamespace := groupByNamespace(fs)
+	for _, ns := range namespaces {
+		nfs := byNamespace[ns]
+		fmt.Fprintf(b, "<details>\n<summary><b>%s</b>: %s (%s)</summary>\n\n", markdownCell(ns), plural(len(nfs), "finding"), severityList(severityCounts(nfs)))
+		b.WriteString("| Severity | Resource | Issue | Suggestion |\n|---|---|---|---|\n")
+		for _, f := range nfs {
+			resource := f.Kind + "/" + f.Resource
+			if f.Container != "" {
+				resource += " (" + f.Container + ")"
+			}
+			fmt.Fprintf(b, "| %s | %s | %s | %s |\n", f.Severity, markdownCell(resource), markdownCell(f.Issue), markdownCell(f.Suggestion))
+		}
+		b.WriteString("\n</details>\n\n")
+	}
+}
+
+func renderMarkdown(r *Report, score Score, all, shown []findings.Finding) []byte {
+	var b bytes.Buffer
+	writeMarkdownSummary(&b, r, score, all)
+	writeMarkdownFindings(&b, shown)
+	if omitted := len(all) - len(shown); omitted > 0 {
+		fmt.Fprintf(&b, "_%s omitted to fit the size limit: %s._\n", plural(omitted, "finding"), severityList(severityCounts(all[len(shown):])))
+	}
+	return b.Bytes()
+}
+
+// WriteMarkdown writes a summary of the report for merge request comments and
+// chat: counts by severity and rule, then the findings in a collapsible section
+// per namespace. If the result is larger than maxSize bytes, the least severe
+// findings are left out and counted instead; the summary is always written. A
+// maxSize of 0 means no limit.
+func WriteMarkdown(w io.Writer, r *Report, score Score, maxSize int) error {
+	all := r.Findings()
+	slices.SortStableFunc(all, func(a, b findings.Finding) int { return b.Severity.Rank() - a.Severity.Rank() })
+
+	out := renderMarkdown(r, score, all, all)
+	if maxSize > 0 && len(out) > maxSize {
+		// The output grows with the findings kept, so search for the most that fit
+		keep := sort.Search(len(all), func(n int) bool {
+			return len(renderMarkdown(r, score, all, all[:n+1])) > maxSize
+		})
+		out = renderMarkdown(r, score, all, all[:keep])
+	}
+	_, err := w.Write(out)
+	return err
+}
+
+func OutputReportAsMarkdown(r *Report, score Score, filename string, maxSize int) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return fmt.Errorf("failed to create Markdown file: %w", err)
+	}
+	defer file.Close()
+
+	if err := WriteMarkdown(file, r, score, maxSize); err != nil {
+		return fmt.Errorf("failed to write Markdown file: %w", err)
+	}
+	return file.Close()
+}
@@ -0,0 +1,43 @@
+package audit_test
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"goprojects/cluster-auditor/internal/audit"
+	"goprojects/findings"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMarkdownBudget(t *testing.T) {
+	limits, _ := audit.LookupCheck("missing-resource-limits")
+	privileged, _ := audit.LookupCheck("privileged-container")
+	report := &audit.Report{Cluster: "prod", Checks: []audit.CheckReport{{Check: limits}, {Check: privileged}}}
+	for i := range 20 {
+		report.Checks[0].Findings = append(report.Checks[0].Findings, findings.Finding{
+			Namespace: "shop", Kind: "Deployment", Resource: fmt.Sprintf("web-%02d", i),
+			Issue: "Missing resource Limits Requests", Severity: findings.SeverityMedium,
+		})
+	}
+	report.Checks[1].Findings = []findings.Finding{{
+		Namespace: "shop", Kind: "Deployment", Resource: "admin", Container: "app",
+		Issue: "Container is running with privileged mode enabled", Suggestion: "Use a | pipe <safely>",
+		Severity: findings.SeverityCritical,
+	}}
+
+	var full bytes.Buffer
+	require.NoError(t, audit.WriteMarkdown(&full, report, audit.ScoreReport(report, 0), 0))
+	require.Contains(t, full.String(), "<summary><b>shop</b>: 21 findings (1 critical, 20 medium)</summary>")
+	require.Contains(t, full.String(), "| `missing-resource-limits` | medium | 20 |")
+	require.Contains(t, full.String(), "| critical | Deployment/admin (app) | Container is running with privileged mode enabled | Use a \\| pipe &lt;safely&gt; |")
+	require.NotContains(t, full.String(), "omitted")
+
+	var limited bytes.Buffer
+	require.NoError(t, audit.WriteMarkdown(&limited, report, audit.ScoreReport(report, 0), full.Len()/2))
+	require.LessOrEqual(t, limited.Len(), full.Len()/2)
+	require.Contains(t, limited.String(), "Deployment/admin", "the most severe findings are kept")
+	require.Regexp(t, `_\d+ findings omitted to fit the size limit: \d+ medium._`, limited.String())
+	require.Contains(t, limited.String(), "| medium | 20 |", "the summary counts every finding")
+}
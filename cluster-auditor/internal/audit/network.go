@@ -10,15 +10,18 @@ import (
 	"k8s.io/client-go/kubernetes"
 )
 
-func CheckMissingNetworkPolicy(a *findings.Auditor, client kubernetes.Interface, namespace string) error {
+func CheckMissingNetworkPolicy(ctx context.Context, a *findings.Auditor, client kubernetes.Interface, namespace string) error {
 
-	networkPolicies, err := client.NetworkingV1().NetworkPolicies(namespace).List(context.TODO(), metav1.ListOptions{})
+	networkPolicies, err := client.NetworkingV1().NetworkPolicies(namespace).List(ctx, metav1.ListOptions{})
 	if err != nil {
 		return fmt.Errorf("failed to list NetworkPolicies: %w", err)
 	}
 
+	if namespace != "" {
+		a.MarkAudited("Namespace", namespace, namespace)
+	}
 	if len(networkPolicies.Items) == 0 {
-		a.AddFindingWithFilter(findings.Finding{
+		a.AddFinding(findings.Finding{
 			Namespace:  namespace,
 			Resource:   namespace,
 			Kind:       "Namespace",
@@ -31,9 +34,9 @@ func CheckMissingNetworkPolicy(a *findings.Auditor, client kubernetes.Interface,
 	return nil
 }
 
-func CheckPortTargetConflicts(a *findings.Auditor, client kubernetes.Interface, namespace string) error {
+func CheckPortTargetConflicts(ctx context.Context, a *findings.Auditor, client kubernetes.Interface, namespace string) error {
 
-	services, err := client.CoreV1().Services(namespace).List(context.TODO(), metav1.ListOptions{})
+	services, err := client.CoreV1().Services(namespace).List(ctx, metav1.ListOptions{})
 	if err != nil {
 		return fmt.Errorf("failed to list Services: %w", err)
 	}
@@ -45,6 +48,7 @@ func CheckPortTargetConflicts(a *findings.Auditor, client kubernetes.Interface,
 	seen := make(map[portKey]string)
 
 	for _, svc := range services.Items {
+		a.MarkAudited("Service", svc.Namespace, svc.Name)
 		for _, p := range svc.Spec.Ports {
 			if p.TargetPort.IntVal == 0 {
 				continue // could be named port, or empty
@@ -0,0 +1,146 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"sync"
+	"time"
+
+	"goprojects/findings"
+
+	"gopkg.in/yaml.v3"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// DefaultTeamKey is the namespace label or annotation naming the owning team
+const DefaultTeamKey = "team"
+
+// TeamMapping assigns namespaces to teams by name or glob pattern, e.g.
+//
+//	teams:
+//	  payments: [payments, payments-*]
+//	  platform: [monitoring, ingress-*]
+type TeamMapping struct {
+	Teams map[string][]string `yaml:"teams"`
+}
+
+// LoadTeamMapping reads a team mapping file
+func LoadTeamMapping(filename string) (*TeamMapping, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read team mapping: %w", err)
+	}
+	var m TeamMapping
+	if err := yaml.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("failed to parse team mapping %s: %w", filename, err)
+	}
+	for team, patterns := range m.Teams {
+		for _, p := range patterns {
+			if _, err := path.Match(p, ""); err != nil {
+				return nil, fmt.Errorf("team %s: invalid namespace pattern %q", team, p)
+			}
+		}
+	}
+	return &m, nil
+}
+
+// Team returns the team owning the namespace. Exact names win over patterns, and
+// when several patterns match the team that sorts first wins, so the result does
+// not depend on map order.
+func (m *TeamMapping) Team(namespace string) (string, bool) {
+	if m == nil {
+		return "", false
+	}
+	teams := make([]string, 0, len(m.Teams))
+	for team := range m.Teams {
+		teams = append(teams, team)
+	}
+	sort.Strings(teams)
+
+	for _, team := range teams {
+		for _, p := range m.Teams[team] {
+			if p == namespace {
+				return team, true
+			}
+		}
+	}
+	for _, team := range teams {
+		for _, p := range m.Teams[team] {
+			if ok, _ := path.Match(p, namespace); ok {
+				return team, true
+			}
+		}
+	}
+	return "", false
+}
+
+// TeamResolver looks up the team owning a namespace: from the mapping if it
+// covers the namespace, otherwise from the namespace's label or annotation.
+// Namespace lookups are cached for TTL.
+type TeamResolver struct {
+	Client  kubernetes.Interface // nil to only use the mapping
+	Mapping *TeamMapping
+	Key     string        // label/annotation key, defaults to DefaultTeamKey
+	TTL     time.Duration // defaults to 5 minutes
+
+	mu    sync.Mutex
+	cache map[string]cachedTeam
+}
+
+type cachedTeam struct {
+	team    string
+	expires time.Time
+}
+
+// Team returns the owning team of the namespace, empty if it has none
+func (r *TeamResolver) Team(ctx context.Context, namespace string) string {
+	if namespace == "" {
+		return ""
+	}
+	if team, ok := r.Mapping.Team(namespace); ok {
+		return team
+	}
+	if r.Client == nil {
+		return ""
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if c, ok := r.cache[namespace]; ok && time.Now().Before(c.expires) {
+		return c.team
+	}
+
+	key, ttl := r.Key, r.TTL
+	if key == "" {
+		key = DefaultTeamKey
+	}
+	if ttl == 0 {
+		ttl = 5 * time.Minute
+	}
+
+	var team string
+	ns, err := r.Client.CoreV1().Namespaces().Get(ctx, namespace, metav1.GetOptions{})
+	if err == nil {
+		team = ns.Labels[key]
+		if team == "" {
+			team = ns.Annotations[key]
+		}
+	}
+	// Failed lookups are cached too, findings just stay unowned until the entry expires
+	if r.cache == nil {
+		r.cache = map[string]cachedTeam{}
+	}
+	r.cache[namespace] = cachedTeam{team: team, expires: time.Now().Add(ttl)}
+	return team
+}
+
+// AssignTeams sets the owning team of every finding that has a namespace
+func (r *TeamResolver) AssignTeams(ctx context.Context, fs []findings.Finding) {
+	for i := range fs {
+		fs[i].Team = r.Team(ctx, fs[i].Namespace)
+	}
+}
@@ -0,0 +1,53 @@
+package audit_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"goprojects/cluster-auditor/internal/audit"
+	"goprojects/findings"
+
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestTeamResolver(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "teams.yaml")
+	mapping := "teams:\n  payments: [payments-*]\n  platform: [monitoring, payments-infra]\n"
+	require.NoError(t, os.WriteFile(path, []byte(mapping), 0600))
+	m, err := audit.LoadTeamMapping(path)
+	require.NoError(t, err)
+
+	client := fake.NewSimpleClientset(
+		&v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "search", Labels: map[string]string{"team": "discovery"}}},
+		&v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "legacy", Annotations: map[string]string{"team": "core"}}},
+		&v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "payments-api", Labels: map[string]string{"team": "ignored"}}},
+		&v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default"}},
+	)
+	r := &audit.TeamResolver{Client: client, Mapping: m}
+
+	fs := []findings.Finding{
+		{Namespace: "payments-api"},   // the mapping wins over the label
+		{Namespace: "payments-infra"}, // exact names win over patterns
+		{Namespace: "search"},
+		{Namespace: "legacy"},
+		{Namespace: "default"},
+		{Namespace: ""},
+		{Namespace: "missing"},
+	}
+	r.AssignTeams(context.Background(), fs)
+
+	var teams []string
+	for _, f := range fs {
+		teams = append(teams, f.Team)
+	}
+	require.Equal(t, []string{"payments", "platform", "discovery", "core", "", "", ""}, teams)
+
+	require.NoError(t, os.WriteFile(path, []byte("teams:\n  broken: ['[']\n"), 0600))
+	_, err = audit.LoadTeamMapping(path)
+	require.Error(t, err)
+}
@@ -0,0 +1,307 @@
+package audit
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"goprojects/findings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+// PolicyReport API of the Kubernetes Policy working group, read by kubectl,
+// Policy Reporter and other tools. See
+// https://github.com/kubernetes-sigs/wg-policy-prototypes/tree/master/policy-report
+var (
+	PolicyReportGVR        = schema.GroupVersionResource{Group: "wgpolicyk8s.io", Version: "v1alpha2", Resource: "policyreports"}
+	ClusterPolicyReportGVR = schema.GroupVersionResource{Group: "wgpolicyk8s.io", Version: "v1alpha2", Resource: "clusterpolicyreports"}
+)
+
+const (
+	// PolicyReportName names the report written to each namespace, and the
+	// cluster report
+	PolicyReportName   = "cluster-auditor"
+	policyReportSource = "cluster-auditor"
+	managedByLabel     = "app.kubernetes.io/managed-by"
+)
+
+type policyReportResource struct {
+	APIVersion string `json:"apiVersion,omitempty"`
+	Kind       string `json:"kind"`
+	Namespace  string `json:"namespace,omitempty"`
+	Name       string `json:"name"`
+}
+
+type policyReportTimestamp struct {
+	Seconds int64 `json:"seconds"`
+	Nanos   int32 `json:"nanos"`
+}
+
+type policyReportResult struct {
+	Policy     string                 `json:"policy"`
+	Category   string                 `json:"category,omitempty"`
+	Severity   string                 `json:"severity,omitempty"`
+	Result     string                 `json:"result"` // pass, fail, warn, error or skip
+	Message    string                 `json:"message,omitempty"`
+	Scored     bool                   `json:"scored"`
+	Source     string                 `json:"source"`
+	Resources  []policyReportResource `json:"resources,omitempty"`
+	Properties map[string]string      `json:"properties,omitempty"`
+	Timestamp  policyReportTimestamp  `json:"timestamp"`
+}
+
+type policyReportSummary struct {
+	Pass  int64 `json:"pass"`
+	Fail  int64 `json:"fail"`
+	Warn  int64 `json:"warn"`
+	Error int64 `json:"error"`
+	Skip  int64 `json:"skip"`
+}
+
+// policyReportResultOf maps a finding to a failed result. Findings suppressed by
+// an exception are skipped results, so tools can tell accepted risks apart.
+func policyReportResultOf(f findings.Finding, exception *findings.Exception, at time.Time) policyReportResult {
+	res := policyReportResult{
+		Policy:   f.RuleID,
+		Severity: string(f.Severity),
+		Result:   "fail",
+		Message:  f.Issue,
+		Scored:   true,
+		Source:   policyReportSource,
+		Resources: []policyReportResource{{
+			Kind: f.Kind, Namespace: f.Namespace, Name: f.Resource,
+		}},
+		Properties: map[string]string{"fingerprint": cmp.Or(f.Fingerprint, findings.ComputeFingerprint(f))},
+		Timestamp:  policyReportTimestamp{Seconds: at.Unix(), Nanos: int32(at.Nanosecond())},
+	}
+	if check, ok := LookupCheck(f.RuleID); ok {
+		res.Category = check.Category
+	}
+	if wk, ok := watchedKinds[f.Kind]; ok {
+		res.Resources[0].APIVersion = wk.gvr.GroupVersion().String()
+	}
+	for key, value := range map[string]string{
+		"container":  f.Container,
+		"suggestion": f.Suggestion,
+		"team":       f.Team,
+		"subjects":   strings.Join(f.Subjects, ", "),
+	} {
+		if value != "" {
+			res.Properties[key] = value
+		}
+	}
+	if exception != nil {
+		res.Result = "skip"
+		res.Properties["exception"] = fmt.Sprintf("%d: %s (owner %s)", exception.ID, exception.Reason, exception.Owner)
+	}
+	return res
+}
+
+func comparePolicyReportResults(a, b policyReportResult) int {
+	ra, rb := a.Resources[0], b.Resources[0]
+	return cmp.Or(
+		findings.Severity(b.Severity).Rank()-findings.Severity(a.Severity).Rank(),
+		cmp.Compare(a.Policy, b.Policy),
+		compareResources(
+			findings.ResourceRef{Kind: ra.Kind, Namespace: ra.Namespace, Name: ra.Name},
+			findings.ResourceRef{Kind: rb.Kind, Namespace: rb.Namespace, Name: rb.Name},
+		),
+		cmp.Compare(a.Properties["container"], b.Properties["container"]),
+		cmp.Compare(a.Message, b.Message),
+	)
+}
+
+// WritePolicyReports writes the findings of a report into the cluster as a
+// PolicyReport per namespace and a ClusterPolicyReport for cluster-scoped
+// resources, all named PolicyReportName. Reports written by earlier runs are
+// updated, and deleted once they have no results left. Results of checks that
+// failed or did not run in this run are kept from the previous report, like the
+// findings store replaces findings check by check. A report of one namespace only
+// touches that namespace's PolicyReport.
+// Findings matched by an active exception are reported as skipped.
+func WritePolicyReports(ctx context.Context, client dynamic.Interface, r *Report, exceptions []findings.Exception) error {
+	existing, err := listPolicyReports(ctx, client, r.Namespace)
+	if err != nil {
+		return err
+	}
+
+	results := map[string][]policyReportResult{} // by namespace, "" for cluster-scoped
+	replaced := map[string]bool{}                // checks whose previous results are replaced
+	for _, c := range r.Checks {
+		if c.Err != nil {
+			continue
+		}
+		replaced[c.Check.ID] = true
+		for _, f := range c.Findings {
+			if r.Namespace != "" && f.Namespace != r.Namespace {
+				continue
+			}
+			var exception *findings.Exception
+			if e, ok := findings.MatchException(f, exceptions, r.FinishedAt); ok {
+				exception = &e
+			}
+			results[f.Namespace] = append(results[f.Namespace], policyReportResultOf(f, exception, r.FinishedAt))
+		}
+	}
+
+	var errs []error
+	for ns, obj := range existing {
+		prev, err := policyReportResults(obj)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("policy report of %q: %w", ns, err))
+			continue
+		}
+		for _, res := range prev {
+			if !replaced[res.Policy] {
+				results[ns] = append(results[ns], res)
+			}
+		}
+	}
+
+	namespaces := map[string]bool{}
+	for ns := range results {
+		namespaces[ns] = true
+	}
+	for ns := range existing {
+		namespaces[ns] = true
+	}
+	for _, ns := range slices.Sorted(maps.Keys(namespaces)) {
+		if err := writePolicyReport(ctx, client, ns, results[ns], existing[ns]); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func policyReportClient(client dynamic.Interface, namespace string) dynamic.ResourceInterface {
+	if namespace == "" {
+		return client.Resource(ClusterPolicyReportGVR)
+	}
+	return client.Resource(PolicyReportGVR).Namespace(namespace)
+}
+
+// listPolicyReports returns the reports written by earlier runs in the scope of
+// an audit, by namespace
+func listPolicyReports(ctx context.Context, client dynamic.Interface, namespace string) (map[string]*unstructured.Unstructured, error) {
+	selector := metav1.ListOptions{LabelSelector: managedByLabel + "=" + policyReportSource}
+	existing := map[string]*unstructured.Unstructured{}
+	list, err := client.Resource(PolicyReportGVR).Namespace(namespace).List(ctx, selector)
+	if apierrors.IsNotFound(err) {
+		return nil, fmt.Errorf("the %s PolicyReport CRD is not installed: %w", PolicyReportGVR.GroupVersion(), err)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list policy reports: %w", err)
+	}
+	for i := range list.Items {
+		if list.Items[i].GetName() == PolicyReportName {
+			existing[list.Items[i].GetNamespace()] = &list.Items[i]
+		}
+	}
+
+	if namespace == "" {
+		obj, err := client.Resource(ClusterPolicyReportGVR).Get(ctx, PolicyReportName, metav1.GetOptions{})
+		switch {
+		case err == nil:
+			existing[""] = obj
+		case !apierrors.IsNotFound(err):
+			return nil, fmt.Errorf("failed to get cluster policy report: %w", err)
+		}
+	}
+	return existing, nil
+}
+
+func policyReportResults(obj *unstructured.Unstructured) ([]policyReportResult, error) {
+	items, _, err := unstructured.NestedSlice(obj.Object, "results")
+	if err != nil {
+		return nil, err
+	}
+	var out []policyReportResult
+	for _, item := range items {
+		m, ok := item.(map[string]any)
+		if !ok {
+			continue
+		}
+		var res policyReportResult
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(m, &res); err != nil {
+			return nil, err
+		}
+		if len(res.Resources) > 0 {
+			out = append(out, res)
+		}
+	}
+	return out, nil
+}
+
+// writePolicyReport creates, updates or deletes the report of one namespace
+func writePolicyReport(ctx context.Context, client dynamic.Interface, namespace string, results []policyReportResult, existing *unstructured.Unstructured) error {
+	reports := policyReportClient(client, namespace)
+	what := "cluster policy report"
+	if namespace != "" {
+		what = "policy report of " + namespace
+	}
+	if len(results) == 0 {
+		if existing == nil {
+			return nil
+		}
+		if err := reports.Delete(ctx, PolicyReportName, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete %s: %w", what, err)
+		}
+		return nil
+	}
+
+	slices.SortFunc(results, comparePolicyReportResults)
+	var summary policyReportSummary
+	for _, res := range results {
+		switch res.Result {
+		case "pass":
+			summary.Pass++
+		case "fail":
+			summary.Fail++
+		case "warn":
+			summary.Warn++
+		case "error":
+			summary.Error++
+		case "skip":
+			summary.Skip++
+		}
+	}
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&struct {
+		Summary policyReportSummary  `json:"summary"`
+		Results []policyReportResult `json:"results"`
+	}{summary, results})
+	if err != nil {
+		return err
+	}
+
+	obj := &unstructured.Unstructured{Object: content}
+	obj.SetAPIVersion(PolicyReportGVR.GroupVersion().String())
+	obj.SetKind("PolicyReport")
+	if namespace == "" {
+		obj.SetKind("ClusterPolicyReport")
+	}
+	obj.SetName(PolicyReportName)
+	obj.SetNamespace(namespace)
+	obj.SetLabels(map[string]string{managedByLabel: policyReportSource})
+
+	if existing == nil {
+		_, err = reports.Create(ctx, obj, metav1.CreateOptions{})
+	} else {
+		obj.SetResourceVersion(existing.GetResourceVersion())
+		_, err = reports.Update(ctx, obj, metav1.UpdateOptions{})
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", what, err)
+	}
+	return nil
+}
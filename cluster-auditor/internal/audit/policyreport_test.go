@@ -0,0 +1,100 @@
+package audit_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"goprojects/cluster-auditor/internal/audit"
+	"goprojects/findings"
+
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+)
+
+func TestWritePolicyReports(t *testing.T) {
+	ctx := context.Background()
+	client := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		audit.PolicyReportGVR:        "PolicyReportList",
+		audit.ClusterPolicyReportGVR: "ClusterPolicyReportList",
+	})
+	limits, _ := audit.LookupCheck("missing-resource-limits")
+	pv, _ := audit.LookupCheck("unclaimed-pv")
+	finding := func(ns, resource string, check audit.Check) findings.Finding {
+		f := findings.Finding{Namespace: ns, Kind: "Deployment", Resource: resource, Container: "app",
+			Issue: "Missing Resource Limits", RuleID: check.ID, Severity: check.Severity}
+		if ns == "" {
+			f.Kind, f.Container, f.Issue = "PersistentVolume", "", "PersistentVolume is not claimed"
+		}
+		return f
+	}
+	report := func(checks ...audit.CheckReport) *audit.Report {
+		return &audit.Report{Cluster: "prod", FinishedAt: time.Unix(1700000000, 0), Checks: checks}
+	}
+	get := func(gvr schema.GroupVersionResource, ns string) *unstructured.Unstructured {
+		obj, err := client.Resource(gvr).Namespace(ns).Get(ctx, audit.PolicyReportName, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		require.NoError(t, err)
+		return obj
+	}
+	results := func(obj *unstructured.Unstructured) []any {
+		items, _, err := unstructured.NestedSlice(obj.Object, "results")
+		require.NoError(t, err)
+		return items
+	}
+
+	exceptions := []findings.Exception{{ID: 7, Namespace: "shop", Resource: "legacy", Reason: "Being replaced", Owner: "shop-team"}}
+	require.NoError(t, audit.WritePolicyReports(ctx, client, report(
+		audit.CheckReport{Check: limits, Findings: []findings.Finding{finding("shop", "web", limits), finding("shop", "legacy", limits), finding("blog", "web", limits)}},
+		audit.CheckReport{Check: pv, Findings: []findings.Finding{finding("", "pv-1", pv)}},
+	), exceptions))
+
+	shop := get(audit.PolicyReportGVR, "shop")
+	require.NotNil(t, shop)
+	require.Equal(t, "PolicyReport", shop.GetKind())
+	summary, _, _ := unstructured.NestedMap(shop.Object, "summary")
+	require.Equal(t, map[string]any{"pass": int64(0), "fail": int64(1), "warn": int64(0), "error": int64(0), "skip": int64(1)}, summary)
+	web := results(shop)[1].(map[string]any)
+	require.Equal(t, "missing-resource-limits", web["policy"])
+	require.Equal(t, "fail", web["result"])
+	require.Equal(t, []any{map[string]any{"apiVersion": "apps/v1", "kind": "Deployment", "namespace": "shop", "name": "web"}}, web["resources"])
+	require.Equal(t, "app", web["properties"].(map[string]any)["container"])
+	legacy := results(shop)[0].(map[string]any)
+	require.Equal(t, "skip", legacy["result"])
+	require.Equal(t, "7: Being replaced (owner shop-team)", legacy["properties"].(map[string]any)["exception"])
+	require.NotNil(t, get(audit.PolicyReportGVR, "blog"))
+	cluster := get(audit.ClusterPolicyReportGVR, "")
+	require.NotNil(t, cluster)
+	require.Len(t, results(cluster), 1)
+
+	// The next run updates the reports, prunes the ones without results and keeps
+	// the results of failed checks
+	require.NoError(t, audit.WritePolicyReports(ctx, client, report(
+		audit.CheckReport{Check: limits, Findings: []findings.Finding{finding("shop", "web", limits)}},
+		audit.CheckReport{Check: pv, Err: errors.New("forbidden")},
+	), nil))
+	require.Len(t, results(get(audit.PolicyReportGVR, "shop")), 1)
+	require.Nil(t, get(audit.PolicyReportGVR, "blog"))
+	require.Len(t, results(get(audit.ClusterPolicyReportGVR, "")), 1)
+
+	// A run of a subset of the checks keeps the results of the others
+	require.NoError(t, audit.WritePolicyReports(ctx, client, report(
+		audit.CheckReport{Check: pv, Findings: []findings.Finding{finding("", "pv-2", pv)}},
+	), nil))
+	require.Len(t, results(get(audit.PolicyReportGVR, "shop")), 1)
+	pvs := results(get(audit.ClusterPolicyReportGVR, ""))
+	require.Len(t, pvs, 1)
+	require.Equal(t, "pv-2", pvs[0].(map[string]any)["resources"].([]any)[0].(map[string]any)["name"])
+
+	require.NoError(t, audit.WritePolicyReports(ctx, client, report(audit.CheckReport{Check: limits}, audit.CheckReport{Check: pv}), nil))
+	require.Nil(t, get(audit.PolicyReportGVR, "shop"))
+	require.Nil(t, get(audit.ClusterPolicyReportGVR, ""))
+}
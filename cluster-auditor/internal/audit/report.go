@@ -0,0 +1,130 @@
+package audit
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"time"
+
+	"goprojects/findings"
+
+	"k8s.io/client-go/kubernetes"
+)
+
+// Report is the outcome of one audit, check by check. Unlike the findings alone
+// it shows what passed: the resources each check audited without findings.
+type Report struct {
+	Cluster    string
+	Namespace  string // empty for all namespaces
+	StartedAt  time.Time
+	FinishedAt time.Time
+	Checks     []CheckReport
+}
+
+// CheckReport is the outcome of one check
+type CheckReport struct {
+	Check     Check
+	Duration  time.Duration
+	Resources []findings.ResourceRef // audited resources, sorted and unique
+	Findings  []findings.Finding
+	Err       error // set if the check failed, its findings may be incomplete
+}
+
+// RunCheckReport runs a check like RunCheck and reports the resources it audited
+// and the findings it added to a
+func RunCheckReport(ctx context.Context, a *findings.Auditor, client kubernetes.Interface, namespace string, check Check) CheckReport {
+	findingsBefore, auditedBefore := len(a.Findings), len(a.Audited)
+	start := time.Now()
+	err := RunCheck(ctx, a, client, namespace, check)
+	return CheckReport{
+		Check:     check,
+		Duration:  time.Since(start),
+		Resources: uniqueResources(a.Audited[auditedBefore:]),
+		Findings:  slices.Clone(a.Findings[findingsBefore:]),
+		Err:       err,
+	}
+}
+
+// RunReport runs the given checks in order and reports their outcome
+func RunReport(ctx context.Context, a *findings.Auditor, client kubernetes.Interface, namespace string, checks []Check) *Report {
+	r := &Report{Cluster: a.Cluster, Namespace: namespace, StartedAt: time.Now()}
+	for _, check := range checks {
+		r.Checks = append(r.Checks, RunCheckReport(ctx, a, client, namespace, check))
+	}
+	r.FinishedAt = time.Now()
+	return r
+}
+
+func uniqueResources(refs []findings.ResourceRef) []findings.ResourceRef {
+	out := slices.Clone(refs)
+	slices.SortFunc(out, compareResources)
+	return slices.Compact(out)
+}
+
+func compareResources(a, b findings.ResourceRef) int {
+	return cmp.Or(cmp.Compare(a.Kind, b.Kind), cmp.Compare(a.Namespace, b.Namespace), cmp.Compare(a.Name, b.Name))
+}
+
+// ResourceOf returns the resource a finding is about
+func ResourceOf(f findings.Finding) findings.ResourceRef {
+	return findings.ResourceRef{Kind: f.Kind, Namespace: f.Namespace, Name: f.Resource}
+}
+
+// Findings returns the findings of every check, in check order
+func (r *Report) Findings() []findings.Finding {
+	all := []findings.Finding{}
+	for _, c := range r.Checks {
+		all = append(all, c.Findings...)
+	}
+	return all
+}
+
+// Errors returns the errors of the checks that failed
+func (r *Report) Errors() []error {
+	var errs []error
+	for _, c := range r.Checks {
+		if c.Err != nil {
+			errs = append(errs, c.Err)
+		}
+	}
+	return errs
+}
+
+// Filter keeps the findings and audited resources of the resources for which keep
+// returns true, so a filtered report does not show the others as passing
+func (r *Report) Filter(keep func(findings.ResourceRef) bool) {
+	for i := range r.Checks {
+		c := &r.Checks[i]
+		c.Findings = slices.DeleteFunc(c.Findings, func(f findings.Finding) bool { return !keep(ResourceOf(f)) })
+		c.Resources = slices.DeleteFunc(c.Resources, func(ref findings.ResourceRef) bool { return !keep(ref) })
+	}
+}
+
+// Score is the health of a report's findings. It is computed once and passed to
+// every output, so they all show the same score.
+type Score struct {
+	Value    float64
+	Status   string
+	Excepted int // findings covered by exceptions, left out of the report
+}
+
+// ScoreReport scores the findings of a report, from which Except removed excepted
+// findings
+func ScoreReport(r *Report, excepted int) Score {
+	s := Score{Excepted: excepted}
+	s.Value, s.Status = findings.HealthScore(r.Findings())
+	return s
+}
+
+// Except removes the findings covered by one of exceptions active at now and
+// returns how many it removed
+func (r *Report) Except(exceptions []findings.Exception, now time.Time) int {
+	removed := 0
+	for i := range r.Checks {
+		c := &r.Checks[i]
+		before := len(c.Findings)
+		c.Findings = findings.ApplyExceptions(c.Findings, exceptions, now)
+		removed += before - len(c.Findings)
+	}
+	return removed
+}
@@ -20,14 +20,15 @@ func getImageAndTag(image string) (string, string) {
 	return parts[0], "latest"
 }
 
-func CheckMissingResourceLimits(a *findings.Auditor, client kubernetes.Interface, namespace string) error {
+func CheckMissingResourceLimits(ctx context.Context, a *findings.Auditor, client kubernetes.Interface, namespace string) error {
 
-	deployments, err := client.AppsV1().Deployments(namespace).List(context.TODO(), metav1.ListOptions{})
+	deployments, err := client.AppsV1().Deployments(namespace).List(ctx, metav1.ListOptions{})
 	if err != nil {
 		return fmt.Errorf("failed to list deployments: %w", err)
 	}
 
 	for _, deploy := range deployments.Items {
+		a.MarkAudited("Deployment", deploy.Namespace, deploy.Name)
 		for _, container := range deploy.Spec.Template.Spec.Containers {
 			res := container.Resources
 			if res.Limits == nil || res.Requests == nil {
@@ -39,7 +40,7 @@ func CheckMissingResourceLimits(a *findings.Auditor, client kubernetes.Interface
 					missing += "Requests"
 				}
 
-				a.AddFindingWithFilter(findings.Finding{
+				a.AddFinding(findings.Finding{
 					Namespace:  deploy.Namespace,
 					Resource:   deploy.Name,
 					Kind:       "Deployment",
@@ -54,14 +55,15 @@ func CheckMissingResourceLimits(a *findings.Auditor, client kubernetes.Interface
 	return nil
 }
 
-func DockerTagCheck(a *findings.Auditor, client kubernetes.Interface, namespace string) error {
+func DockerTagCheck(ctx context.Context, a *findings.Auditor, client kubernetes.Interface, namespace string) error {
 
-	deployments, err := client.AppsV1().Deployments(namespace).List(context.TODO(), metav1.ListOptions{})
+	deployments, err := client.AppsV1().Deployments(namespace).List(ctx, metav1.ListOptions{})
 	if err != nil {
 		return fmt.Errorf("failed to list deployments: %w", err)
 	}
 
 	for _, deploy := range deployments.Items {
+		a.MarkAudited("Deployment", deploy.Namespace, deploy.Name)
 		for _, container := range deploy.Spec.Template.Spec.Containers {
 			_, tag := getImageAndTag(container.Image)
 			if tag == "latest" {
@@ -80,14 +82,15 @@ func DockerTagCheck(a *findings.Auditor, client kubernetes.Interface, namespace
 	return nil
 }
 
-func CheckMissingLivenessProbes(a *findings.Auditor, client kubernetes.Interface, namespace string) error {
+func CheckMissingLivenessProbes(ctx context.Context, a *findings.Auditor, client kubernetes.Interface, namespace string) error {
 
-	deployments, err := client.AppsV1().Deployments(namespace).List(context.TODO(), metav1.ListOptions{})
+	deployments, err := client.AppsV1().Deployments(namespace).List(ctx, metav1.ListOptions{})
 	if err != nil {
 		return fmt.Errorf("failed to list deployments: %w", err)
 	}
 
 	for _, deploy := range deployments.Items {
+		a.MarkAudited("Deployment", deploy.Namespace, deploy.Name)
 		for _, container := range deploy.Spec.Template.Spec.Containers {
 
 			restartPolicy := deploy.Spec.Template.Spec.RestartPolicy
@@ -115,14 +118,15 @@ func CheckMissingLivenessProbes(a *findings.Auditor, client kubernetes.Interface
 	return nil
 }
 
-func CheckMissingReadinessProbes(a *findings.Auditor, client kubernetes.Interface, namespace string) error {
+func CheckMissingReadinessProbes(ctx context.Context, a *findings.Auditor, client kubernetes.Interface, namespace string) error {
 
-	deployments, err := client.AppsV1().Deployments(namespace).List(context.TODO(), metav1.ListOptions{})
+	deployments, err := client.AppsV1().Deployments(namespace).List(ctx, metav1.ListOptions{})
 	if err != nil {
 		return fmt.Errorf("failed to list deployments: %w", err)
 	}
 
 	for _, deploy := range deployments.Items {
+		a.MarkAudited("Deployment", deploy.Namespace, deploy.Name)
 		for _, container := range deploy.Spec.Template.Spec.Containers {
 
 			restartPolicy := deploy.Spec.Template.Spec.RestartPolicy
@@ -135,7 +139,7 @@ func CheckMissingReadinessProbes(a *findings.Auditor, client kubernetes.Interfac
 				if isProbablySafe {
 					suggestion = "No readiness probe found. This container may be safe without one."
 
-					a.AddFindingWithFilter(findings.Finding{
+					a.AddFinding(findings.Finding{
 						Namespace:  deploy.Namespace,
 						Resource:   deploy.Name,
 						Kind:       "Deployment",
@@ -151,9 +155,9 @@ func CheckMissingReadinessProbes(a *findings.Auditor, client kubernetes.Interfac
 	return nil
 }
 
-func CheckHPAConflict(a *findings.Auditor, client kubernetes.Interface, namespace string) error {
+func CheckHPAConflict(ctx context.Context, a *findings.Auditor, client kubernetes.Interface, namespace string) error {
 
-	hpaList, err := client.AutoscalingV1().HorizontalPodAutoscalers(namespace).List(context.TODO(), metav1.ListOptions{})
+	hpaList, err := client.AutoscalingV1().HorizontalPodAutoscalers(namespace).List(ctx, metav1.ListOptions{})
 	if err != nil {
 		return fmt.Errorf("failed to list HPAs: %w", err)
 	}
@@ -172,12 +176,13 @@ func CheckHPAConflict(a *findings.Auditor, client kubernetes.Interface, namespac
 		// is more memory efficient (zero bytes).
 	}
 
-	deployments, err := client.AppsV1().Deployments(namespace).List(context.TODO(), metav1.ListOptions{})
+	deployments, err := client.AppsV1().Deployments(namespace).List(ctx, metav1.ListOptions{})
 	if err != nil {
 		return fmt.Errorf("failed to list deployments: %w", err)
 	}
 
 	for _, deploy := range deployments.Items {
+		a.MarkAudited("Deployment", deploy.Namespace, deploy.Name)
 		if _, ok := hpaTargets[targetKey{"Deployment", deploy.Name}]; ok && deploy.Spec.Replicas != nil {
 			a.AddFinding(findings.Finding{
 				Namespace:  deploy.Namespace,
@@ -189,12 +194,13 @@ func CheckHPAConflict(a *findings.Auditor, client kubernetes.Interface, namespac
 			})
 		}
 	}
-	statefulsets, err := client.AppsV1().StatefulSets(namespace).List(context.TODO(), metav1.ListOptions{})
+	statefulsets, err := client.AppsV1().StatefulSets(namespace).List(ctx, metav1.ListOptions{})
 	if err != nil {
 		return fmt.Errorf("failed to list statefulsets: %w", err)
 	}
 
 	for _, state := range statefulsets.Items {
+		a.MarkAudited("StatefulSet", state.Namespace, state.Name)
 		if _, ok := hpaTargets[targetKey{"Statefulset", state.Name}]; ok && state.Spec.Replicas != nil {
 			a.AddFinding(findings.Finding{
 				Namespace:  state.Namespace,
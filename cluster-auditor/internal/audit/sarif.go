@@ -0,0 +1,334 @@
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"goprojects/findings"
+)
+
+// SARIF 2.1.0, the subset code scanning tools read. See
+// https://docs.oasis-open.org/sarif/sarif/v2.1.0/sarif-v2.1.0.html
+const (
+	sarifVersion = "2.1.0"
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+)
+
+type sarifLog struct {
+	Version string     `json:"version"`
+	Schema  string     `json:"$schema"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool               sarifTool                        `json:"tool"`
+	OriginalURIBaseIDs map[string]sarifArtifactLocation `json:"originalUriBaseIds,omitempty"`
+	LogicalLocations   []sarifLogicalLocation           `json:"logicalLocations,omitempty"`
+	Results            []sarifResult                    `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri,omitempty"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifText struct {
+	Text     string `json:"text"`
+	Markdown string `json:"markdown,omitempty"`
+}
+
+type sarifRule struct {
+	ID                   string             `json:"id"`
+	Name                 string             `json:"name"`
+	ShortDescription     sarifText          `json:"shortDescription"`
+	FullDescription      sarifText          `json:"fullDescription"`
+	Help                 sarifText          `json:"help"`
+	DefaultConfiguration sarifConfiguration `json:"defaultConfiguration"`
+	Properties           map[string]any     `json:"properties,omitempty"`
+}
+
+type sarifConfiguration struct {
+	Level string `json:"level"`
+}
+
+type sarifResult struct {
+	RuleID              string            `json:"ruleId"`
+	RuleIndex           int               `json:"ruleIndex"`
+	Level               string            `json:"level"`
+	Message             sarifText         `json:"message"`
+	Locations           []sarifLocation   `json:"locations"`
+	PartialFingerprints map[string]string `json:"partialFingerprints,omitempty"`
+	Properties          map[string]any    `json:"properties,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation *sarifPhysicalLocation `json:"physicalLocation,omitempty"`
+	LogicalLocations []sarifLogicalLocation `json:"logicalLocations,omitempty"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI       string `json:"uri"`
+	URIBaseID string `json:"uriBaseId,omitempty"`
+}
+
+type sarifRegion struct {
+	StartLine int `json:"startLine"`
+}
+
+// sarifLogicalLocation is either an entry of the run's logical locations or, with
+// just Index and FullyQualifiedName, a reference to one from a result
+type sarifLogicalLocation struct {
+	Index              *int   `json:"index,omitempty"`
+	Name               string `json:"name,omitempty"`
+	FullyQualifiedName string `json:"fullyQualifiedName"`
+	Kind               string `json:"kind,omitempty"`
+	ParentIndex        *int   `json:"parentIndex,omitempty"`
+}
+
+// sarifSourceRoot is the base of the manifest URIs, described in the run's
+// originalUriBaseIds
+const sarifSourceRoot = "%SRCROOT%"
+
+// sarifLevel maps a severity to a SARIF result level
+func sarifLevel(s findings.Severity) string {
+	switch s {
+	case findings.SeverityCritical, findings.SeverityHigh:
+		return "error"
+	case findings.SeverityMedium:
+		return "warning"
+	}
+	return "note"
+}
+
+// securitySeverity is the CVSS-like score code scanning UIs rank rules by
+var securitySeverity = map[findings.Severity]string{
+	findings.SeverityCritical: "9.5",
+	findings.SeverityHigh:     "8.0",
+	findings.SeverityMedium:   "5.5",
+	findings.SeverityLow:      "3.0",
+	findings.SeverityInfo:     "0.0",
+}
+
+func sarifRuleOf(c Check) sarifRule {
+	return sarifRule{
+		ID:                   c.ID,
+		Name:                 c.Name,
+		ShortDescription:     sarifText{Text: c.Description},
+		FullDescription:      sarifText{Text: c.Description},
+		Help:                 sarifText{Text: c.Help, Markdown: c.Help},
+		DefaultConfiguration: sarifConfiguration{Level: sarifLevel(c.Severity)},
+		Properties: map[string]any{
+			"tags":              []string{"kubernetes"},
+			"security-severity": securitySeverity[c.Severity],
+		},
+	}
+}
+
+// sarifRoot returns the directory the manifest URIs are relative to: the working
+// directory, or the common parent of the files when some are outside of it
+func sarifRoot(files []string) (string, error) {
+	root, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	for _, file := range files {
+		abs, err := filepath.Abs(file)
+		if err != nil {
+			return "", err
+		}
+		for {
+			if rel, err := filepath.Rel(root, abs); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+				break
+			}
+			root = filepath.Dir(root)
+		}
+	}
+	return root, nil
+}
+
+// sarifURI returns the URI of a manifest relative to root, so code scanning can
+// match it with the repository's files
+func sarifURI(root, path string) string {
+	abs, err := filepath.Abs(path)
+	if err == nil {
+		if rel, err := filepath.Rel(root, abs); err == nil {
+			path = rel
+		}
+	}
+	return (&url.URL{Path: filepath.ToSlash(path)}).String()
+}
+
+// sarifRootURI returns the file URI of a directory, with the trailing slash
+// SARIF requires of base URIs
+func sarifRootURI(root string) string {
+	path := filepath.ToSlash(root)
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path // Windows drive letters
+	}
+	if !strings.HasSuffix(path, "/") {
+		path += "/"
+	}
+	return (&url.URL{Scheme: "file", Path: path}).String()
+}
+
+// resourceName names the resource of a finding, e.g. prod/payments/Deployment/api
+func resourceName(f findings.Finding) string {
+	parts := []string{}
+	for _, p := range []string{f.Cluster, f.Namespace, f.Kind, f.Resource} {
+		if p != "" {
+			parts = append(parts, p)
+		}
+	}
+	return strings.Join(parts, "/")
+}
+
+// WriteSARIF writes the findings as a SARIF 2.1.0 log with one rule per
+// registered check. Every result is located by its resource in the run's logical
+// locations. locate, if not nil, returns the manifest defining a finding's
+// resource, which is added with a URI relative to the %SRCROOT% base.
+func WriteSARIF(w io.Writer, fs []findings.Finding, locate func(findings.Finding) (ManifestSource, bool)) error {
+	driver := sarifDriver{Name: "cluster-auditor", Rules: make([]sarifRule, 0, len(Registry))}
+	ruleIndex := map[string]int{}
+	for _, c := range Registry {
+		ruleIndex[c.ID] = len(driver.Rules)
+		driver.Rules = append(driver.Rules, sarifRuleOf(c))
+	}
+
+	sources := make([]ManifestSource, len(fs))
+	var files []string
+	for i, f := range fs {
+		if locate != nil {
+			if src, ok := locate(f); ok {
+				sources[i] = src
+				files = append(files, src.File)
+			}
+		}
+	}
+	var run sarifRun
+	root := ""
+	if len(files) > 0 {
+		var err error
+		if root, err = sarifRoot(files); err != nil {
+			return err
+		}
+		run.OriginalURIBaseIDs = map[string]sarifArtifactLocation{sarifSourceRoot: {URI: sarifRootURI(root)}}
+	}
+
+	// Every resource is a logical location, in its namespace if it has one
+	logicalIndex := map[string]int{}
+	logical := func(name, qualifiedName, kind string, parent *int) int {
+		if i, ok := logicalIndex[qualifiedName]; ok {
+			return i
+		}
+		i := len(run.LogicalLocations)
+		logicalIndex[qualifiedName] = i
+		run.LogicalLocations = append(run.LogicalLocations, sarifLogicalLocation{
+			Name: name, FullyQualifiedName: qualifiedName, Kind: kind, ParentIndex: parent,
+		})
+		return i
+	}
+
+	results := make([]sarifResult, 0, len(fs))
+	for i, f := range fs {
+		idx, ok := ruleIndex[f.RuleID]
+		if !ok {
+			// Findings of rules that are no longer registered still need a rule
+			idx = len(driver.Rules)
+			ruleIndex[f.RuleID] = idx
+			driver.Rules = append(driver.Rules, sarifRuleOf(Check{ID: f.RuleID, Name: f.RuleID, Severity: f.Severity, Description: f.Issue}))
+		}
+
+		message := f.Issue
+		if f.Container != "" {
+			message = fmt.Sprintf("%s (container %s)", message, f.Container)
+		}
+		if f.Suggestion != "" {
+			message += ". " + f.Suggestion
+		}
+
+		var parent *int
+		if f.Namespace != "" {
+			ns := findings.Finding{Cluster: f.Cluster, Kind: "Namespace", Resource: f.Namespace}
+			idx := logical(f.Namespace, resourceName(ns), "namespace", nil)
+			parent = &idx
+		}
+		logicalIdx := 0
+		if parent != nil && f.Kind == "Namespace" && f.Resource == f.Namespace {
+			logicalIdx = *parent // findings about the namespace itself
+		} else {
+			logicalIdx = logical(f.Resource, resourceName(f), "resource", parent)
+		}
+		loc := sarifLocation{LogicalLocations: []sarifLogicalLocation{{
+			Index:              &logicalIdx,
+			FullyQualifiedName: run.LogicalLocations[logicalIdx].FullyQualifiedName,
+		}}}
+		if src := sources[i]; src.File != "" {
+			loc.PhysicalLocation = &sarifPhysicalLocation{ArtifactLocation: sarifArtifactLocation{URI: sarifURI(root, src.File), URIBaseID: sarifSourceRoot}}
+			if src.Line > 0 {
+				loc.PhysicalLocation.Region = &sarifRegion{StartLine: src.Line}
+			}
+		}
+
+		fingerprint := f.Fingerprint
+		if fingerprint == "" {
+			fingerprint = findings.ComputeFingerprint(f)
+		}
+		properties := map[string]any{"severity": string(f.Severity), "kind": f.Kind, "resource": f.Resource}
+		for k, v := range map[string]string{"namespace": f.Namespace, "container": f.Container, "cluster": f.Cluster, "team": f.Team} {
+			if v != "" {
+				properties[k] = v
+			}
+		}
+		if len(f.Subjects) > 0 {
+			properties["subjects"] = f.Subjects
+		}
+
+		results = append(results, sarifResult{
+			RuleID:              f.RuleID,
+			RuleIndex:           idx,
+			Level:               sarifLevel(f.Severity),
+			Message:             sarifText{Text: message},
+			Locations:           []sarifLocation{loc},
+			PartialFingerprints: map[string]string{"auditorFingerprint/v1": fingerprint},
+			Properties:          properties,
+		})
+	}
+
+	run.Tool, run.Results = sarifTool{Driver: driver}, results
+	log := sarifLog{
+		Version: sarifVersion,
+		Schema:  sarifSchema,
+		Runs:    []sarifRun{run},
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(log)
+}
+
+func OutputFindingsAsSARIF(findingsList []findings.Finding, filename string, locate func(findings.Finding) (ManifestSource, bool)) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return fmt.Errorf("failed to create SARIF file: %w", err)
+	}
+	defer file.Close()
+
+	if err := WriteSARIF(file, findingsList, locate); err != nil {
+		return fmt.Errorf("failed to write SARIF file: %w", err)
+	}
+	return file.Close()
+}
@@ -0,0 +1,163 @@
+package audit_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"goprojects/cluster-auditor/internal/audit"
+	"goprojects/findings"
+
+	"github.com/stretchr/testify/require"
+)
+
+const privilegedManifest = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: settings
+---
+# the workload
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: shop
+spec:
+  selector:
+    matchLabels: {app: web}
+  template:
+    metadata:
+      labels: {app: web}
+    spec:
+      containers:
+      - name: nginx
+        image: nginx:1.27
+        securityContext:
+          privileged: true
+---
+apiVersion: example.com/v1
+kind: Widget
+metadata:
+  name: ignored
+`
+
+func TestSARIFFromManifests(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "app.yaml"), []byte(privilegedManifest), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "README.md"), []byte("not a manifest"), 0600))
+
+	m, err := audit.LoadManifests(dir)
+	require.NoError(t, err)
+	require.Len(t, m.Objects, 2, "custom resources and other files are skipped")
+
+	a := findings.NewAuditor()
+	check, ok := audit.LookupCheck("privileged-container")
+	require.True(t, ok)
+	require.NoError(t, audit.RunCheck(context.Background(), a, m.Client(), "", check))
+	require.Len(t, a.Findings, 1)
+
+	var buf bytes.Buffer
+	require.NoError(t, audit.WriteSARIF(&buf, a.Findings, m.Locate))
+
+	var log sarifTestLog
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &log))
+	require.Equal(t, "2.1.0", log.Version)
+
+	run := log.Runs[0]
+	require.Len(t, run.Tool.Driver.Rules, len(audit.Registry))
+	for _, r := range run.Tool.Driver.Rules {
+		require.NotEmpty(t, r.Help.Text, r.ID)
+	}
+
+	require.Len(t, run.Results, 1)
+	result := run.Results[0]
+	require.Equal(t, "privileged-container", result.RuleID)
+	require.Equal(t, "privileged-container", run.Tool.Driver.Rules[result.RuleIndex].ID)
+	require.Equal(t, "error", result.Level)
+	loc := result.Locations[0].PhysicalLocation
+	// Paths outside the working directory are relative to their common parent
+	require.NotContains(t, loc.ArtifactLocation.URI, "file:")
+	require.Equal(t, "%SRCROOT%", loc.ArtifactLocation.URIBaseID)
+	base, err := url.Parse(run.OriginalURIBaseIDs["%SRCROOT%"].URI)
+	require.NoError(t, err)
+	uri, err := base.Parse(loc.ArtifactLocation.URI)
+	require.NoError(t, err)
+	require.Equal(t, filepath.ToSlash(filepath.Join(dir, "app.yaml")), uri.Path)
+	require.Equal(t, 7, loc.Region.StartLine)
+}
+
+func TestSARIFFromCluster(t *testing.T) {
+	fs := []findings.Finding{
+		{Cluster: "prod", Namespace: "shop", Kind: "Deployment", Resource: "web", RuleID: "privileged-container", Severity: findings.SeverityCritical},
+		{Cluster: "prod", Namespace: "shop", Kind: "Namespace", Resource: "shop", RuleID: "missing-network-policy", Severity: findings.SeverityMedium},
+	}
+	var buf bytes.Buffer
+	require.NoError(t, audit.WriteSARIF(&buf, fs, nil))
+
+	var log sarifTestLog
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &log))
+	run := log.Runs[0]
+	require.Empty(t, run.OriginalURIBaseIDs)
+	require.Len(t, run.LogicalLocations, 2)
+	namespace, deployment := run.LogicalLocations[0], run.LogicalLocations[1]
+	require.Equal(t, "prod/Namespace/shop", namespace.FullyQualifiedName)
+	require.Equal(t, "namespace", namespace.Kind)
+	require.Equal(t, "prod/shop/Deployment/web", deployment.FullyQualifiedName)
+	require.Equal(t, "resource", deployment.Kind)
+	require.Equal(t, 0, *deployment.ParentIndex)
+
+	for i, index := range []int{1, 0} {
+		loc := run.Results[i].Locations[0]
+		require.Empty(t, loc.PhysicalLocation.ArtifactLocation.URI)
+		require.Equal(t, index, *loc.LogicalLocations[0].Index)
+		require.Equal(t, run.LogicalLocations[index].FullyQualifiedName, loc.LogicalLocations[0].FullyQualifiedName)
+	}
+}
+
+type sarifTestLog struct {
+	Version string `json:"version"`
+	Runs    []struct {
+		Tool struct {
+			Driver struct {
+				Rules []struct {
+					ID   string `json:"id"`
+					Help struct {
+						Text string `json:"text"`
+					} `json:"help"`
+				} `json:"rules"`
+			} `json:"driver"`
+		} `json:"tool"`
+		OriginalURIBaseIDs map[string]struct {
+			URI string `json:"uri"`
+		} `json:"originalUriBaseIds"`
+		LogicalLocations []struct {
+			FullyQualifiedName string `json:"fullyQualifiedName"`
+			Kind               string `json:"kind"`
+			ParentIndex        *int   `json:"parentIndex"`
+		} `json:"logicalLocations"`
+		Results []struct {
+			RuleID    string `json:"ruleId"`
+			RuleIndex int    `json:"ruleIndex"`
+			Level     string `json:"level"`
+			Locations []struct {
+				PhysicalLocation struct {
+					ArtifactLocation struct {
+						URI       string `json:"uri"`
+						URIBaseID string `json:"uriBaseId"`
+					} `json:"artifactLocation"`
+					Region struct {
+						StartLine int `json:"startLine"`
+					} `json:"region"`
+				} `json:"physicalLocation"`
+				LogicalLocations []struct {
+					Index              *int   `json:"index"`
+					FullyQualifiedName string `json:"fullyQualifiedName"`
+				} `json:"logicalLocations"`
+			} `json:"locations"`
+		} `json:"results"`
+	} `json:"runs"`
+}
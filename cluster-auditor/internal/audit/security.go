@@ -13,13 +13,14 @@ import (
 )
 
 // SecurityPrivilegeCheck flags containers running in privileged mode
-func SecurityPrivilegeCheck(a *findings.Auditor, client kubernetes.Interface, namespace string) error {
+func SecurityPrivilegeCheck(ctx context.Context, a *findings.Auditor, client kubernetes.Interface, namespace string) error {
 
-	workloads, err := GatherWorkloads(client, namespace)
+	workloads, err := GatherWorkloads(ctx, client, namespace)
 	if err != nil {
 		return fmt.Errorf("failed to gather workloads: %w", err)
 	}
 	for _, wl := range workloads {
+		a.MarkAudited(wl.Kind, wl.Namespace, wl.Name)
 		for _, c := range append(wl.PodSpec.Containers, wl.PodSpec.InitContainers...) {
 			if c.SecurityContext != nil && c.SecurityContext.Privileged != nil && *c.SecurityContext.Privileged { //*c.SecurityContext.Privileged the actual boolean value
 
@@ -105,6 +106,7 @@ func checkFinding(a *findings.Auditor, kind string, roleMeta metav1.ObjectMeta,
 		Resource:   roleMeta.Name,
 		Kind:       kind,
 		Issue:      issue,
+		Key:        fmt.Sprintf("%s: %s", field, detail), // the issue without the subjects, which change with the bindings
 		Suggestion: fmt.Sprintf("Restrict the %s to only those necessary for this %s.", field, kind),
 		Subjects:   allSubjects, // full detail for export / SQL
 	})
@@ -148,21 +150,21 @@ func checkRoleRules(a *findings.Auditor, kind string, roleMeta metav1.ObjectMeta
 	}
 }
 
-func RBACcheck(a *findings.Auditor, client kubernetes.Interface, namespace string) error {
+func RBACcheck(ctx context.Context, a *findings.Auditor, client kubernetes.Interface, namespace string) error {
 
 	// Fetch all RoleBindings and ClusterRoleBindings in the namespace
-	roleBindings, err := client.RbacV1().RoleBindings(namespace).List(context.TODO(), metav1.ListOptions{})
+	roleBindings, err := client.RbacV1().RoleBindings(namespace).List(ctx, metav1.ListOptions{})
 	if err != nil {
 		return fmt.Errorf("failed to list rolebindings: %w", err)
 	}
 
-	clusterBindings, err := client.RbacV1().ClusterRoleBindings().List(context.TODO(), metav1.ListOptions{})
+	clusterBindings, err := client.RbacV1().ClusterRoleBindings().List(ctx, metav1.ListOptions{})
 	if err != nil {
 		return fmt.Errorf("failed to list clusterrolebindings: %w", err)
 	}
 
 	// Namespace-scoped Roles
-	roles, err := client.RbacV1().Roles(namespace).List(context.TODO(), metav1.ListOptions{})
+	roles, err := client.RbacV1().Roles(namespace).List(ctx, metav1.ListOptions{})
 	if err != nil {
 		return fmt.Errorf("failed to list roles: %w", err)
 	}
@@ -174,11 +176,12 @@ func RBACcheck(a *findings.Auditor, client kubernetes.Interface, namespace strin
 	}
 
 	for _, role := range roles.Items {
+		a.MarkAudited("Role", role.Namespace, role.Name)
 		checkRoleRules(a, "Role", role.ObjectMeta, role.Rules, roleSubjects[role.Name])
 	}
 
 	// Cluster-wide ClusterRoles
-	clusterRoles, err := client.RbacV1().ClusterRoles().List(context.TODO(), metav1.ListOptions{})
+	clusterRoles, err := client.RbacV1().ClusterRoles().List(ctx, metav1.ListOptions{})
 	if err != nil {
 		return fmt.Errorf("failed to list clusterroles: %w", err)
 	}
@@ -189,6 +192,7 @@ func RBACcheck(a *findings.Auditor, client kubernetes.Interface, namespace strin
 		}
 	}
 	for _, cr := range clusterRoles.Items {
+		a.MarkAudited("ClusterRole", "", cr.Name)
 		checkRoleRules(a, "ClusterRole", cr.ObjectMeta, cr.Rules, crSubjects[cr.Name])
 	}
 
@@ -1,6 +1,7 @@
 package audit_test
 
 import (
+	"context"
 	"testing"
 
 	corev1 "k8s.io/api/core/v1"
@@ -36,7 +37,7 @@ func TestSecurityPrivilegeCheck(t *testing.T) {
 	},
 	)
 	a := findings.NewAuditor()
-	err := audit.SecurityPrivilegeCheck(a, client, "default")
+	err := audit.SecurityPrivilegeCheck(context.Background(), a, client, "default")
 	require.NoError(t, err)
 	require.NotEmpty(t, a.Findings, "expected at least one finding")
 	require.Equal(t, "privileged-pod", a.Findings[0].Resource)
@@ -110,7 +111,7 @@ func TestRBACcheck(t *testing.T) {
 		)
 
 		a := findings.NewAuditor()
-		err := audit.RBACcheck(a, client, "default")
+		err := audit.RBACcheck(context.Background(), a, client, "default")
 		require.NoError(t, err)
 		require.Len(t, a.Findings, 1)
 
@@ -130,7 +131,7 @@ func TestRBACcheck(t *testing.T) {
 		)
 
 		a := findings.NewAuditor()
-		err := audit.RBACcheck(a, client, "default")
+		err := audit.RBACcheck(context.Background(), a, client, "default")
 		require.NoError(t, err)
 		require.Empty(t, a.Findings)
 	})
@@ -144,7 +145,7 @@ func TestRBACcheck(t *testing.T) {
 		)
 
 		a := findings.NewAuditor()
-		err := audit.RBACcheck(a, client, "default")
+		err := audit.RBACcheck(context.Background(), a, client, "default")
 		require.NoError(t, err)
 		require.NotEmpty(t, a.Findings)
 		require.Contains(t, a.Findings[0].Issue, "Secrets read access")
@@ -162,7 +163,7 @@ func TestRBACcheck(t *testing.T) {
 		)
 
 		a := findings.NewAuditor()
-		err := audit.RBACcheck(a, client, "default")
+		err := audit.RBACcheck(context.Background(), a, client, "default")
 		require.NoError(t, err)
 		require.NotEmpty(t, a.Findings)
 		require.Contains(t, a.Findings[0].Issue, "Impersonation")
@@ -191,7 +192,7 @@ func TestRBACcheck_SubjectTruncation(t *testing.T) {
 	client := fake.NewSimpleClientset(role, rb)
 
 	a := findings.NewAuditor()
-	err := audit.RBACcheck(a, client, "default")
+	err := audit.RBACcheck(context.Background(), a, client, "default")
 	require.NoError(t, err)
 	require.NotEmpty(t, a.Findings)
 
@@ -207,3 +208,27 @@ func TestRBACcheck_SubjectTruncation(t *testing.T) {
 	require.Contains(t, f.Subjects, "SA:default/sa1")
 	require.Contains(t, f.Subjects, "SA:default/sa7")
 }
+
+func TestRBACcheck_FingerprintIgnoresSubjects(t *testing.T) {
+	check, ok := audit.LookupCheck("risky-rbac")
+	require.True(t, ok)
+	role := newRole("wildcard-role", "default",
+		rbacv1.PolicyRule{Verbs: []string{"*", "get"}, Resources: []string{"secrets"}},
+	)
+	run := func(subjects ...rbacv1.Subject) []findings.Finding {
+		client := fake.NewSimpleClientset(role, newRoleBinding("bind", "default", role.Name, subjects...))
+		a := findings.NewAuditor()
+		require.NoError(t, audit.RunCheck(context.Background(), a, client, "default", check))
+		return a.Findings
+	}
+
+	before := run(newServiceAccountSubject("sa1", "default"))
+	after := run(newServiceAccountSubject("sa1", "default"), newServiceAccountSubject("sa2", "default"))
+	require.Len(t, before, 2, "wildcard verbs and secrets read access")
+	require.Len(t, after, 2)
+	require.NotEqual(t, before[0].Issue, after[0].Issue)
+	require.NotEqual(t, before[0].Fingerprint, before[1].Fingerprint)
+	for i := range before {
+		require.Equal(t, before[i].Fingerprint, after[i].Fingerprint)
+	}
+}
@@ -12,14 +12,15 @@ import (
 	"k8s.io/client-go/kubernetes"
 )
 
-func PVCcheck(a *findings.Auditor, client kubernetes.Interface, namespace string) error {
+func PVCcheck(ctx context.Context, a *findings.Auditor, client kubernetes.Interface, namespace string) error {
 
-	pvcs, err := client.CoreV1().PersistentVolumeClaims(namespace).List(context.TODO(), metav1.ListOptions{})
+	pvcs, err := client.CoreV1().PersistentVolumeClaims(namespace).List(ctx, metav1.ListOptions{})
 	if err != nil {
 		return fmt.Errorf("failed to list persistent volume claims: %w", err)
 	}
 
 	for _, pvc := range pvcs.Items {
+		a.MarkAudited("PersistentVolumeClaim", pvc.Namespace, pvc.Name)
 		switch pvc.Status.Phase {
 		case v1.ClaimPending:
 			a.AddFinding(findings.Finding{
@@ -45,14 +46,15 @@ func PVCcheck(a *findings.Auditor, client kubernetes.Interface, namespace string
 	return nil
 }
 
-func UnclaimedPV(a *findings.Auditor, client kubernetes.Interface, namespace string) error {
+func UnclaimedPV(ctx context.Context, a *findings.Auditor, client kubernetes.Interface, namespace string) error {
 
-	pvs, err := client.CoreV1().PersistentVolumes().List(context.TODO(), metav1.ListOptions{})
+	pvs, err := client.CoreV1().PersistentVolumes().List(ctx, metav1.ListOptions{})
 	if err != nil {
 		return fmt.Errorf("failed to list persistent volumes: %w", err)
 	}
 
 	for _, pv := range pvs.Items {
+		a.MarkAudited("PersistentVolume", "", pv.Name)
 		if pv.Status.Phase == v1.VolumeAvailable {
 			age := time.Since(pv.CreationTimestamp.Time)
 
@@ -62,7 +64,7 @@ func UnclaimedPV(a *findings.Auditor, client kubernetes.Interface, namespace str
 					Resource:   pv.Name,
 					Kind:       "PersistentVolume",
 					Container:  "",
-					Issue:      fmt.Sprintf("PersistentVolume has been unclaimed and available for %s", age.Round(time.Hour)),
+					Issue:      fmt.Sprintf("PersistentVolume has been unclaimed and available since %s", pv.CreationTimestamp.Format("2006-01-02")),
 					Suggestion: "Consider deleting or reusing this PersistentVolume if it is no longer needed.",
 				})
 			}
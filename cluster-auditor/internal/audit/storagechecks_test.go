@@ -1,6 +1,7 @@
 package audit_test
 
 import (
+	"context"
 	"goprojects/cluster-auditor/internal/audit"
 	"goprojects/findings"
 	"testing"
@@ -36,7 +37,7 @@ func TestPVCcheck(t *testing.T) {
 
 			auditor := findings.NewAuditor()
 
-			err := audit.PVCcheck(auditor, client, "default")
+			err := audit.PVCcheck(context.Background(), auditor, client, "default")
 			require.NoError(t, err)
 
 			if tt.expectFind {
@@ -0,0 +1,87 @@
+package audit
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strings"
+
+	"goprojects/findings"
+)
+
+// FindingWriter writes findings one at a time, so they can be streamed as checks
+// produce them instead of being marshalled at once
+type FindingWriter interface {
+	Write(f findings.Finding) error
+	// Flush writes any buffered data and returns the first error of a Write
+	Flush() error
+}
+
+// CSVColumns is the column order of the CSV output. New columns are only added
+// at the end, so spreadsheets and scripts reading by position keep working.
+var CSVColumns = []string{
+	"cluster", "namespace", "kind", "resource", "container", "rule_id", "severity",
+	"team", "issue", "suggestion", "subjects", "fingerprint",
+}
+
+type csvFindingWriter struct {
+	w      *csv.Writer
+	header bool
+}
+
+// NewCSVWriter returns a FindingWriter writing a header and then one row per
+// finding, in CSVColumns order. Subjects are joined with semicolons.
+func NewCSVWriter(w io.Writer) FindingWriter {
+	return &csvFindingWriter{w: csv.NewWriter(w)}
+}
+
+func (c *csvFindingWriter) Write(f findings.Finding) error {
+	if !c.header {
+		c.header = true
+		if err := c.w.Write(CSVColumns); err != nil {
+			return err
+		}
+	}
+	return c.w.Write(csvRecord(f))
+}
+
+// csvRecord returns the cells of a finding in CSVColumns order
+func csvRecord(f findings.Finding) []string {
+	return []string{
+		f.Cluster, f.Namespace, f.Kind, f.Resource, f.Container, f.RuleID, string(f.Severity),
+		f.Team, f.Issue, f.Suggestion, strings.Join(f.Subjects, ";"), f.Fingerprint,
+	}
+}
+
+func (c *csvFindingWriter) Flush() error {
+	if !c.header {
+		// An empty report still has a header, so it reads as a table without rows
+		c.header = true
+		c.w.Write(CSVColumns)
+	}
+	c.w.Flush()
+	return c.w.Error()
+}
+
+type ndjsonFindingWriter struct {
+	enc *json.Encoder
+	err error
+}
+
+// NewNDJSONWriter returns a FindingWriter writing each finding as a JSON object
+// on its own line, with the fields of the JSON output
+func NewNDJSONWriter(w io.Writer) FindingWriter {
+	return &ndjsonFindingWriter{enc: json.NewEncoder(w)}
+}
+
+func (n *ndjsonFindingWriter) Write(f findings.Finding) error {
+	if n.err != nil {
+		return n.err
+	}
+	n.err = n.enc.Encode(f)
+	return n.err
+}
+
+func (n *ndjsonFindingWriter) Flush() error {
+	return n.err
+}
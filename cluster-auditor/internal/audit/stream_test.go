@@ -0,0 +1,52 @@
+package audit_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"goprojects/cluster-auditor/internal/audit"
+	"goprojects/findings"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStreamWriters(t *testing.T) {
+	fs := []findings.Finding{
+		{Cluster: "prod", Kind: "ClusterRole", Resource: "admin", Issue: "Role grants wildcard verbs, \"*\"",
+			Subjects: []string{"User/alice", "Group/ops"}, RuleID: "risky-rbac", Severity: findings.SeverityHigh},
+		{Cluster: "prod", Namespace: "shop", Kind: "Deployment", Resource: "web", Container: "app",
+			Issue: "Missing Liveness Probe", RuleID: "missing-liveness-probe", Severity: findings.SeverityLow, Team: "payments"},
+	}
+
+	var csvOut bytes.Buffer
+	w := audit.NewCSVWriter(&csvOut)
+	for _, f := range fs {
+		require.NoError(t, w.Write(f))
+	}
+	require.NoError(t, w.Flush())
+	require.Equal(t, strings.Join([]string{
+		"cluster,namespace,kind,resource,container,rule_id,severity,team,issue,suggestion,subjects,fingerprint",
+		`prod,,ClusterRole,admin,,risky-rbac,high,,"Role grants wildcard verbs, ""*""",,User/alice;Group/ops,`,
+		"prod,shop,Deployment,web,app,missing-liveness-probe,low,payments,Missing Liveness Probe,,,",
+		"",
+	}, "\n"), csvOut.String())
+
+	var empty bytes.Buffer
+	require.NoError(t, audit.NewCSVWriter(&empty).Flush())
+	require.Equal(t, strings.Join(audit.CSVColumns, ",")+"\n", empty.String(), "an empty report still has a header")
+
+	var ndjson bytes.Buffer
+	w = audit.NewNDJSONWriter(&ndjson)
+	for _, f := range fs {
+		require.NoError(t, w.Write(f))
+	}
+	require.NoError(t, w.Flush())
+	lines := strings.Split(strings.TrimSuffix(ndjson.String(), "\n"), "\n")
+	require.Len(t, lines, 2)
+	var got findings.Finding
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &got))
+	require.Equal(t, fs[1].Team, got.Team)
+	require.Equal(t, fs[1].RuleID, got.RuleID)
+}
@@ -0,0 +1,190 @@
+package audit
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"goprojects/findings"
+)
+
+// TableOptions configures WriteTable
+type TableOptions struct {
+	Wide  bool // add the container, rule, team and suggestion columns and never truncate
+	Color bool // color severities with ANSI escapes
+	Width int  // truncate rows to this many columns, 0 to never truncate
+}
+
+const (
+	ansiReset = "\x1b[0m"
+	ansiBold  = "\x1b[1m"
+	ansiDim   = "\x1b[2m"
+)
+
+var severityColors = map[findings.Severity]string{
+	findings.SeverityCritical: "\x1b[1;31m",
+	findings.SeverityHigh:     "\x1b[31m",
+	findings.SeverityMedium:   "\x1b[33m",
+	findings.SeverityLow:      "\x1b[34m",
+	findings.SeverityInfo:     ansiDim,
+}
+
+// minIssueWidth keeps the issue readable on narrow terminals, where rows wrap
+// rather than losing the issue entirely
+const minIssueWidth = 20
+
+type tableWriter struct {
+	w      io.Writer
+	opts   TableOptions
+	widths []int
+}
+
+func (t *tableWriter) style(s, code string) string {
+	if !t.opts.Color || code == "" {
+		return s
+	}
+	return code + s + ansiReset
+}
+
+// row writes padded cells, coloring each cell by its code. The last cell is
+// truncated to the table width and not padded.
+func (t *tableWriter) row(cells []string, codes ...string) {
+	var line strings.Builder
+	used := 0
+	for i, cell := range cells {
+		code := ""
+		if i < len(codes) {
+			code = codes[i]
+		}
+		if i == len(cells)-1 {
+			if t.opts.Width > 0 {
+				cell = truncate(cell, max(t.opts.Width-used, minIssueWidth))
+			}
+			line.WriteString(t.style(cell, code))
+			break
+		}
+		padded := cell + strings.Repeat(" ", t.widths[i]-utf8.RuneCountInString(cell)+2)
+		line.WriteString(t.style(padded, code))
+		used += t.widths[i] + 2
+	}
+	fmt.Fprintln(t.w, strings.TrimRight(line.String(), " "))
+}
+
+func truncate(s string, width int) string {
+	if utf8.RuneCountInString(s) <= width {
+		return s
+	}
+	return string([]rune(s)[:width-1]) + "…"
+}
+
+func tableCells(f findings.Finding, wide bool) []string {
+	issue := strings.ReplaceAll(f.Issue, "\n", " ")
+	if !wide {
+		return []string{string(f.Severity), f.Kind, f.Resource, issue}
+	}
+	suggestion := strings.ReplaceAll(f.Suggestion, "\n", " ")
+	return []string{string(f.Severity), f.Kind, f.Resource, f.Container, f.RuleID, f.Team, issue, suggestion}
+}
+
+// WriteTable writes the findings of the report as a table for terminals, grouped
+// by namespace with the most severe findings first, and a summary footer
+func WriteTable(w io.Writer, r *Report, score Score, opts TableOptions) error {
+	if opts.Wide {
+		opts.Width = 0
+	}
+	fs := r.Findings()
+	header := []string{"SEVERITY", "KIND", "RESOURCE", "ISSUE"}
+	if opts.Wide {
+		header = []string{"SEVERITY", "KIND", "RESOURCE", "CONTAINER", "RULE", "TEAM", "ISSUE", "SUGGESTION"}
+	}
+
+	namespaces, byNamespace := groupByNamespace(fs)
+
+	// Columns line up across namespaces
+	t := &tableWriter{w: w, opts: opts, widths: make([]int, len(header))}
+	for i, h := range header {
+		t.widths[i] = len(h)
+	}
+	for _, f := range fs {
+		for i, cell := range tableCells(f, opts.Wide) {
+			t.widths[i] = max(t.widths[i], utf8.RuneCountInString(cell))
+		}
+	}
+
+	for i, ns := range namespaces {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		nfs := byNamespace[ns]
+		fmt.Fprintf(w, "%s %s\n", t.style(ns, ansiBold), t.style(fmt.Sprintf("(%s)", plural(len(nfs), "finding")), ansiDim))
+		t.row(header, slices.Repeat([]string{ansiDim}, len(header))...)
+		for _, f := range nfs {
+			t.row(tableCells(f, opts.Wide), severityColors[f.Severity])
+		}
+	}
+
+	if len(namespaces) > 0 {
+		fmt.Fprintln(w)
+	}
+	return writeTableSummary(w, t, r, score, fs, len(namespaces))
+}
+
+// groupByNamespace groups findings by namespace, cluster-scoped ones under
+// clusterScopedGroup. Findings are sorted by severity, then kind and resource,
+// and namespaces with the worst findings come first.
+func groupByNamespace(fs []findings.Finding) ([]string, map[string][]findings.Finding) {
+	byNamespace := map[string][]findings.Finding{}
+	for _, f := range fs {
+		ns := cmp.Or(f.Namespace, clusterScopedGroup)
+		byNamespace[ns] = append(byNamespace[ns], f)
+	}
+	namespaces := make([]string, 0, len(byNamespace))
+	for ns, nfs := range byNamespace {
+		slices.SortStableFunc(nfs, compareFindings)
+		namespaces = append(namespaces, ns)
+	}
+	slices.SortFunc(namespaces, func(a, b string) int {
+		return cmp.Or(byNamespace[b][0].Severity.Rank()-byNamespace[a][0].Severity.Rank(),
+			cmp.Compare(len(byNamespace[b]), len(byNamespace[a])), cmp.Compare(a, b))
+	})
+	return namespaces, byNamespace
+}
+
+// compareFindings orders findings by severity, most severe first, then by kind
+// and resource
+func compareFindings(a, b findings.Finding) int {
+	return cmp.Or(b.Severity.Rank()-a.Severity.Rank(), cmp.Compare(a.Kind, b.Kind), cmp.Compare(a.Resource, b.Resource))
+}
+
+func writeTableSummary(w io.Writer, t *tableWriter, r *Report, score Score, fs []findings.Finding, namespaces int) error {
+	counts := map[findings.Severity]int{}
+	for _, f := range fs {
+		counts[f.Severity]++
+	}
+	var bySeverity []string
+	for _, s := range findings.Severities {
+		bySeverity = append(bySeverity, t.style(fmt.Sprintf("%d %s", counts[s], s), severityColors[s]))
+	}
+
+	if len(fs) == 0 {
+		fmt.Fprintln(w, "No findings.")
+	} else {
+		fmt.Fprintf(w, "%s in %s: %s\n", plural(len(fs), "finding"), plural(namespaces, "namespace"), strings.Join(bySeverity, ", "))
+	}
+	fmt.Fprintf(w, "Health score %s (%s), %s run\n", t.style(fmt.Sprintf("%.1f", score.Value), ansiBold), score.Status, plural(len(r.Checks), "check"))
+	if errs := r.Errors(); len(errs) > 0 {
+		_, err := fmt.Fprintf(w, "%s, their findings are missing\n", t.style(plural(len(errs), "check")+" failed", severityColors[findings.SeverityHigh]))
+		return err
+	}
+	return nil
+}
+
+func plural(n int, noun string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, noun)
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
@@ -0,0 +1,47 @@
+package audit_test
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"goprojects/cluster-auditor/internal/audit"
+	"goprojects/findings"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTable(t *testing.T) {
+	limits, _ := audit.LookupCheck("missing-resource-limits")
+	privileged, _ := audit.LookupCheck("privileged-container")
+	rbac, _ := audit.LookupCheck("risky-rbac")
+	report := &audit.Report{Checks: []audit.CheckReport{
+		{Check: limits, Findings: []findings.Finding{
+			{Namespace: "shop", Kind: "Deployment", Resource: "web", Issue: "Missing resource Limits Requests", Severity: findings.SeverityMedium},
+			{Namespace: "batch", Kind: "CronJob", Resource: "report", Issue: "Missing resource Limits Requests", Severity: findings.SeverityMedium},
+		}},
+		{Check: privileged, Findings: []findings.Finding{
+			{Namespace: "shop", Kind: "Deployment", Resource: "web", Issue: "Container is running with privileged mode enabled", Severity: findings.SeverityCritical},
+		}},
+		{Check: rbac, Err: errors.New("forbidden")},
+	}}
+
+	var buf bytes.Buffer
+	require.NoError(t, audit.WriteTable(&buf, report, audit.ScoreReport(report, 0), audit.TableOptions{Width: 50}))
+	require.Equal(t, strings.Join([]string{
+		"shop (2 findings)",
+		"SEVERITY  KIND        RESOURCE  ISSUE",
+		"critical  Deployment  web       Container is runnin…",
+		"medium    Deployment  web       Missing resource Li…",
+		"",
+		"batch (1 finding)",
+		"SEVERITY  KIND        RESOURCE  ISSUE",
+		"medium    CronJob     report    Missing resource Li…",
+		"",
+		"3 findings in 2 namespaces: 1 critical, 0 high, 2 medium, 0 low, 0 info",
+		"Health score 86.9 (Healthy), 3 checks run",
+		"1 check failed, their findings are missing",
+		"",
+	}, "\n"), buf.String())
+}
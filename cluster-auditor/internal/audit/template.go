@@ -0,0 +1,265 @@
+package audit
+
+import (
+	"cmp"
+	"embed"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"text/template"
+	"time"
+
+	"goprojects/findings"
+)
+
+//go:embed templates/*.tmpl
+var builtinTemplates embed.FS
+
+// BuiltinTemplatePrefix selects a template shipped in the binary, e.g. builtin:summary
+const BuiltinTemplatePrefix = "builtin:"
+
+// TemplateData is the report model templates are rendered with
+type TemplateData struct {
+	Cluster    string
+	Namespace  string // empty for all namespaces
+	StartedAt  time.Time
+	FinishedAt time.Time
+	Duration   time.Duration
+	Score      float64
+	Status     string
+	Excepted   int                // findings covered by exceptions, left out
+	Findings   []findings.Finding // sorted by severity, most severe first
+	Severities []SeverityCount    // every severity, most severe first
+	Checks     []TemplateCheck    // in execution order
+	Teams      []TemplateScore    // health of every team with findings, sorted by team
+	Namespaces []TemplateScore    // health of every namespace with findings, sorted by namespace
+	Errors     []string           // errors of the checks that failed
+}
+
+// SeverityCount is the number of findings of a severity
+type SeverityCount struct {
+	Severity findings.Severity
+	Count    int
+}
+
+// TemplateCheck is the outcome of one check
+type TemplateCheck struct {
+	ID          string
+	Name        string
+	Severity    findings.Severity
+	Category    string
+	Description string
+	Duration    time.Duration
+	Resources   int // audited resources
+	Findings    []findings.Finding
+	Error       string // empty if the check ran
+	Passed      bool   // ran without findings
+}
+
+// TemplateScore is the health score of a team or namespace
+type TemplateScore struct {
+	Name     string
+	Score    float64
+	Status   string
+	Findings int
+}
+
+// FindingGroup is a group of findings returned by the groupBy template function
+type FindingGroup struct {
+	Key      string
+	Findings []findings.Finding
+}
+
+// groupKeys are the fields groupBy can group findings by
+var groupKeys = map[string]func(findings.Finding) string{
+	"cluster":   func(f findings.Finding) string { return f.Cluster },
+	"namespace": func(f findings.Finding) string { return f.Namespace },
+	"kind":      func(f findings.Finding) string { return f.Kind },
+	"resource":  func(f findings.Finding) string { return f.Kind + "/" + f.Resource },
+	"rule":      func(f findings.Finding) string { return f.RuleID },
+	"severity":  func(f findings.Finding) string { return string(f.Severity) },
+	"team":      func(f findings.Finding) string { return f.Team },
+}
+
+// groupBy groups findings by a field, keeping their order within groups. Groups
+// are sorted by key, severities from the most severe.
+func groupBy(field string, fs []findings.Finding) ([]FindingGroup, error) {
+	key, ok := groupKeys[field]
+	if !ok {
+		keys := slices.Sorted(maps.Keys(groupKeys))
+		return nil, fmt.Errorf("cannot group by %q (want %s)", field, strings.Join(keys, ", "))
+	}
+	var groups []FindingGroup
+	index := map[string]int{}
+	for _, f := range fs {
+		k := key(f)
+		i, ok := index[k]
+		if !ok {
+			i = len(groups)
+			index[k] = i
+			groups = append(groups, FindingGroup{Key: k})
+		}
+		groups[i].Findings = append(groups[i].Findings, f)
+	}
+	slices.SortFunc(groups, func(a, b FindingGroup) int {
+		if field == "severity" {
+			return findings.Severity(b.Key).Rank() - findings.Severity(a.Key).Rank()
+		}
+		return cmp.Compare(a.Key, b.Key)
+	})
+	return groups, nil
+}
+
+// severityHexColors are the severity colors of the HTML report
+var severityHexColors = map[findings.Severity]string{
+	findings.SeverityCritical: "#b71c1c",
+	findings.SeverityHigh:     "#e65100",
+	findings.SeverityMedium:   "#f9a825",
+	findings.SeverityLow:      "#1565c0",
+	findings.SeverityInfo:     "#607d8b",
+}
+
+// SeverityColor returns the hex color of a severity, for HTML or chat messages
+func SeverityColor(s findings.Severity) string {
+	return cmp.Or(severityHexColors[s], severityHexColors[findings.SeverityInfo])
+}
+
+var templateFuncs = template.FuncMap{
+	"groupBy":       groupBy,
+	"severityColor": SeverityColor,
+	"colorize":      colorize(false), // set per write by WriteTemplate
+	"join":          func(sep string, elems []string) string { return strings.Join(elems, sep) },
+	"truncate": func(n int, s string) string {
+		if n < 1 {
+			return ""
+		}
+		return truncate(s, n)
+	},
+	"upper":  strings.ToUpper,
+	"lower":  strings.ToLower,
+	"plural": plural,
+}
+
+// colorize returns the colorize template function, which wraps text in the ANSI
+// color of a severity for terminals, or leaves it as is without color
+func colorize(color bool) func(findings.Severity, string) string {
+	return func(s findings.Severity, text string) string {
+		if !color {
+			return text
+		}
+		return severityColors[s] + text + ansiReset
+	}
+}
+
+// NewTemplateData builds the model of a report
+func NewTemplateData(r *Report, score Score) TemplateData {
+	fs := r.Findings()
+	slices.SortStableFunc(fs, func(a, b findings.Finding) int { return b.Severity.Rank() - a.Severity.Rank() })
+	d := TemplateData{
+		Cluster:    r.Cluster,
+		Namespace:  r.Namespace,
+		StartedAt:  r.StartedAt,
+		FinishedAt: r.FinishedAt,
+		Duration:   r.FinishedAt.Sub(r.StartedAt),
+		Score:      score.Value,
+		Status:     score.Status,
+		Excepted:   score.Excepted,
+		Findings:   fs,
+	}
+
+	counts := severityCounts(fs)
+	for _, s := range findings.Severities {
+		d.Severities = append(d.Severities, SeverityCount{Severity: s, Count: counts[s]})
+	}
+	for _, c := range r.Checks {
+		tc := TemplateCheck{
+			ID: c.Check.ID, Name: c.Check.Name, Severity: c.Check.Severity, Category: c.Check.Category,
+			Description: c.Check.Description, Duration: c.Duration, Resources: len(c.Resources),
+			Findings: c.Findings, Passed: c.Err == nil && len(c.Findings) == 0,
+		}
+		if c.Err != nil {
+			tc.Error = c.Err.Error()
+			d.Errors = append(d.Errors, tc.Error)
+		}
+		d.Checks = append(d.Checks, tc)
+	}
+	d.Teams = scoresBy(fs, func(f findings.Finding) string { return f.Team })
+	d.Namespaces = scoresBy(fs, func(f findings.Finding) string { return f.Namespace })
+	return d
+}
+
+// scoresBy scores the findings of each non-empty key
+func scoresBy(fs []findings.Finding, key func(findings.Finding) string) []TemplateScore {
+	byKey := map[string][]findings.Finding{}
+	for _, f := range fs {
+		if k := key(f); k != "" {
+			byKey[k] = append(byKey[k], f)
+		}
+	}
+	var scores []TemplateScore
+	for _, k := range slices.Sorted(maps.Keys(byKey)) {
+		score, status := findings.HealthScore(byKey[k])
+		scores = append(scores, TemplateScore{Name: k, Score: score, Status: status, Findings: len(byKey[k])})
+	}
+	return scores
+}
+
+// BuiltinTemplates returns the names of the templates shipped in the binary
+func BuiltinTemplates() []string {
+	entries, _ := builtinTemplates.ReadDir("templates")
+	var names []string
+	for _, e := range entries {
+		if name, ok := strings.CutSuffix(e.Name(), ".tmpl"); ok {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// LoadTemplate parses a report template from a file, or a built-in template if
+// spec starts with BuiltinTemplatePrefix
+func LoadTemplate(spec string) (*template.Template, error) {
+	var text []byte
+	var err error
+	if name, ok := strings.CutPrefix(spec, BuiltinTemplatePrefix); ok {
+		text, err = builtinTemplates.ReadFile("templates/" + name + ".tmpl")
+		if err != nil {
+			return nil, fmt.Errorf("no built-in template %q (want one of %s)", name, strings.Join(BuiltinTemplates(), ", "))
+		}
+	} else if text, err = os.ReadFile(spec); err != nil {
+		return nil, fmt.Errorf("failed to read template: %w", err)
+	}
+
+	t, err := template.New(path.Base(spec)).Funcs(templateFuncs).Parse(string(text))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template: %w", err)
+	}
+	return t, nil
+}
+
+// WriteTemplate renders the report through a template from LoadTemplate. color
+// enables the ANSI colors of colorize, for terminals.
+func WriteTemplate(w io.Writer, t *template.Template, r *Report, score Score, color bool) error {
+	t, err := t.Clone()
+	if err != nil {
+		return err
+	}
+	return t.Funcs(template.FuncMap{"colorize": colorize(color)}).Execute(w, NewTemplateData(r, score))
+}
+
+func OutputReportWithTemplate(r *Report, score Score, t *template.Template, filename string) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return fmt.Errorf("failed to create report file: %w", err)
+	}
+	defer file.Close()
+
+	if err := WriteTemplate(file, t, r, score, false); err != nil {
+		return fmt.Errorf("failed to render template: %w", err)
+	}
+	return file.Close()
+}
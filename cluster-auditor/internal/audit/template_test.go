@@ -0,0 +1,71 @@
+package audit_test
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"goprojects/cluster-auditor/internal/audit"
+	"goprojects/findings"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTemplate(t *testing.T) {
+	rbac, _ := audit.LookupCheck("risky-rbac")
+	limits, _ := audit.LookupCheck("missing-resource-limits")
+	pvc, _ := audit.LookupCheck("pvc-not-bound")
+	report := &audit.Report{Cluster: "prod", Checks: []audit.CheckReport{
+		{Check: limits, Findings: []findings.Finding{
+			{Namespace: "shop", Kind: "Deployment", Resource: "web", Issue: "Missing resource Limits Requests", Severity: findings.SeverityMedium, Team: "payments"},
+		}},
+		{Check: rbac, Findings: []findings.Finding{
+			{Kind: "ClusterRole", Resource: "admin", Issue: "Role grants wildcard verbs", Severity: findings.SeverityHigh, Subjects: []string{"User/alice", "Group/ops"}},
+		}},
+		{Check: pvc, Err: errors.New("forbidden")},
+	}}
+
+	file := filepath.Join(t.TempDir(), "custom.tmpl")
+	require.NoError(t, os.WriteFile(file, []byte(
+		`{{.Cluster}} {{printf "%.1f" .Score}}
+{{- range groupBy "severity" .Findings}}
+{{.Key}}:{{range .Findings}} {{.Resource}} [{{join "," .Subjects}}] {{truncate 10 .Issue}}{{end}}
+{{- end}}
+{{range .Teams}}{{.Name}}={{.Score}}{{end}} {{range .Checks}}{{if .Error}}{{.ID}} {{.Error}}{{end}}{{end}}
+`), 0o644))
+	tmpl, err := audit.LoadTemplate(file)
+	require.NoError(t, err)
+	var buf bytes.Buffer
+	require.NoError(t, audit.WriteTemplate(&buf, tmpl, report, audit.ScoreReport(report, 0), false))
+	require.Equal(t, `prod 93.2
+high: admin [User/alice,Group/ops] Role gran…
+medium: web [] Missing r…
+payments=98 pvc-not-bound forbidden
+`, buf.String())
+
+	for _, name := range audit.BuiltinTemplates() {
+		tmpl, err := audit.LoadTemplate(audit.BuiltinTemplatePrefix + name)
+		require.NoError(t, err, name)
+		require.NoError(t, audit.WriteTemplate(&bytes.Buffer{}, tmpl, report, audit.ScoreReport(report, 0), false), name)
+	}
+	_, err = audit.LoadTemplate("builtin:nope")
+	require.ErrorContains(t, err, "want one of checks, summary, teams")
+}
+
+func TestTemplateColorize(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "color.tmpl")
+	require.NoError(t, os.WriteFile(file, []byte(`{{colorize "high" "web"}}`), 0o644))
+	tmpl, err := audit.LoadTemplate(file)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, audit.WriteTemplate(&buf, tmpl, &audit.Report{}, audit.Score{}, false))
+	require.Equal(t, "web", buf.String())
+
+	buf.Reset()
+	require.NoError(t, audit.WriteTemplate(&buf, tmpl, &audit.Report{}, audit.Score{}, true))
+	require.Contains(t, buf.String(), "\x1b[")
+	require.Contains(t, buf.String(), "web")
+}
@@ -0,0 +1,363 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"slices"
+	"time"
+
+	"goprojects/cluster-auditor/internal/logging"
+	"goprojects/findings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+)
+
+type watchedKind struct {
+	gvr        schema.GroupVersionResource
+	namespaced bool
+}
+
+// watchedKinds maps every kind referenced by the registry to the resource its informer lists
+var watchedKinds = map[string]watchedKind{
+	"Deployment":              {schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}, true},
+	"StatefulSet":             {schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "statefulsets"}, true},
+	"DaemonSet":               {schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "daemonsets"}, true},
+	"ReplicaSet":              {schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "replicasets"}, true},
+	"Job":                     {schema.GroupVersionResource{Group: "batch", Version: "v1", Resource: "jobs"}, true},
+	"CronJob":                 {schema.GroupVersionResource{Group: "batch", Version: "v1", Resource: "cronjobs"}, true},
+	"Pod":                     {schema.GroupVersionResource{Version: "v1", Resource: "pods"}, true},
+	"ReplicationController":   {schema.GroupVersionResource{Version: "v1", Resource: "replicationcontrollers"}, true},
+	"Service":                 {schema.GroupVersionResource{Version: "v1", Resource: "services"}, true},
+	"PersistentVolumeClaim":   {schema.GroupVersionResource{Version: "v1", Resource: "persistentvolumeclaims"}, true},
+	"PersistentVolume":        {schema.GroupVersionResource{Version: "v1", Resource: "persistentvolumes"}, false},
+	"HorizontalPodAutoscaler": {schema.GroupVersionResource{Group: "autoscaling", Version: "v1", Resource: "horizontalpodautoscalers"}, true},
+	"NetworkPolicy":           {schema.GroupVersionResource{Group: "networking.k8s.io", Version: "v1", Resource: "networkpolicies"}, true},
+	"Role":                    {schema.GroupVersionResource{Group: "rbac.authorization.k8s.io", Version: "v1", Resource: "roles"}, true},
+	"RoleBinding":             {schema.GroupVersionResource{Group: "rbac.authorization.k8s.io", Version: "v1", Resource: "rolebindings"}, true},
+	"ClusterRole":             {schema.GroupVersionResource{Group: "rbac.authorization.k8s.io", Version: "v1", Resource: "clusterroles"}, false},
+	"ClusterRoleBinding":      {schema.GroupVersionResource{Group: "rbac.authorization.k8s.io", Version: "v1", Resource: "clusterrolebindings"}, false},
+}
+
+// statusAudited lists the kinds whose status is read by checks. Updates of the
+// status of other kinds do not change findings and are ignored.
+var statusAudited = map[string]bool{"PersistentVolumeClaim": true, "PersistentVolume": true}
+
+// ResourceOfKind returns the API resource of a kind that checks report findings
+// about, and whether it is namespaced
+func ResourceOfKind(kind string) (gvr schema.GroupVersionResource, namespaced, ok bool) {
+	wk, ok := watchedKinds[kind]
+	return wk.gvr, wk.namespaced, ok
+}
+
+// watchKey identifies one check to re-run for one namespace ("" meaning all
+// namespaces). A key without a check is a sweep, re-running every check.
+type watchKey struct {
+	namespace string
+	checkID   string
+}
+
+// Watcher keeps findings up to date from informer events instead of periodic full
+// audits. Only the checks that read the changed kind are re-run, and they read from
+// the informer cache, so the API server only sees the initial lists and the watches.
+// When watching all namespaces, namespaces created or deleted are re-evaluated too.
+// Every check is also re-run from the cache at start and every Resync period, which
+// re-evaluates time based checks. Watching one namespace only lists namespaced
+// kinds, so checks reading cluster-scoped kinds are skipped.
+type Watcher struct {
+	Client    kubernetes.Interface
+	Checks    []Check       // checks to keep up to date, e.g. from SelectChecks
+	Cluster   string        // cluster name stamped on findings
+	Namespace string        // restrict watching to one namespace, empty for all
+	Resync    time.Duration // period of the sweeps re-running every check, 0 for none after the first
+	Debounce  time.Duration // delay that coalesces bursts of events, e.g. during a rollout
+
+	// Update receives the complete set of findings a check produced for a namespace
+	// ("" meaning cluster-wide) every time that set may have changed.
+	Update func(namespace, checkID string, fs []findings.Finding) error
+	// Swept, if not nil, receives the report of every sweep once its findings
+	// were passed to Update, e.g. to record it as an audit run
+	Swept func(*Report)
+
+	factory informers.SharedInformerFactory
+	cache   kubernetes.Interface // serves the checks from the informer caches
+	queue   workqueue.TypedDelayingInterface[watchKey]
+	checks  []Check // the Checks that can run in the watched scope
+}
+
+// inScope reports whether a kind can be listed in the watched scope
+func (w *Watcher) inScope(kind string) bool {
+	wk, ok := watchedKinds[kind]
+	return ok && (wk.namespaced || w.Namespace == "")
+}
+
+// checksInScope returns the Checks whose kinds can all be listed in the watched
+// scope
+func (w *Watcher) checksInScope() []Check {
+	var checks []Check
+	for _, check := range w.Checks {
+		if !slices.ContainsFunc(check.Kinds, func(kind string) bool { return !w.inScope(kind) }) {
+			checks = append(checks, check)
+			continue
+		}
+		slog.Warn("watch: check reads cluster-scoped kinds, skipped when watching one namespace",
+			"check_id", check.ID, "namespace", w.Namespace)
+	}
+	return checks
+}
+
+// Run starts the informers and processes events until ctx is cancelled
+func (w *Watcher) Run(ctx context.Context) error {
+	w.factory = informers.NewSharedInformerFactoryWithOptions(w.Client, 0, informers.WithNamespace(w.Namespace))
+	w.cache = listerClient(w.listCached)
+	w.queue = workqueue.NewTypedDelayingQueue[watchKey]()
+	defer w.queue.ShutDown()
+	w.checks = w.checksInScope()
+
+	var synced []cache.InformerSynced
+	for kind, wk := range watchedKinds {
+		if !w.inScope(kind) {
+			continue
+		}
+		informer, err := w.factory.ForResource(wk.gvr)
+		if err != nil {
+			return fmt.Errorf("failed to create informer for %s: %w", kind, err)
+		}
+		_, err = informer.Informer().AddEventHandler(w.handlerFor(kind))
+		if err != nil {
+			return fmt.Errorf("failed to register handler for %s: %w", kind, err)
+		}
+		synced = append(synced, informer.Informer().HasSynced)
+	}
+	if w.Namespace == "" {
+		namespaces := w.factory.Core().V1().Namespaces().Informer()
+		if _, err := namespaces.AddEventHandler(w.namespaceHandler()); err != nil {
+			return fmt.Errorf("failed to register handler for Namespace: %w", err)
+		}
+		synced = append(synced, namespaces.HasSynced)
+	}
+
+	w.factory.Start(ctx.Done())
+	defer w.factory.Shutdown()
+	if !cache.WaitForCacheSync(ctx.Done(), synced...) {
+		return fmt.Errorf("timed out waiting for informer caches to sync")
+	}
+
+	// The first sweep makes the store complete
+	w.queue.Add(watchKey{namespace: w.Namespace})
+	go func() {
+		defer w.queue.ShutDown()
+		if w.Resync <= 0 {
+			<-ctx.Done()
+			return
+		}
+		ticker := time.NewTicker(w.Resync)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				w.queue.Add(watchKey{namespace: w.Namespace})
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	for w.processNext(ctx) {
+	}
+	return nil
+}
+
+func (w *Watcher) handlerFor(kind string) cache.ResourceEventHandler {
+	enqueue := func(obj interface{}) {
+		if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+			obj = tombstone.Obj
+		}
+		accessor, err := meta.Accessor(obj)
+		if err != nil {
+			return
+		}
+		namespace := accessor.GetNamespace()
+		if namespace == "" {
+			namespace = w.Namespace
+		}
+		for _, check := range w.checks {
+			if slices.Contains(check.Kinds, kind) {
+				w.queue.AddAfter(watchKey{namespace: namespace, checkID: check.ID}, w.Debounce)
+			}
+		}
+	}
+	return cache.ResourceEventHandlerFuncs{
+		AddFunc: enqueue,
+		UpdateFunc: func(old, obj interface{}) {
+			if auditedChange(kind, old, obj) {
+				enqueue(obj)
+			}
+		},
+		DeleteFunc: enqueue,
+	}
+}
+
+// namespaceHandler re-runs every check for namespaces created or deleted after
+// the initial evaluation, e.g. so a new namespace without NetworkPolicies is
+// reported and the findings of a deleted one are resolved
+func (w *Watcher) namespaceHandler() cache.ResourceEventHandler {
+	enqueue := func(obj interface{}) {
+		if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+			obj = tombstone.Obj
+		}
+		accessor, err := meta.Accessor(obj)
+		if err != nil {
+			return
+		}
+		for _, check := range w.checks {
+			w.queue.AddAfter(watchKey{namespace: accessor.GetName(), checkID: check.ID}, w.Debounce)
+		}
+	}
+	return cache.ResourceEventHandlerDetailedFuncs{
+		AddFunc: func(obj interface{}, isInInitialList bool) {
+			if !isInInitialList {
+				enqueue(obj)
+			}
+		},
+		DeleteFunc: enqueue,
+	}
+}
+
+// auditedChange reports whether an update may change findings. Updates without a
+// new resource version, like relists, always do, while status updates only do for
+// the kinds in statusAudited.
+func auditedChange(kind string, old, obj interface{}) bool {
+	oldFields, err := runtime.DefaultUnstructuredConverter.ToUnstructured(old)
+	if err != nil {
+		return true
+	}
+	fields, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return true
+	}
+	oldObj, newObj := &unstructured.Unstructured{Object: oldFields}, &unstructured.Unstructured{Object: fields}
+	if oldObj.GetResourceVersion() == newObj.GetResourceVersion() {
+		return true
+	}
+	for _, u := range []*unstructured.Unstructured{oldObj, newObj} {
+		u.SetResourceVersion("")
+		u.SetManagedFields(nil)
+		if !statusAudited[kind] {
+			unstructured.RemoveNestedField(u.Object, "status")
+		}
+	}
+	return !reflect.DeepEqual(oldObj.Object, newObj.Object)
+}
+
+func (w *Watcher) processNext(ctx context.Context) bool {
+	key, shutdown := w.queue.Get()
+	if shutdown {
+		return false
+	}
+	defer w.queue.Done(key)
+
+	if key.checkID == "" {
+		w.sweep(logging.With(ctx, "namespace", key.namespace), key.namespace)
+		return true
+	}
+	ctx = logging.With(ctx, "check_id", key.checkID, "namespace", key.namespace)
+	if err := w.evaluate(ctx, key); err != nil {
+		slog.ErrorContext(ctx, "watch: failed to evaluate check", "err", err)
+	}
+	return true
+}
+
+// sweep re-runs every check for the namespace, like a full audit
+func (w *Watcher) sweep(ctx context.Context, namespace string) {
+	a := findings.NewAuditor()
+	a.Cluster = w.Cluster
+	report := &Report{Cluster: w.Cluster, Namespace: namespace, StartedAt: time.Now()}
+	for _, check := range w.checks {
+		checkCtx := logging.With(ctx, "check_id", check.ID)
+		cr := w.runCheck(checkCtx, a, namespace, check)
+		if cr.Err == nil {
+			cr.Err = w.Update(namespace, check.ID, cr.Findings)
+		}
+		if cr.Err != nil {
+			slog.ErrorContext(checkCtx, "watch: failed to evaluate check", "err", cr.Err)
+		}
+		report.Checks = append(report.Checks, cr)
+	}
+	report.FinishedAt = time.Now()
+	if w.Swept != nil {
+		w.Swept(report)
+	}
+}
+
+// runCheck runs a check from the cache. Across all namespaces, PerNamespace
+// checks run for each namespace, so they report the same findings as the
+// evaluations of namespace events.
+func (w *Watcher) runCheck(ctx context.Context, a *findings.Auditor, namespace string, check Check) CheckReport {
+	if namespace != "" || !check.PerNamespace {
+		return RunCheckReport(ctx, a, w.cache, namespace, check)
+	}
+	cr := CheckReport{Check: check}
+	start := time.Now()
+	namespaces, err := w.factory.Core().V1().Namespaces().Lister().List(labels.Everything())
+	if err != nil {
+		cr.Err = fmt.Errorf("failed to list namespaces: %w", err)
+	}
+	for _, ns := range namespaces {
+		nr := RunCheckReport(ctx, a, w.cache, ns.Name, check)
+		cr.Findings = append(cr.Findings, nr.Findings...)
+		cr.Resources = append(cr.Resources, nr.Resources...)
+		if nr.Err != nil {
+			cr.Err = nr.Err
+			break
+		}
+	}
+	cr.Duration = time.Since(start)
+	cr.Resources = uniqueResources(cr.Resources)
+	return cr
+}
+
+func (w *Watcher) evaluate(ctx context.Context, key watchKey) error {
+	check, ok := LookupCheck(key.checkID)
+	if !ok {
+		return fmt.Errorf("unknown check %q", key.checkID)
+	}
+
+	// The namespace is gone, and so are its findings
+	if w.Namespace == "" && key.namespace != "" {
+		_, err := w.factory.Core().V1().Namespaces().Lister().Get(key.namespace)
+		if apierrors.IsNotFound(err) {
+			return w.Update(key.namespace, check.ID, nil)
+		}
+	}
+
+	a := findings.NewAuditor()
+	a.Cluster = w.Cluster
+	cr := w.runCheck(ctx, a, key.namespace, check)
+	if cr.Err != nil {
+		return cr.Err
+	}
+	return w.Update(key.namespace, check.ID, cr.Findings)
+}
+
+// listCached lists objects from the informer caches
+func (w *Watcher) listCached(gvr schema.GroupVersionResource, namespace string) ([]runtime.Object, error) {
+	informer, err := w.factory.ForResource(gvr)
+	if err != nil {
+		return nil, err
+	}
+	if namespace != "" {
+		return informer.Lister().ByNamespace(namespace).List(labels.Everything())
+	}
+	return informer.Lister().List(labels.Everything())
+}
@@ -0,0 +1,239 @@
+package audit_test
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"goprojects/cluster-auditor/internal/audit"
+	"goprojects/findings"
+)
+
+func newDeployment(name, ns, image string) *appsv1.Deployment {
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: ns},
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{Name: "app", Image: image}},
+				},
+			},
+		},
+	}
+}
+
+func newNamespace(name string) *corev1.Namespace {
+	return &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name}}
+}
+
+func TestWatcherUpdatesOnChange(t *testing.T) {
+	client := fake.NewSimpleClientset(newNamespace("default"), newDeployment("web", "default", "nginx:1.27"))
+
+	var mu sync.Mutex
+	latest := map[string][]findings.Finding{}
+	var swept *audit.Report
+	w := &audit.Watcher{
+		Client:  client,
+		Checks:  audit.Registry,
+		Cluster: "test",
+		Update: func(ns, checkID string, fs []findings.Finding) error {
+			mu.Lock()
+			defer mu.Unlock()
+			latest[checkID] = fs
+			return nil
+		},
+		Swept: func(r *audit.Report) {
+			mu.Lock()
+			defer mu.Unlock()
+			swept = r
+		},
+	}
+	tagFindings := func() ([]findings.Finding, bool) {
+		mu.Lock()
+		defer mu.Unlock()
+		fs, ok := latest["latest-image-tag"]
+		return fs, ok
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error)
+	go func() { done <- w.Run(ctx) }()
+
+	require.Eventually(t, func() bool {
+		fs, ok := tagFindings()
+		return ok && len(fs) == 0
+	}, 5*time.Second, 20*time.Millisecond, "initial evaluation should report no tag findings")
+	require.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return swept != nil
+	}, 5*time.Second, 20*time.Millisecond, "initial evaluation should be reported as a sweep")
+	mu.Lock()
+	require.Equal(t, "test", swept.Cluster)
+	require.Len(t, swept.Checks, len(audit.Registry))
+	require.Empty(t, swept.Errors())
+	mu.Unlock()
+
+	_, err := client.AppsV1().Deployments("default").Update(ctx, newDeployment("web", "default", "nginx:latest"), metav1.UpdateOptions{})
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		fs, _ := tagFindings()
+		return len(fs) == 1 && fs[0].Resource == "web" && fs[0].RuleID == "latest-image-tag"
+	}, 5*time.Second, 20*time.Millisecond, "rollout with latest tag should be reported")
+
+	cancel()
+	require.NoError(t, <-done)
+}
+
+func TestWatcherIgnoresStatusUpdates(t *testing.T) {
+	deployment := newDeployment("web", "default", "nginx:latest")
+	deployment.ResourceVersion = "1"
+	client := fake.NewSimpleClientset(newNamespace("default"), deployment)
+
+	var runs atomic.Int32
+	w := &audit.Watcher{
+		Client: client,
+		Checks: audit.Registry,
+		Update: func(ns, checkID string, fs []findings.Finding) error {
+			if checkID == "latest-image-tag" {
+				runs.Add(1)
+			}
+			return nil
+		},
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error)
+	go func() { done <- w.Run(ctx) }()
+	require.Eventually(t, func() bool { return runs.Load() > 0 }, 5*time.Second, 20*time.Millisecond)
+	time.Sleep(100 * time.Millisecond) // the evaluation of the initial list
+	before := runs.Load()
+
+	// A rollout only updating the status does not re-run the checks
+	deployment = deployment.DeepCopy()
+	deployment.ResourceVersion = "2"
+	deployment.Status.Replicas = 3
+	_, err := client.AppsV1().Deployments("default").UpdateStatus(ctx, deployment, metav1.UpdateOptions{})
+	require.NoError(t, err)
+	time.Sleep(100 * time.Millisecond)
+	require.Equal(t, before, runs.Load())
+
+	deployment = deployment.DeepCopy()
+	deployment.ResourceVersion = "3"
+	deployment.Labels = map[string]string{"app": "web"}
+	_, err = client.AppsV1().Deployments("default").Update(ctx, deployment, metav1.UpdateOptions{})
+	require.NoError(t, err)
+	require.Eventually(t, func() bool { return runs.Load() > before }, 5*time.Second, 20*time.Millisecond)
+
+	cancel()
+	require.NoError(t, <-done)
+}
+
+func TestWatcherNamespaces(t *testing.T) {
+	client := fake.NewSimpleClientset(newNamespace("default"), newNamespace("blog"))
+
+	var mu sync.Mutex
+	latest := map[string][]findings.Finding{}
+	w := &audit.Watcher{
+		Client: client,
+		Checks: audit.Registry,
+		Update: func(ns, checkID string, fs []findings.Finding) error {
+			mu.Lock()
+			defer mu.Unlock()
+			if checkID == "missing-network-policy" {
+				latest[ns] = fs
+			}
+			return nil
+		},
+	}
+	policyFindings := func(ns string) ([]findings.Finding, bool) {
+		mu.Lock()
+		defer mu.Unlock()
+		fs, ok := latest[ns]
+		return fs, ok
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error)
+	go func() { done <- w.Run(ctx) }()
+	require.Eventually(t, func() bool {
+		_, ok := policyFindings("")
+		return ok
+	}, 5*time.Second, 20*time.Millisecond)
+
+	// The sweep reports every namespace, like the evaluations of their events
+	fs, _ := policyFindings("")
+	require.Len(t, fs, 2)
+	require.Equal(t, []string{"blog", "default"}, []string{fs[0].Namespace, fs[1].Namespace})
+
+	// A new namespace without NetworkPolicies is reported, and resolved once deleted
+	_, err := client.CoreV1().Namespaces().Create(ctx, newNamespace("shop"), metav1.CreateOptions{})
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		fs, _ := policyFindings("shop")
+		return len(fs) == 1 && fs[0].Kind == "Namespace" && fs[0].Resource == "shop"
+	}, 5*time.Second, 20*time.Millisecond)
+
+	require.NoError(t, client.CoreV1().Namespaces().Delete(ctx, "shop", metav1.DeleteOptions{}))
+	require.Eventually(t, func() bool {
+		fs, _ := policyFindings("shop")
+		return len(fs) == 0
+	}, 5*time.Second, 20*time.Millisecond)
+
+	cancel()
+	require.NoError(t, <-done)
+}
+
+func TestWatcherNamespaceScope(t *testing.T) {
+	client := fake.NewSimpleClientset(newDeployment("web", "shop", "nginx:latest"))
+
+	var mu sync.Mutex
+	var swept *audit.Report
+	w := &audit.Watcher{
+		Client:    client,
+		Checks:    audit.Registry,
+		Namespace: "shop",
+		Update:    func(ns, checkID string, fs []findings.Finding) error { return nil },
+		Swept: func(r *audit.Report) {
+			mu.Lock()
+			defer mu.Unlock()
+			swept = r
+		},
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error)
+	go func() { done <- w.Run(ctx) }()
+
+	// Cluster-scoped kinds are not listed, so the caches sync and the checks
+	// reading them are skipped
+	require.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return swept != nil
+	}, 5*time.Second, 20*time.Millisecond)
+	mu.Lock()
+	var ids []string
+	for _, cr := range swept.Checks {
+		ids = append(ids, cr.Check.ID)
+	}
+	require.Empty(t, swept.Errors())
+	mu.Unlock()
+	require.Contains(t, ids, "latest-image-tag")
+	require.Contains(t, ids, "privileged-container")
+	require.NotContains(t, ids, "unclaimed-pv")
+	require.NotContains(t, ids, "risky-rbac")
+
+	cancel()
+	require.NoError(t, <-done)
+}
@@ -30,7 +30,7 @@ type Workload struct {
 	PodSpec   corev1.PodSpec
 }
 
-func GatherWorkloads(client kubernetes.Interface, namespace string, types ...WorkloadType) ([]Workload, error) {
+func GatherWorkloads(ctx context.Context, client kubernetes.Interface, namespace string, types ...WorkloadType) ([]Workload, error) {
 	var workloads []Workload
 
 	// If no types are passed, gather all
@@ -54,7 +54,7 @@ func GatherWorkloads(client kubernetes.Interface, namespace string, types ...Wor
 
 	fetchers := map[WorkloadType]func() error{
 		Deployment: func() error {
-			items, err := client.AppsV1().Deployments(namespace).List(context.TODO(), metav1.ListOptions{})
+			items, err := client.AppsV1().Deployments(namespace).List(ctx, metav1.ListOptions{})
 			if err != nil {
 				return err
 			}
@@ -66,7 +66,7 @@ func GatherWorkloads(client kubernetes.Interface, namespace string, types ...Wor
 			return nil
 		},
 		StatefulSet: func() error {
-			items, err := client.AppsV1().StatefulSets(namespace).List(context.TODO(), metav1.ListOptions{})
+			items, err := client.AppsV1().StatefulSets(namespace).List(ctx, metav1.ListOptions{})
 			if err != nil {
 				return err
 			}
@@ -78,7 +78,7 @@ func GatherWorkloads(client kubernetes.Interface, namespace string, types ...Wor
 			return nil
 		},
 		DaemonSet: func() error {
-			items, err := client.AppsV1().DaemonSets(namespace).List(context.TODO(), metav1.ListOptions{})
+			items, err := client.AppsV1().DaemonSets(namespace).List(ctx, metav1.ListOptions{})
 			if err != nil {
 				return err
 			}
@@ -90,7 +90,7 @@ func GatherWorkloads(client kubernetes.Interface, namespace string, types ...Wor
 			return nil
 		},
 		Job: func() error {
-			items, err := client.BatchV1().Jobs(namespace).List(context.TODO(), metav1.ListOptions{})
+			items, err := client.BatchV1().Jobs(namespace).List(ctx, metav1.ListOptions{})
 			if err != nil {
 				return err
 			}
@@ -102,7 +102,7 @@ func GatherWorkloads(client kubernetes.Interface, namespace string, types ...Wor
 			return nil
 		},
 		CronJob: func() error {
-			items, err := client.BatchV1().CronJobs(namespace).List(context.TODO(), metav1.ListOptions{})
+			items, err := client.BatchV1().CronJobs(namespace).List(ctx, metav1.ListOptions{})
 			if err != nil {
 				return err
 			}
@@ -114,7 +114,7 @@ func GatherWorkloads(client kubernetes.Interface, namespace string, types ...Wor
 			return nil
 		},
 		ReplicaSet: func() error {
-			items, err := client.AppsV1().ReplicaSets(namespace).List(context.TODO(), metav1.ListOptions{})
+			items, err := client.AppsV1().ReplicaSets(namespace).List(ctx, metav1.ListOptions{})
 			if err != nil {
 				return err
 			}
@@ -126,7 +126,7 @@ func GatherWorkloads(client kubernetes.Interface, namespace string, types ...Wor
 			return nil
 		},
 		Pod: func() error {
-			items, err := client.CoreV1().Pods(namespace).List(context.TODO(), metav1.ListOptions{})
+			items, err := client.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{})
 			if err != nil {
 				return err
 			}
@@ -138,7 +138,7 @@ func GatherWorkloads(client kubernetes.Interface, namespace string, types ...Wor
 			return nil
 		},
 		ReplicationController: func() error {
-			items, err := client.CoreV1().ReplicationControllers(namespace).List(context.TODO(), metav1.ListOptions{})
+			items, err := client.CoreV1().ReplicationControllers(namespace).List(ctx, metav1.ListOptions{})
 			if err != nil {
 				return err
 			}
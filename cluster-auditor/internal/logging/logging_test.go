@@ -0,0 +1,35 @@
+package logging_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"goprojects/cluster-auditor/internal/logging"
+)
+
+func TestContextAttributes(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := logging.New(&buf, "debug", "json")
+	require.NoError(t, err)
+
+	ctx := logging.With(context.Background(), "run_id", 42, "namespace", "payments")
+	ctx = logging.With(ctx, "check_id", "risky-rbac")
+	logger.InfoContext(ctx, "Check failed", "err", "forbidden")
+
+	var record map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+	require.Equal(t, "Check failed", record["msg"])
+	require.Equal(t, float64(42), record["run_id"])
+	require.Equal(t, "payments", record["namespace"])
+	require.Equal(t, "risky-rbac", record["check_id"])
+	require.Equal(t, "forbidden", record["err"])
+
+	_, err = logging.New(&buf, "verbose", "json")
+	require.Error(t, err)
+	_, err = logging.New(&buf, "info", "xml")
+	require.Error(t, err)
+}
@@ -0,0 +1,198 @@
+package notify
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+
+	"goprojects/findings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config is the YAML notification config. URLs, secrets, header values and SMTP
+// credentials may reference environment variables as ${VAR}, so secrets need not
+// be written in the file. Other text, including a lone $, is taken literally.
+// Settings left out take the Notifier defaults; retries: 0 disables retries.
+//
+//	minSeverity: high
+//	dedupWindow: 24h
+//	rateLimit: 10
+//	retryInterval: 5m
+//	webhooks:
+//	  - url: https://hooks.example.com/audit
+//	    secret: ${WEBHOOK_SECRET}
+//	    template: body.tmpl
+//	slack:
+//	  - url: ${SLACK_WEBHOOK_URL}
+//	    channel: "#platform-alerts"
+//	smtp:
+//	  - addr: smtp.example.com:587
+//	    from: auditor@example.com
+//	    to: [platform@example.com]
+//	    username: auditor
+//	    password: ${SMTP_PASSWORD}
+type Config struct {
+	MinSeverity string `yaml:"minSeverity"`
+	DedupWindow string `yaml:"dedupWindow"`
+	RateLimit   *int   `yaml:"rateLimit"`
+	Retries     *int   `yaml:"retries"`
+	Backoff     string `yaml:"backoff"`
+	MaxFindings *int   `yaml:"maxFindings"`
+	// RetryInterval is how often long running modes resend pending notifications
+	RetryInterval string          `yaml:"retryInterval"`
+	Webhooks      []WebhookConfig `yaml:"webhooks"`
+	Slack         []SlackConfig   `yaml:"slack"`
+	SMTP          []SMTPConfig    `yaml:"smtp"`
+}
+
+type WebhookConfig struct {
+	URL     string            `yaml:"url"`
+	Secret  string            `yaml:"secret"`
+	Headers map[string]string `yaml:"headers"`
+	// Template is a text/template file rendering the JSON body, relative to the config
+	Template string `yaml:"template"`
+}
+
+type SlackConfig struct {
+	URL      string `yaml:"url"`
+	Channel  string `yaml:"channel"`
+	Username string `yaml:"username"`
+}
+
+type SMTPConfig struct {
+	Addr     string   `yaml:"addr"`
+	From     string   `yaml:"from"`
+	To       []string `yaml:"to"`
+	Username string   `yaml:"username"`
+	Password string   `yaml:"password"`
+}
+
+// LoadConfig reads a notification config and returns a Notifier sending to its sinks
+func LoadConfig(filename string) (*Notifier, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read notification config: %w", err)
+	}
+	var c Config
+	if err := yaml.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("failed to parse notification config %s: %w", filename, err)
+	}
+	n, err := c.notifier(filepath.Dir(filename))
+	if err != nil {
+		return nil, fmt.Errorf("notification config %s: %w", filename, err)
+	}
+	return n, nil
+}
+
+func (c Config) notifier(dir string) (*Notifier, error) {
+	n := &Notifier{}
+	var err error
+	if c.RateLimit != nil {
+		if n.RateLimit, err = parsePositive("rateLimit", *c.RateLimit); err != nil {
+			return nil, err
+		}
+	}
+	if c.MaxFindings != nil {
+		if n.MaxFindings, err = parsePositive("maxFindings", *c.MaxFindings); err != nil {
+			return nil, err
+		}
+	}
+	if c.Retries != nil {
+		switch {
+		case *c.Retries < 0:
+			return nil, fmt.Errorf("invalid retries %d", *c.Retries)
+		case *c.Retries == 0:
+			n.Retries = NoRetries
+		default:
+			n.Retries = *c.Retries
+		}
+	}
+	if c.MinSeverity != "" {
+		if n.MinSeverity, err = findings.ParseSeverity(c.MinSeverity); err != nil {
+			return nil, err
+		}
+	}
+	if c.DedupWindow != "" {
+		if n.DedupWindow, err = parseDuration("dedupWindow", c.DedupWindow); err != nil {
+			return nil, err
+		}
+	}
+	if c.RetryInterval != "" {
+		if n.RetryInterval, err = parseDuration("retryInterval", c.RetryInterval); err != nil {
+			return nil, err
+		}
+	}
+	if c.Backoff != "" {
+		if n.Backoff, err = parseDuration("backoff", c.Backoff); err != nil {
+			return nil, err
+		}
+	}
+
+	for i, w := range c.Webhooks {
+		if w.URL == "" {
+			return nil, fmt.Errorf("webhook %d has no url", i+1)
+		}
+		sink := &WebhookSink{URL: expandEnv(w.URL), Secret: expandEnv(w.Secret), Headers: map[string]string{}}
+		for k, v := range w.Headers {
+			sink.Headers[k] = expandEnv(v)
+		}
+		if w.Template != "" {
+			file := w.Template
+			if !filepath.IsAbs(file) {
+				file = filepath.Join(dir, file)
+			}
+			text, err := os.ReadFile(file)
+			if err != nil {
+				return nil, fmt.Errorf("webhook %d: %w", i+1, err)
+			}
+			if sink.Template, err = ParseWebhookTemplate(filepath.Base(file), string(text)); err != nil {
+				return nil, fmt.Errorf("webhook %d: %w", i+1, err)
+			}
+		}
+		n.Sinks = append(n.Sinks, sink)
+	}
+	for i, s := range c.Slack {
+		if s.URL == "" {
+			return nil, fmt.Errorf("slack sink %d has no url", i+1)
+		}
+		n.Sinks = append(n.Sinks, &SlackSink{URL: expandEnv(s.URL), Channel: s.Channel, Username: s.Username})
+	}
+	for i, s := range c.SMTP {
+		if s.Addr == "" || s.From == "" || len(s.To) == 0 {
+			return nil, fmt.Errorf("smtp sink %d needs addr, from and to", i+1)
+		}
+		n.Sinks = append(n.Sinks, &SMTPSink{Addr: s.Addr, From: s.From, To: s.To, Username: expandEnv(s.Username), Password: expandEnv(s.Password)})
+	}
+	if len(n.Sinks) == 0 {
+		return nil, errors.New("no sinks configured")
+	}
+	return n, nil
+}
+
+var envReference = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// expandEnv replaces the ${VAR} references in a value with the environment variables
+func expandEnv(s string) string {
+	return envReference.ReplaceAllStringFunc(s, func(ref string) string {
+		return os.Getenv(ref[2 : len(ref)-1])
+	})
+}
+
+func parsePositive(name string, v int) (int, error) {
+	if v <= 0 {
+		return 0, fmt.Errorf("invalid %s %d", name, v)
+	}
+	return v, nil
+}
+
+func parseDuration(name, s string) (time.Duration, error) {
+	d, err := time.ParseDuration(s)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid %s %q", name, s)
+	}
+	return d, nil
+}
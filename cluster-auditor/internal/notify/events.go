@@ -0,0 +1,175 @@
+package notify
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"goprojects/cluster-auditor/internal/audit"
+	"goprojects/findings"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/clock"
+)
+
+const (
+	// EventReason is the reason of the events about findings
+	EventReason = "AuditFinding"
+	eventSource = "cluster-auditor"
+
+	DefaultEventBurst  = 10
+	DefaultEventRefill = 5 * time.Minute
+)
+
+// EventEmitter records a Warning Event on the object of each finding, so it shows
+// up in kubectl describe. Identical events are aggregated into one with a count,
+// and each object gets at most Burst events, then one more per Refill, like the
+// events of the kubelet and controllers. Callers pass findings as they open.
+// A nil EventEmitter emits nothing.
+type EventEmitter struct {
+	Client  kubernetes.Interface
+	Dynamic dynamic.Interface // looks up the UIDs of the objects, which kubectl describe matches events on
+	Burst   int
+	Refill  time.Duration
+	Clock   clock.Clock // for tests, default the real clock
+
+	once       sync.Once
+	correlator *record.EventCorrelator
+	host       string
+}
+
+func (e *EventEmitter) init() {
+	e.once.Do(func() {
+		e.Clock = cmp.Or[clock.Clock](e.Clock, clock.RealClock{})
+		e.correlator = record.NewEventCorrelatorWithOptions(record.CorrelatorOptions{
+			BurstSize: cmp.Or(e.Burst, DefaultEventBurst),
+			QPS:       float32(1 / cmp.Or(e.Refill, DefaultEventRefill).Seconds()),
+			Clock:     e.Clock,
+		})
+		e.host, _ = os.Hostname()
+	})
+}
+
+// Emit records an event for every finding. Findings about objects that are gone,
+// or of kinds without an API resource, are skipped. Objects whose events are rate
+// limited are logged at debug level.
+func (e *EventEmitter) Emit(ctx context.Context, fs []findings.Finding) error {
+	if e == nil || len(fs) == 0 {
+		return nil
+	}
+	e.init()
+	var errs []error
+	for _, f := range fs {
+		ref, err := e.reference(ctx, f)
+		if apierrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if ref == nil {
+			continue
+		}
+		if err := e.record(ctx, e.event(ref, f)); err != nil {
+			errs = append(errs, fmt.Errorf("failed to record event on %s %s: %w", f.Kind, ref.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// reference returns the object a finding is about, nil for unknown kinds
+func (e *EventEmitter) reference(ctx context.Context, f findings.Finding) (*corev1.ObjectReference, error) {
+	gvr, namespaced, ok := audit.ResourceOfKind(f.Kind)
+	if !ok {
+		return nil, nil
+	}
+	resource := e.Dynamic.Resource(gvr)
+	var ri dynamic.ResourceInterface = resource
+	if namespaced {
+		ri = resource.Namespace(f.Namespace)
+	}
+	obj, err := ri.Get(ctx, f.Resource, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return &corev1.ObjectReference{
+		APIVersion:      gvr.GroupVersion().String(),
+		Kind:            f.Kind,
+		Namespace:       obj.GetNamespace(),
+		Name:            obj.GetName(),
+		UID:             obj.GetUID(),
+		ResourceVersion: obj.GetResourceVersion(),
+	}, nil
+}
+
+func (e *EventEmitter) event(ref *corev1.ObjectReference, f findings.Finding) *corev1.Event {
+	message := fmt.Sprintf("[%s] %s: %s", f.Severity, f.RuleID, f.Issue)
+	if f.Container != "" {
+		message += " (container " + f.Container + ")"
+	}
+	if f.Suggestion != "" {
+		message += ". " + strings.TrimSuffix(f.Suggestion, ".")
+	}
+	now := metav1.NewTime(e.Clock.Now())
+	namespace := cmp.Or(ref.Namespace, metav1.NamespaceDefault) // events of cluster-scoped objects
+	return &corev1.Event{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s.%x", ref.Name, now.UnixNano()),
+			Namespace: namespace,
+		},
+		InvolvedObject:      *ref,
+		Reason:              EventReason,
+		Message:             message,
+		Type:                corev1.EventTypeWarning,
+		Source:              corev1.EventSource{Component: eventSource, Host: e.host},
+		FirstTimestamp:      now,
+		LastTimestamp:       now,
+		Count:               1,
+		ReportingController: eventSource,
+		ReportingInstance:   e.host,
+	}
+}
+
+// record writes an event through the correlator, the way the event broadcaster of
+// client-go does, but synchronously so a one-off audit does not exit before its
+// events are written
+func (e *EventEmitter) record(ctx context.Context, event *corev1.Event) error {
+	result, err := e.correlator.EventCorrelate(event)
+	if err != nil {
+		return err
+	}
+	if result.Skip {
+		slog.DebugContext(ctx, "Event rate limited", "kind", event.InvolvedObject.Kind,
+			"namespace", event.InvolvedObject.Namespace, "name", event.InvolvedObject.Name)
+		return nil
+	}
+	event = result.Event
+	events := e.Client.CoreV1().Events(event.Namespace)
+
+	var written *corev1.Event
+	if event.Count > 1 {
+		written, err = events.Patch(ctx, event.Name, types.StrategicMergePatchType, result.Patch, metav1.PatchOptions{})
+	}
+	if event.Count <= 1 || apierrors.IsNotFound(err) {
+		event.ResourceVersion = ""
+		written, err = events.Create(ctx, event, metav1.CreateOptions{})
+	}
+	if err != nil {
+		return err
+	}
+	e.correlator.UpdateState(written)
+	return nil
+}
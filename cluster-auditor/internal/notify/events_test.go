@@ -0,0 +1,61 @@
+package notify_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"goprojects/cluster-auditor/internal/notify"
+	"goprojects/findings"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+	clocktesting "k8s.io/utils/clock/testing"
+)
+
+func TestEventEmitter(t *testing.T) {
+	ctx := context.Background()
+	deployment := &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "apps/v1", "kind": "Deployment",
+		"metadata": map[string]any{"name": "web", "namespace": "shop", "uid": "uid-web"},
+	}}
+	client := fake.NewSimpleClientset()
+	e := &notify.EventEmitter{
+		Client:  client,
+		Dynamic: dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), deployment),
+		Burst:   2,
+		Clock:   clocktesting.NewFakeClock(time.Unix(1700000000, 0)),
+	}
+	privileged := finding("web", findings.SeverityCritical)
+	privileged.Container = "app"
+	gone := finding("api", findings.SeverityHigh)
+	require.NoError(t, e.Emit(ctx, []findings.Finding{privileged, gone}))
+
+	events, err := client.CoreV1().Events("shop").List(ctx, metav1.ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, events.Items, 1)
+	event := events.Items[0]
+	require.Equal(t, corev1.EventTypeWarning, event.Type)
+	require.Equal(t, notify.EventReason, event.Reason)
+	require.Equal(t, corev1.ObjectReference{APIVersion: "apps/v1", Kind: "Deployment", Namespace: "shop", Name: "web", UID: "uid-web"}, event.InvolvedObject)
+	require.Equal(t, "[critical] privileged: Container is running with privileged mode enabled (container app). Disable privileged mode", event.Message)
+
+	// An identical event is counted on the existing one, and the object's burst
+	// is used up after that
+	require.NoError(t, e.Emit(ctx, []findings.Finding{privileged}))
+	other := privileged
+	other.Container = "sidecar"
+	require.NoError(t, e.Emit(ctx, []findings.Finding{other}))
+	events, err = client.CoreV1().Events("shop").List(ctx, metav1.ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, events.Items, 1)
+	require.EqualValues(t, 2, events.Items[0].Count)
+
+	var nilEmitter *notify.EventEmitter
+	require.NoError(t, nilEmitter.Emit(ctx, []findings.Finding{privileged}))
+}
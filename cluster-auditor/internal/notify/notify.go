@@ -0,0 +1,343 @@
+// Package notify alerts about new findings through webhooks, Slack-compatible
+// incoming webhooks, email and Kubernetes Events
+package notify
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+
+	"goprojects/findings"
+
+	"golang.org/x/time/rate"
+)
+
+// Notification is one message about new findings
+type Notification struct {
+	Cluster  string             `json:"cluster"`
+	Findings []findings.Finding `json:"findings"` // most severe first
+	Omitted  int                `json:"omitted"`  // new findings left out of the message
+}
+
+// Title describes the notification in one line, for subjects and chat messages
+func (n Notification) Title() string {
+	total := len(n.Findings) + n.Omitted
+	noun := "findings"
+	if total == 1 {
+		noun = "finding"
+	}
+	if n.Cluster == "" {
+		return fmt.Sprintf("%d new %s", total, noun)
+	}
+	return fmt.Sprintf("%d new %s in %s", total, noun, n.Cluster)
+}
+
+// Sink delivers notifications
+type Sink interface {
+	// Name identifies the sink in logs, and in dedup and rate limiting state
+	Name() string
+	// Send delivers a notification. Errors wrapped with Permanent are not retried.
+	Send(ctx context.Context, n Notification) error
+}
+
+type permanentError struct{ err error }
+
+func (e permanentError) Error() string { return e.err.Error() }
+func (e permanentError) Unwrap() error { return e.err }
+
+// Permanent marks an error that retrying will not fix, like a rejected request
+func Permanent(err error) error {
+	return permanentError{err}
+}
+
+func isPermanent(err error) bool {
+	var p permanentError
+	return errors.As(err, &p)
+}
+
+// Defaults of the Notifier settings
+const (
+	DefaultDedupWindow   = 24 * time.Hour
+	DefaultRateLimit     = 10 // messages per sink and hour
+	DefaultRetries       = 3
+	DefaultBackoff       = time.Second
+	DefaultMaxFindings   = 20
+	DefaultRetryInterval = 5 * time.Minute
+
+	NoRetries = -1 // Retries value disabling retries
+)
+
+// Notifier sends the new findings of audits to its sinks. Each sink is told about
+// a finding once per dedup window, is rate limited, and sends are retried with
+// exponential backoff. Findings that are rate limited or still fail after the
+// retries stay pending in memory and go out with a later flush; they are lost if
+// the process exits first. Zero settings take their defaults. A nil Notifier
+// sends nothing.
+type Notifier struct {
+	Sinks         []Sink
+	MinSeverity   findings.Severity // findings below it are not notified, default high
+	DedupWindow   time.Duration
+	RateLimit     int // messages per sink and hour
+	Retries       int // retries after the first attempt of a send, NoRetries for none
+	Backoff       time.Duration
+	MaxFindings   int           // findings listed per message, the others are counted
+	RetryInterval time.Duration // how often Run flushes pending findings
+
+	mu       sync.Mutex
+	notified map[string]time.Time // sink name and fingerprint
+	limiters map[string]*rate.Limiter
+	pending  map[string][]pendingFinding // by sink name
+	wake     chan struct{}
+}
+
+type pendingFinding struct {
+	cluster string
+	finding findings.Finding
+}
+
+func (n *Notifier) minSeverity() findings.Severity {
+	return cmp.Or(n.MinSeverity, findings.SeverityHigh)
+}
+
+// Notify queues the findings at or above the minimum severity that a sink was not
+// told about yet, and flushes. Callers pass findings as they open, like the ones
+// returned by ReplaceFindings. Sinks that fail do not stop the others; their
+// errors are joined.
+func (n *Notifier) Notify(ctx context.Context, cluster string, fs []findings.Finding) error {
+	if n == nil || len(n.Sinks) == 0 {
+		return nil
+	}
+	n.enqueue(cluster, fs)
+	return n.Flush(ctx)
+}
+
+// Enqueue queues findings like Notify, but leaves sending them to Run, so slow
+// sinks and retries do not block the caller
+func (n *Notifier) Enqueue(cluster string, fs []findings.Finding) {
+	if n == nil || len(n.Sinks) == 0 {
+		return
+	}
+	if n.enqueue(cluster, fs) {
+		select {
+		case n.wakeup() <- struct{}{}:
+		default: // a flush is due already
+		}
+	}
+}
+
+// Run flushes the findings queued by Enqueue as they come, and the pending ones
+// every RetryInterval, until ctx is done
+func (n *Notifier) Run(ctx context.Context) {
+	if n == nil || len(n.Sinks) == 0 {
+		return
+	}
+	ticker := time.NewTicker(cmp.Or(n.RetryInterval, DefaultRetryInterval))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-n.wakeup():
+		case <-ticker.C:
+		}
+		if err := n.Flush(ctx); err != nil {
+			slog.ErrorContext(ctx, "Failed to send notifications", "err", err)
+		}
+	}
+}
+
+// Pending returns the number of findings not sent to some sink yet
+func (n *Notifier) Pending() int {
+	if n == nil {
+		return 0
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	total := 0
+	for _, p := range n.pending {
+		total += len(p)
+	}
+	return total
+}
+
+func (n *Notifier) wakeup() chan struct{} {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.wake == nil {
+		n.wake = make(chan struct{}, 1)
+	}
+	return n.wake
+}
+
+// enqueue adds the findings to the pending ones of each sink, and reports
+// whether any were added
+func (n *Notifier) enqueue(cluster string, fs []findings.Finding) bool {
+	var candidates []pendingFinding
+	for _, f := range fs {
+		if f.Severity.Rank() >= n.minSeverity().Rank() {
+			if f.Fingerprint == "" {
+				f.Fingerprint = findings.ComputeFingerprint(f)
+			}
+			candidates = append(candidates, pendingFinding{cluster, f})
+		}
+	}
+	added := false
+	for _, sink := range n.Sinks {
+		fresh := n.unnotified(sink.Name(), candidates)
+		added = added || len(fresh) > 0
+		n.addPending(sink.Name(), fresh)
+	}
+	return added
+}
+
+// Flush sends the pending findings of every sink, a message per cluster. Findings
+// that are rate limited or fail to send stay pending, unless the sink rejected
+// them for good.
+func (n *Notifier) Flush(ctx context.Context) error {
+	if n == nil {
+		return nil
+	}
+	var errs []error
+	for _, sink := range n.Sinks {
+		if err := n.flushSink(ctx, sink); err != nil {
+			errs = append(errs, fmt.Errorf("notify %s: %w", sink.Name(), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (n *Notifier) flushSink(ctx context.Context, sink Sink) error {
+	n.mu.Lock()
+	pending := n.pending[sink.Name()]
+	delete(n.pending, sink.Name())
+	n.mu.Unlock()
+
+	var clusters []string
+	byCluster := map[string][]findings.Finding{}
+	for _, p := range pending {
+		if _, ok := byCluster[p.cluster]; !ok {
+			clusters = append(clusters, p.cluster)
+		}
+		byCluster[p.cluster] = append(byCluster[p.cluster], p.finding)
+	}
+
+	var errs []error
+	for _, cluster := range clusters {
+		fs := byCluster[cluster]
+		slices.SortStableFunc(fs, func(a, b findings.Finding) int { return b.Severity.Rank() - a.Severity.Rank() })
+		if !n.limiter(sink.Name()).Allow() {
+			slog.WarnContext(ctx, "Notification rate limited, sending it later", "sink", sink.Name(), "findings", len(fs))
+			n.addPending(sink.Name(), pendingOf(cluster, fs))
+			continue
+		}
+
+		msg := Notification{Cluster: cluster, Findings: fs}
+		if max := cmp.Or(n.MaxFindings, DefaultMaxFindings); len(fs) > max {
+			msg.Findings, msg.Omitted = fs[:max], len(fs)-max
+		}
+		if err := n.send(ctx, sink, msg); err != nil {
+			if !isPermanent(err) {
+				n.addPending(sink.Name(), pendingOf(cluster, fs))
+			}
+			errs = append(errs, err)
+			continue
+		}
+		n.markNotified(sink.Name(), fs)
+		slog.InfoContext(ctx, "Sent notification", "sink", sink.Name(), "findings", len(fs))
+	}
+	return errors.Join(errs...)
+}
+
+func pendingOf(cluster string, fs []findings.Finding) []pendingFinding {
+	out := make([]pendingFinding, len(fs))
+	for i, f := range fs {
+		out[i] = pendingFinding{cluster, f}
+	}
+	return out
+}
+
+// addPending queues findings for a sink, skipping the ones queued already
+func (n *Notifier) addPending(sink string, fs []pendingFinding) {
+	if len(fs) == 0 {
+		return
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.pending == nil {
+		n.pending = map[string][]pendingFinding{}
+	}
+	for _, p := range fs {
+		if !slices.ContainsFunc(n.pending[sink], func(q pendingFinding) bool { return q.finding.Fingerprint == p.finding.Fingerprint }) {
+			n.pending[sink] = append(n.pending[sink], p)
+		}
+	}
+}
+
+// send retries failed sends with exponential backoff
+func (n *Notifier) send(ctx context.Context, sink Sink, msg Notification) error {
+	backoff := cmp.Or(n.Backoff, DefaultBackoff)
+	retries := cmp.Or(n.Retries, DefaultRetries)
+	for attempt := 0; ; attempt++ {
+		err := sink.Send(ctx, msg)
+		if err == nil || isPermanent(err) || attempt >= retries {
+			return err
+		}
+		slog.WarnContext(ctx, "Notification failed, retrying", "sink", sink.Name(), "err", err, "attempt", attempt+1, "backoff", backoff)
+		select {
+		case <-ctx.Done():
+			return errors.Join(err, ctx.Err())
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+func (n *Notifier) unnotified(sink string, fs []pendingFinding) []pendingFinding {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	window, now := cmp.Or(n.DedupWindow, DefaultDedupWindow), time.Now()
+	var out []pendingFinding
+	for _, p := range fs {
+		if at, ok := n.notified[sink+"\x00"+p.finding.Fingerprint]; !ok || now.Sub(at) >= window {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+func (n *Notifier) markNotified(sink string, fs []findings.Finding) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.notified == nil {
+		n.notified = map[string]time.Time{}
+	}
+	now := time.Now()
+	for key, at := range n.notified {
+		if now.Sub(at) >= cmp.Or(n.DedupWindow, DefaultDedupWindow) {
+			delete(n.notified, key)
+		}
+	}
+	for _, f := range fs {
+		n.notified[sink+"\x00"+f.Fingerprint] = now
+	}
+}
+
+func (n *Notifier) limiter(sink string) *rate.Limiter {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.limiters == nil {
+		n.limiters = map[string]*rate.Limiter{}
+	}
+	l, ok := n.limiters[sink]
+	if !ok {
+		perHour := cmp.Or(n.RateLimit, DefaultRateLimit)
+		l = rate.NewLimiter(rate.Every(time.Hour/time.Duration(perHour)), perHour)
+		n.limiters[sink] = l
+	}
+	return l
+}
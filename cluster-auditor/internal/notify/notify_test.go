@@ -0,0 +1,299 @@
+package notify_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"goprojects/cluster-auditor/internal/notify"
+	"goprojects/findings"
+
+	"github.com/stretchr/testify/require"
+)
+
+func finding(resource string, severity findings.Severity) findings.Finding {
+	return findings.Finding{Namespace: "shop", Kind: "Deployment", Resource: resource, Severity: severity,
+		RuleID: "privileged", Issue: "Container is running with privileged mode enabled", Suggestion: "Disable privileged mode"}
+}
+
+// recorder records the requests of an httptest server, so tests assert on them
+// from the test goroutine once the calls returned
+type recorder struct {
+	mu       sync.Mutex
+	requests []recorded
+}
+
+type recorded struct {
+	body      []byte
+	signature string
+}
+
+// record records a request and returns how many were received
+func (rec *recorder) record(r *http.Request) int {
+	body, _ := io.ReadAll(r.Body)
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	rec.requests = append(rec.requests, recorded{body: body, signature: r.Header.Get(notify.SignatureHeader)})
+	return len(rec.requests)
+}
+
+func (rec *recorder) received() []recorded {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	return slices.Clone(rec.requests)
+}
+
+func TestWebhookDedupAndRetry(t *testing.T) {
+	var rec recorder
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if rec.record(r) == 1 {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+	last := func() notify.Notification {
+		requests := rec.received()
+		var got notify.Notification
+		require.NoError(t, json.Unmarshal(requests[len(requests)-1].body, &got))
+		return got
+	}
+
+	n := &notify.Notifier{Sinks: []notify.Sink{&notify.WebhookSink{URL: srv.URL, Secret: "s3cret"}}, Backoff: time.Millisecond}
+	fs := []findings.Finding{finding("web", findings.SeverityHigh), finding("api", findings.SeverityCritical), finding("cron", findings.SeverityLow)}
+	require.NoError(t, n.Notify(context.Background(), "prod", fs))
+	require.Len(t, rec.received(), 2)
+	got := last()
+	require.Equal(t, "prod", got.Cluster)
+	require.Len(t, got.Findings, 2)
+	require.Equal(t, "api", got.Findings[0].Resource)
+
+	// Findings already notified are not sent again, new ones are
+	require.NoError(t, n.Notify(context.Background(), "prod", fs))
+	require.Len(t, rec.received(), 2)
+	require.NoError(t, n.Notify(context.Background(), "prod", append(fs, finding("worker", findings.SeverityHigh))))
+	require.Len(t, rec.received(), 3)
+	require.Len(t, last().Findings, 1)
+	for _, req := range rec.received() {
+		require.Equal(t, notify.Sign("s3cret", req.body), req.signature)
+	}
+
+	// Rejected requests are not retried
+	var rejectedRec recorder
+	rejected := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rejectedRec.record(r)
+		http.Error(w, "bad request", http.StatusBadRequest)
+	}))
+	defer rejected.Close()
+	n = &notify.Notifier{Sinks: []notify.Sink{&notify.WebhookSink{URL: rejected.URL}}, Backoff: time.Millisecond}
+	require.ErrorContains(t, n.Notify(context.Background(), "prod", fs), "400 Bad Request")
+	require.Len(t, rejectedRec.received(), 1)
+}
+
+func TestNotifierRetriesPending(t *testing.T) {
+	var down atomic.Bool
+	var rec recorder
+	down.Store(true)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if down.Load() {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		rec.record(r)
+	}))
+	defer srv.Close()
+	sent := func() int {
+		count := 0
+		for _, req := range rec.received() {
+			var got notify.Notification
+			require.NoError(t, json.Unmarshal(req.body, &got))
+			count += len(got.Findings)
+		}
+		return count
+	}
+
+	n := &notify.Notifier{Sinks: []notify.Sink{&notify.WebhookSink{URL: srv.URL}}, Retries: 1, Backoff: time.Millisecond,
+		RetryInterval: 10 * time.Millisecond}
+	require.ErrorContains(t, n.Notify(context.Background(), "prod", []findings.Finding{finding("web", findings.SeverityHigh)}), "503")
+	require.Equal(t, 1, n.Pending())
+
+	// Run sends queued findings in the background, along with the pending ones
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go n.Run(ctx)
+	down.Store(false)
+	n.Enqueue("prod", []findings.Finding{finding("api", findings.SeverityHigh)})
+	require.Eventually(t, func() bool { return sent() == 2 }, 5*time.Second, 10*time.Millisecond)
+	require.Equal(t, 0, n.Pending())
+}
+
+func TestWebhookTemplate(t *testing.T) {
+	var rec recorder
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rec.record(r)
+	}))
+	defer srv.Close()
+
+	tmpl, err := notify.ParseWebhookTemplate("body", `{"summary": {{json .Title}}, "first": {{json (line (index .Findings 0))}}}`)
+	require.NoError(t, err)
+	sink := &notify.WebhookSink{URL: srv.URL, Template: tmpl}
+	require.NoError(t, sink.Send(context.Background(), notify.Notification{Cluster: "prod", Findings: []findings.Finding{finding("web", findings.SeverityHigh)}}))
+	requests := rec.received()
+	require.Len(t, requests, 1)
+	var body map[string]any
+	require.NoError(t, json.Unmarshal(requests[0].body, &body))
+	require.Equal(t, map[string]any{
+		"summary": "1 new finding in prod",
+		"first":   "[high] Deployment shop/web: Container is running with privileged mode enabled",
+	}, body)
+}
+
+func TestSlackRateLimit(t *testing.T) {
+	var rec recorder
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rec.record(r)
+	}))
+	defer srv.Close()
+
+	n := &notify.Notifier{Sinks: []notify.Sink{&notify.SlackSink{URL: srv.URL, Channel: "#alerts"}}, RateLimit: 1}
+	require.NoError(t, n.Notify(context.Background(), "prod", []findings.Finding{finding("web", findings.SeverityCritical)}))
+	require.NoError(t, n.Notify(context.Background(), "prod", []findings.Finding{finding("api", findings.SeverityCritical)}))
+	requests := rec.received()
+	require.Len(t, requests, 1)
+	require.Equal(t, 1, n.Pending(), "the rate limited finding is kept for later")
+	var msg map[string]any
+	require.NoError(t, json.Unmarshal(requests[0].body, &msg))
+	require.Equal(t, "#alerts", msg["channel"])
+	require.Equal(t, ":rotating_light: 1 new finding in prod", msg["text"])
+	require.Equal(t, []any{map[string]any{
+		"color":    "#b71c1c",
+		"title":    "[critical] Deployment shop/web: Container is running with privileged mode enabled",
+		"text":     "Disable privileged mode",
+		"fallback": "[critical] Deployment shop/web: Container is running with privileged mode enabled",
+	}}, msg["attachments"])
+}
+
+func TestSMTP(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+	received := make(chan string, 1)
+	go serveSMTP(l, received)
+
+	sink := &notify.SMTPSink{Addr: l.Addr().String(), From: "auditor@example.com", To: []string{"platform@example.com"}}
+	require.NoError(t, sink.Send(context.Background(), notify.Notification{Cluster: "prod", Findings: []findings.Finding{finding("web", findings.SeverityHigh)}, Omitted: 2}))
+	msg := <-received
+	require.Contains(t, msg, "Subject: [cluster-auditor] 3 new findings in prod\n")
+	require.Contains(t, msg, "[high] Deployment shop/web: Container is running with privileged mode enabled\n    Disable privileged mode\n")
+	require.Contains(t, msg, "and 2 more.")
+}
+
+func TestSMTPHungServer(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+	go func() {
+		conn, err := l.Accept()
+		if err == nil {
+			defer conn.Close()
+			time.Sleep(time.Minute) // never greets
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	sink := &notify.SMTPSink{Addr: l.Addr().String(), From: "auditor@example.com", To: []string{"platform@example.com"}}
+	start := time.Now()
+	require.Error(t, sink.Send(ctx, notify.Notification{Cluster: "prod", Findings: []findings.Finding{finding("web", findings.SeverityHigh)}}))
+	require.Less(t, time.Since(start), 5*time.Second)
+}
+
+// serveSMTP accepts one message, with just enough SMTP for SMTPSink.
+// The received message has LF line endings.
+func serveSMTP(l net.Listener, received chan<- string) {
+	conn, err := l.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	tp := textproto.NewConn(conn)
+	tp.PrintfLine("220 localhost ready")
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+		switch strings.ToUpper(strings.Fields(line)[0]) {
+		case "EHLO", "HELO":
+			tp.PrintfLine("250 localhost")
+		case "DATA":
+			tp.PrintfLine("354 go ahead")
+			data, _ := io.ReadAll(tp.DotReader())
+			received <- string(data)
+			tp.PrintfLine("250 queued")
+		case "QUIT":
+			tp.PrintfLine("221 bye")
+			return
+		default:
+			tp.PrintfLine("250 ok")
+		}
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("TEST_WEBHOOK_SECRET", "s3cret")
+	config := filepath.Join(dir, "notify.yaml")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "body.tmpl"), []byte(`{"text": {{json .Title}}}`), 0o644))
+	require.NoError(t, os.WriteFile(config, []byte(`
+minSeverity: medium
+dedupWindow: 1h
+webhooks:
+  - url: https://hooks.example.com/audit
+    secret: ${TEST_WEBHOOK_SECRET}
+    template: body.tmpl
+smtp:
+  - addr: localhost:25
+    from: auditor@example.com
+    to: [platform@example.com]
+    username: auditor
+    password: pa$$word
+`), 0o644))
+
+	n, err := notify.LoadConfig(config)
+	require.NoError(t, err)
+	require.Equal(t, findings.SeverityMedium, n.MinSeverity)
+	require.Equal(t, time.Hour, n.DedupWindow)
+	require.Len(t, n.Sinks, 2)
+	webhook := n.Sinks[0].(*notify.WebhookSink)
+	require.Equal(t, "s3cret", webhook.Secret)
+	require.NotNil(t, webhook.Template)
+	require.Equal(t, "pa$$word", n.Sinks[1].(*notify.SMTPSink).Password)
+
+	require.NoError(t, os.WriteFile(config, []byte("minSeverity: high\n"), 0o644))
+	_, err = notify.LoadConfig(config)
+	require.ErrorContains(t, err, "no sinks configured")
+
+	// Zero retries disables them, settings that cannot be zero or negative are rejected
+	sinks := "webhooks:\n  - url: https://hooks.example.com/audit\n"
+	require.NoError(t, os.WriteFile(config, []byte("retries: 0\n"+sinks), 0o644))
+	n, err = notify.LoadConfig(config)
+	require.NoError(t, err)
+	require.Equal(t, notify.NoRetries, n.Retries)
+	for _, setting := range []string{"retries: -1", "rateLimit: 0", "rateLimit: -5", "maxFindings: 0"} {
+		require.NoError(t, os.WriteFile(config, []byte(setting+"\n"+sinks), 0o644))
+		_, err = notify.LoadConfig(config)
+		require.ErrorContains(t, err, "invalid", setting)
+	}
+}
@@ -0,0 +1,304 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/smtp"
+	"net/textproto"
+	"net/url"
+	"strings"
+	"text/template"
+	"time"
+
+	"goprojects/cluster-auditor/internal/audit"
+	"goprojects/findings"
+)
+
+// SignatureHeader carries the HMAC-SHA256 of a webhook body, as sha256=<hex>
+const SignatureHeader = "X-Auditor-Signature"
+
+const (
+	httpTimeout = 10 * time.Second
+	smtpTimeout = 30 * time.Second
+)
+
+// findingLine describes a finding in one line, e.g.
+// "[high] Deployment shop/web: Container is running with privileged mode enabled"
+func findingLine(f findings.Finding) string {
+	resource := f.Kind + " " + f.Resource
+	if f.Namespace != "" {
+		resource = f.Kind + " " + f.Namespace + "/" + f.Resource
+	}
+	if f.Container != "" {
+		resource += " (" + f.Container + ")"
+	}
+	return fmt.Sprintf("[%s] %s: %s", f.Severity, resource, f.Issue)
+}
+
+// postJSON posts a JSON body. Rejected requests are permanent errors, except
+// for rate limiting and timeouts which are worth retrying.
+func postJSON(ctx context.Context, client *http.Client, target string, body []byte, headers http.Header) error {
+	if client == nil {
+		client = &http.Client{Timeout: httpTimeout}
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return Permanent(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, vs := range headers {
+		req.Header[k] = vs
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		// Leaves the URL, which may hold a token, out of logged errors
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			return urlErr.Err
+		}
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 300 {
+		return nil
+	}
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	err = fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	if resp.StatusCode < 500 && resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode != http.StatusRequestTimeout {
+		return Permanent(err)
+	}
+	return err
+}
+
+// WebhookSink posts notifications as JSON to a URL. The body is the Notification
+// unless Template is set, and is signed in SignatureHeader if Secret is set.
+type WebhookSink struct {
+	URL     string
+	Secret  string
+	Headers map[string]string
+	// Template renders the body from the Notification; the json function
+	// marshals any value
+	Template *template.Template
+	Client   *http.Client
+}
+
+// ParseWebhookTemplate parses a text/template for WebhookSink.Template
+func ParseWebhookTemplate(name, text string) (*template.Template, error) {
+	return template.New(name).Funcs(template.FuncMap{
+		"json": func(v any) (string, error) {
+			b, err := json.Marshal(v)
+			return string(b), err
+		},
+		"line":          findingLine,
+		"severityColor": audit.SeverityColor,
+	}).Parse(text)
+}
+
+func (s *WebhookSink) Name() string { return "webhook " + redactURL(s.URL) }
+
+// redactURL identifies a webhook URL in logs without its path or query, which
+// often hold a token, by its host and a short hash of the whole URL
+func redactURL(rawURL string) string {
+	sum := sha256.Sum256([]byte(rawURL))
+	host := rawURL
+	if u, err := url.Parse(rawURL); err == nil && u.Host != "" {
+		host = u.Host
+	}
+	return host + "#" + hex.EncodeToString(sum[:4])
+}
+
+// Sign returns the signature of a body in the format of SignatureHeader
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func (s *WebhookSink) Send(ctx context.Context, n Notification) error {
+	var body []byte
+	if s.Template == nil {
+		var err error
+		if body, err = json.Marshal(n); err != nil {
+			return Permanent(err)
+		}
+	} else {
+		var buf bytes.Buffer
+		if err := s.Template.Execute(&buf, n); err != nil {
+			return Permanent(fmt.Errorf("failed to render body: %w", err))
+		}
+		if !json.Valid(buf.Bytes()) {
+			return Permanent(errors.New("body template did not render valid JSON"))
+		}
+		body = buf.Bytes()
+	}
+
+	headers := http.Header{}
+	for k, v := range s.Headers {
+		headers.Set(k, v)
+	}
+	if s.Secret != "" {
+		headers.Set(SignatureHeader, Sign(s.Secret, body))
+	}
+	return postJSON(ctx, s.Client, s.URL, body, headers)
+}
+
+// SlackSink posts notifications to a Slack incoming webhook, or a compatible one
+// like Mattermost's, with an attachment per finding colored by severity
+type SlackSink struct {
+	URL      string
+	Channel  string // optional, overrides the webhook's channel where supported
+	Username string // optional
+	Client   *http.Client
+}
+
+func (s *SlackSink) Name() string {
+	if s.Channel != "" {
+		return "slack " + s.Channel + " " + redactURL(s.URL)
+	}
+	return "slack " + redactURL(s.URL)
+}
+
+type slackAttachment struct {
+	Color    string `json:"color"`
+	Title    string `json:"title"`
+	Text     string `json:"text,omitempty"`
+	Fallback string `json:"fallback"`
+}
+
+type slackMessage struct {
+	Text        string            `json:"text"`
+	Channel     string            `json:"channel,omitempty"`
+	Username    string            `json:"username,omitempty"`
+	Attachments []slackAttachment `json:"attachments"`
+}
+
+func (s *SlackSink) Send(ctx context.Context, n Notification) error {
+	msg := slackMessage{Text: ":rotating_light: " + n.Title(), Channel: s.Channel, Username: s.Username}
+	for _, f := range n.Findings {
+		line := findingLine(f)
+		msg.Attachments = append(msg.Attachments, slackAttachment{
+			Color: audit.SeverityColor(f.Severity), Title: line, Text: f.Suggestion, Fallback: line,
+		})
+	}
+	if n.Omitted > 0 {
+		msg.Attachments = append(msg.Attachments, slackAttachment{Title: fmt.Sprintf("and %d more", n.Omitted), Fallback: fmt.Sprintf("and %d more", n.Omitted)})
+	}
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return Permanent(err)
+	}
+	return postJSON(ctx, s.Client, s.URL, body, nil)
+}
+
+// SMTPSink emails notifications as plain text. It authenticates with PLAIN if a
+// username is set, which net/smtp only allows over TLS or to localhost, and
+// upgrades to TLS with STARTTLS when the server offers it.
+type SMTPSink struct {
+	Addr     string // host:port
+	From     string
+	To       []string
+	Username string
+	Password string
+}
+
+func (s *SMTPSink) Name() string { return "smtp " + strings.Join(s.To, ",") }
+
+func (s *SMTPSink) message(n Notification) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", s.From)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(s.To, ", "))
+	fmt.Fprintf(&b, "Subject: [cluster-auditor] %s\r\n", n.Title())
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\nContent-Type: text/plain; charset=utf-8\r\n\r\n")
+	fmt.Fprintf(&b, "%s:\r\n\r\n", n.Title())
+	for _, f := range n.Findings {
+		fmt.Fprintf(&b, "%s\r\n", findingLine(f))
+		if f.Suggestion != "" {
+			fmt.Fprintf(&b, "    %s\r\n", f.Suggestion)
+		}
+	}
+	if n.Omitted > 0 {
+		fmt.Fprintf(&b, "\r\nand %d more.\r\n", n.Omitted)
+	}
+	return []byte(b.String())
+}
+
+func (s *SMTPSink) Send(ctx context.Context, n Notification) error {
+	host, _, err := net.SplitHostPort(s.Addr)
+	if err != nil {
+		return Permanent(err)
+	}
+	err = s.send(ctx, host, s.message(n))
+
+	// 5xx replies reject the message for good, 4xx ones are transient
+	var reply *textproto.Error
+	if errors.As(err, &reply) && reply.Code >= 500 {
+		return Permanent(err)
+	}
+	return err
+}
+
+// send does what smtp.SendMail does, over a connection that is closed when the
+// context is done or smtpTimeout passes, so a hung server cannot block the audit
+func (s *SMTPSink) send(ctx context.Context, host string, msg []byte) error {
+	dialer := &net.Dialer{Timeout: smtpTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", s.Addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	deadline := time.Now().Add(smtpTimeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	if err := conn.SetDeadline(deadline); err != nil {
+		return err
+	}
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return err
+		}
+	}
+	if s.Username != "" {
+		if err := c.Auth(smtp.PlainAuth("", s.Username, s.Password, host)); err != nil {
+			return err
+		}
+	}
+	if err := c.Mail(s.From); err != nil {
+		return err
+	}
+	for _, to := range s.To {
+		if err := c.Rcpt(to); err != nil {
+			return err
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
@@ -0,0 +1,180 @@
+// Package webhook serves the audit checks as a ValidatingAdmissionWebhook
+package webhook
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"goprojects/cluster-auditor/internal/audit"
+	"goprojects/cluster-auditor/internal/logging"
+	"goprojects/findings"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/scheme"
+)
+
+// Mode decides what happens to an admission request that triggers a rule
+type Mode string
+
+const (
+	ModeDeny   Mode = "deny"   // reject the request
+	ModeWarn   Mode = "warn"   // admit the request and return a warning to the client
+	ModeIgnore Mode = "ignore" // admit the request silently
+)
+
+// ParseMode validates an enforcement mode given on the command line
+func ParseMode(s string) (Mode, error) {
+	switch m := Mode(strings.ToLower(s)); m {
+	case ModeDeny, ModeWarn, ModeIgnore:
+		return m, nil
+	}
+	return "", fmt.Errorf("invalid enforcement mode %q (want deny, warn or ignore)", s)
+}
+
+type Config struct {
+	DefaultMode Mode
+	Rules       map[string]Mode // per rule ID overrides of DefaultMode
+	DryRun      bool            // never deny, only report what would have been denied
+}
+
+func (c Config) modeFor(ruleID string) Mode {
+	if m, ok := c.Rules[ruleID]; ok {
+		return m
+	}
+	if c.DefaultMode == "" {
+		return ModeWarn
+	}
+	return c.DefaultMode
+}
+
+// Handler answers AdmissionReview requests by running the registered checks
+// against the submitted object
+type Handler struct {
+	Config Config
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, 3<<20))
+	if err != nil {
+		http.Error(w, "failed to read request body", http.StatusBadRequest)
+		return
+	}
+
+	var review admissionv1.AdmissionReview
+	if err := json.Unmarshal(body, &review); err != nil {
+		http.Error(w, fmt.Sprintf("invalid AdmissionReview: %v", err), http.StatusBadRequest)
+		return
+	}
+	if review.Request == nil {
+		http.Error(w, "AdmissionReview has no request", http.StatusBadRequest)
+		return
+	}
+
+	review.Response = h.Review(r.Context(), review.Request)
+	review.Response.UID = review.Request.UID
+	review.Request = nil
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(review); err != nil {
+		slog.ErrorContext(r.Context(), "webhook: failed to write response", "err", err)
+	}
+}
+
+// Review evaluates a single admission request
+func (h *Handler) Review(ctx context.Context, req *admissionv1.AdmissionRequest) *admissionv1.AdmissionResponse {
+	ctx = logging.With(ctx, "uid", string(req.UID), "kind", req.Kind.Kind, "namespace", req.Namespace, "name", req.Name)
+	resp := &admissionv1.AdmissionResponse{Allowed: true}
+	if req.Operation != admissionv1.Create && req.Operation != admissionv1.Update {
+		return resp
+	}
+
+	obj, err := decodeObject(req)
+	if err != nil {
+		// Objects we cannot audit are admitted, the webhook should never block unknown kinds
+		slog.DebugContext(ctx, "webhook: skipping object", "err", err)
+		return resp
+	}
+
+	a, errs := audit.EvaluateObjects(ctx, req.Namespace, obj)
+	if a == nil {
+		// The object could not be evaluated at all, so it is admitted like objects we cannot decode
+		slog.ErrorContext(ctx, "webhook: failed to evaluate object", "err", errors.Join(errs...))
+		return resp
+	}
+	for _, err := range errs {
+		slog.WarnContext(ctx, "webhook: check failed", "err", err)
+	}
+
+	var denied []string
+	for _, f := range a.Findings {
+		msg := formatFinding(f)
+		switch h.Config.modeFor(f.RuleID) {
+		case ModeDeny:
+			if h.Config.DryRun {
+				slog.InfoContext(ctx, "webhook: dry-run, would deny", "finding", msg)
+				resp.Warnings = append(resp.Warnings, "[dry-run] "+msg)
+			} else {
+				denied = append(denied, msg)
+			}
+		case ModeWarn:
+			resp.Warnings = append(resp.Warnings, msg)
+		}
+	}
+
+	if len(denied) > 0 {
+		resp.Allowed = false
+		resp.Result = &metav1.Status{
+			Status:  metav1.StatusFailure,
+			Code:    http.StatusForbidden,
+			Reason:  metav1.StatusReasonForbidden,
+			Message: "cluster-auditor denied the request: " + strings.Join(denied, "; "),
+		}
+	}
+	return resp
+}
+
+func decodeObject(req *admissionv1.AdmissionRequest) (runtime.Object, error) {
+	if len(req.Object.Raw) == 0 {
+		return nil, fmt.Errorf("request has no object")
+	}
+	gvk := schema.GroupVersionKind{Group: req.Kind.Group, Version: req.Kind.Version, Kind: req.Kind.Kind}
+	obj, _, err := scheme.Codecs.UniversalDeserializer().Decode(req.Object.Raw, &gvk, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode object: %w", err)
+	}
+	return obj, nil
+}
+
+func formatFinding(f findings.Finding) string {
+	target := f.Kind + " " + f.Resource
+	if f.Container != "" {
+		target += " container " + f.Container
+	}
+	return fmt.Sprintf("%s: %s (%s). %s", f.RuleID, f.Issue, target, f.Suggestion)
+}
+
+// LoadTLSConfig loads the serving certificate mounted for the webhook
+func LoadTLSConfig(certFile, keyFile string) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load webhook certificate: %w", err)
+	}
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}, nil
+}
@@ -0,0 +1,89 @@
+package webhook_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	admissionv1 "k8s.io/api/admission/v1"
+
+	"goprojects/cluster-auditor/internal/webhook"
+)
+
+func review(t *testing.T, cfg webhook.Config, fixture string) *admissionv1.AdmissionResponse {
+	t.Helper()
+
+	body, err := os.ReadFile(filepath.Join("testdata", fixture))
+	require.NoError(t, err)
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/validate", bytes.NewReader(body))
+	(&webhook.Handler{Config: cfg}).ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var out admissionv1.AdmissionReview
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &out))
+	require.NotNil(t, out.Response)
+	return out.Response
+}
+
+func hasWarning(warnings []string, substr string) bool {
+	for _, w := range warnings {
+		if strings.Contains(w, substr) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestDeploymentWarnsByDefault(t *testing.T) {
+	resp := review(t, webhook.Config{}, "deployment-latest.json")
+
+	require.True(t, resp.Allowed)
+	require.Equal(t, "705ab4f5-6393-11e8-b7cc-42010a800002", string(resp.UID))
+	require.True(t, hasWarning(resp.Warnings, "latest-image-tag"), "warnings: %v", resp.Warnings)
+	require.False(t, hasWarning(resp.Warnings, "missing-resource-limits"), "limits are set in the fixture")
+}
+
+func TestDeploymentDeniedByRuleMode(t *testing.T) {
+	resp := review(t, webhook.Config{
+		DefaultMode: webhook.ModeIgnore,
+		Rules:       map[string]webhook.Mode{"latest-image-tag": webhook.ModeDeny},
+	}, "deployment-latest.json")
+
+	require.False(t, resp.Allowed)
+	require.NotNil(t, resp.Result)
+	require.EqualValues(t, http.StatusForbidden, resp.Result.Code)
+	require.Contains(t, resp.Result.Message, "latest-image-tag")
+	require.Empty(t, resp.Warnings)
+}
+
+func TestDryRunNeverDenies(t *testing.T) {
+	resp := review(t, webhook.Config{DefaultMode: webhook.ModeDeny, DryRun: true}, "role-wildcard.json")
+
+	require.True(t, resp.Allowed)
+	require.Nil(t, resp.Result)
+	require.True(t, hasWarning(resp.Warnings, "[dry-run] risky-rbac"), "warnings: %v", resp.Warnings)
+}
+
+func TestDeleteIsAlwaysAllowed(t *testing.T) {
+	resp := review(t, webhook.Config{DefaultMode: webhook.ModeDeny}, "pod-delete.json")
+
+	require.True(t, resp.Allowed)
+	require.Empty(t, resp.Warnings)
+}
+
+func TestParseMode(t *testing.T) {
+	m, err := webhook.ParseMode("Deny")
+	require.NoError(t, err)
+	require.Equal(t, webhook.ModeDeny, m)
+
+	_, err = webhook.ParseMode("block")
+	require.Error(t, err)
+}
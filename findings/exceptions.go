@@ -1,24 +1,87 @@
 package findings
 
-// Contains logic for excluding certain namespaces, resources, etc.
-// from the audit findings
-var excludedNamespaces = map[string]bool{
-	"kube-system":        true,
-	"local-path-storage": true,
-	"istio-system":       true,
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+// Exception suppresses the findings it matches, e.g. an accepted risk. Empty
+// matcher fields match anything, but at least one of them must be set.
+type Exception struct {
+	ID        int64
+	Namespace string
+	Kind      string
+	Resource  string
+	RuleID    string
+	Reason    string
+	Owner     string    // team or person accountable for the exception
+	ExpiresAt time.Time // zero never expires
+	CreatedAt time.Time
+	CreatedBy string
+}
+
+// DefaultExceptions suppress the findings of system components. They are stored
+// once in every database, and can be deleted like any other exception.
+var DefaultExceptions = []Exception{
+	{Namespace: "kube-system", Reason: "Kubernetes system namespace", Owner: "cluster-admins"},
+	{Namespace: "local-path-storage", Reason: "local-path-provisioner system namespace", Owner: "cluster-admins"},
+	{Namespace: "istio-system", Reason: "Istio system namespace", Owner: "cluster-admins"},
+	{Resource: "local-path-provisioner", Reason: "Storage provisioner system component", Owner: "cluster-admins"},
+}
+
+func (e Exception) Validate() error {
+	if e.Namespace == "" && e.Kind == "" && e.Resource == "" && e.RuleID == "" {
+		return errors.New("exception must match on at least one of namespace, kind, resource or rule")
+	}
+	if strings.TrimSpace(e.Reason) == "" {
+		return errors.New("exception reason is required")
+	}
+	if strings.TrimSpace(e.Owner) == "" {
+		return errors.New("exception owner is required")
+	}
+	return nil
+}
+
+// Active reports whether the exception is still in effect at the given time
+func (e Exception) Active(now time.Time) bool {
+	return e.ExpiresAt.IsZero() || now.Before(e.ExpiresAt)
 }
 
-var excludedResources = map[string]bool{
-	"local-path-provisioner": true,
+func (e Exception) Matches(f Finding) bool {
+	return (e.Namespace == "" || e.Namespace == f.Namespace) &&
+		(e.Kind == "" || e.Kind == f.Kind) &&
+		(e.Resource == "" || e.Resource == f.Resource) &&
+		(e.RuleID == "" || e.RuleID == f.RuleID)
 }
 
-func IsExcluded(namespace, resource string) bool {
-	if excludedNamespaces[namespace] {
-		return true
+// ApplyExceptions returns the findings not matched by any exception active at now
+func ApplyExceptions(fs []Finding, exceptions []Exception, now time.Time) []Finding {
+	var active []Exception
+	for _, e := range exceptions {
+		if e.Active(now) {
+			active = append(active, e)
+		}
 	}
-	if excludedResources[resource] {
-		return true
+	if len(active) == 0 {
+		return fs
 	}
 
-	return false
+	out := make([]Finding, 0, len(fs))
+	for _, f := range fs {
+		if _, excepted := MatchException(f, active, now); !excepted {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+// MatchException returns the first exception active at now that matches f
+func MatchException(f Finding, exceptions []Exception, now time.Time) (Exception, bool) {
+	for _, e := range exceptions {
+		if e.Active(now) && e.Matches(f) {
+			return e, true
+		}
+	}
+	return Exception{}, false
 }
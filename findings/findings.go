@@ -1,24 +1,96 @@
 package findings
 
+import (
+	"cmp"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"time"
+)
+
 // Package findings provides structures and methods for managing audit findings
 
-// Will add context and severity field at some point
+// Status of a stored finding
+type Status string
+
+const (
+	StatusOpen     Status = "open"
+	StatusResolved Status = "resolved"
+)
+
 type Finding struct {
-	Namespace  string
-	Resource   string
-	Kind       string
-	Container  string
-	Issue      string
+	Namespace string
+	Resource  string
+	Kind      string
+	Container string
+	Issue     string
+	// Key tells apart the findings of one rule about the same resource when Issue
+	// also carries details that change, like the subjects bound to a role. It is
+	// hashed into the fingerprint instead of Issue.
+	Key        string
 	Suggestion string
 	Subjects   []string // optional, e.g. for RBAC findings
+	RuleID     string   // ID of the check that produced the finding
+	Severity   Severity
+	Cluster    string
+	Team       string // owning team of the namespace, empty if unowned
+	// Fingerprint identifies the same finding across runs, see ComputeFingerprint
+	Fingerprint string
+	// Lifecycle fields, maintained by the findings store
+	FirstSeen time.Time
+	LastSeen  time.Time
+	Status    Status
+}
+
+// ComputeFingerprint hashes the fields that identify a finding: the rule, the
+// resource and container, and Key, or Issue for findings without one. Everything
+// that may change while the problem persists, like the suggestion or bound
+// subjects, is left out.
+func ComputeFingerprint(f Finding) string {
+	parts := []string{f.Cluster, f.Namespace, f.Kind, f.Resource, f.Container, f.RuleID, cmp.Or(f.Key, f.Issue)}
+	sum := sha256.Sum256([]byte(strings.Join(parts, "\x00")))
+	return hex.EncodeToString(sum[:16])
+}
+
+// ResourceRef identifies a Kubernetes resource
+type ResourceRef struct {
+	Kind      string
+	Namespace string // empty for cluster-scoped resources
+	Name      string
 }
 
 type Auditor struct {
 	Findings []Finding
+	Cluster  string // stamped on every finding added
+	// RuleID and Severity are stamped on every finding added while RuleID is set,
+	// along with the fingerprint. RunCheck sets them to the check it runs.
+	RuleID   string
+	Severity Severity
+	// OnFinding, if not nil, is called with every finding added, so findings can
+	// be streamed while the checks run
+	OnFinding func(Finding)
+	// Audited lists the resources checks looked at, whether or not they had
+	// findings, so reports can show what passed
+	Audited []ResourceRef
+}
+
+// MarkAudited records that a check looked at a resource
+func (a *Auditor) MarkAudited(kind, namespace, name string) {
+	a.Audited = append(a.Audited, ResourceRef{Kind: kind, Namespace: namespace, Name: name})
 }
 
 func (a *Auditor) AddFinding(f Finding) {
+	if f.Cluster == "" {
+		f.Cluster = a.Cluster
+	}
+	if a.RuleID != "" {
+		f.RuleID, f.Severity = a.RuleID, a.Severity
+		f.Fingerprint = ComputeFingerprint(f)
+	}
 	a.Findings = append(a.Findings, f)
+	if a.OnFinding != nil {
+		a.OnFinding(f)
+	}
 }
 
 func NewAuditor() *Auditor {
@@ -26,11 +98,3 @@ func NewAuditor() *Auditor {
 		Findings: []Finding{},
 	}
 }
-
-func (a *Auditor) AddFindingWithFilter(f Finding) {
-	if IsExcluded(f.Namespace, f.Resource) {
-		return // skip excluded finding
-	}
-	a.AddFinding(f)
-
-}
@@ -0,0 +1,41 @@
+package findings
+
+import "math"
+
+// severityWeights is the score penalty of one open finding per severity
+var severityWeights = map[Severity]float64{
+	SeverityCritical: 10,
+	SeverityHigh:     5,
+	SeverityMedium:   2,
+	SeverityLow:      1,
+	SeverityInfo:     0,
+}
+
+// HealthScore rates a set of open findings from 0 to 100. Every finding adds
+// its severity's weight to a penalty, and the score decays exponentially with
+// it, so it never goes negative and the first findings weigh the most.
+// Findings without a severity count as medium.
+func HealthScore(fs []Finding) (float64, string) {
+	penalty := 0.0
+	for _, f := range fs {
+		w, ok := severityWeights[f.Severity]
+		if !ok {
+			w = severityWeights[SeverityMedium]
+		}
+		penalty += w
+	}
+	score := 100 * math.Exp(-penalty/100)
+	return math.Round(score*10) / 10, HealthStatus(score)
+}
+
+// HealthStatus describes a health score
+func HealthStatus(score float64) string {
+	switch {
+	case score >= 80:
+		return "Healthy"
+	case score >= 50:
+		return "Degraded"
+	default:
+		return "Critical"
+	}
+}
@@ -0,0 +1,260 @@
+// Package client connects to a running auditor server and wraps the generated
+// ClusterAuditor client with typed helpers
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"goprojects/findings"
+	"goprojects/services/convert"
+	"goprojects/services/generated/auditorpb"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// Options configure the connection to the server
+type Options struct {
+	Address string // host:port of the gRPC server
+
+	// TLS is enabled when TLS is set or any of the files below is given
+	TLS                bool
+	CAFile             string // CA bundle verifying the server, defaults to the system pool
+	CertFile           string // client certificate for mutual TLS
+	KeyFile            string
+	ServerName         string // overrides the name verified in the server certificate
+	InsecureSkipVerify bool
+
+	Token   string        // bearer token sent with every call
+	Timeout time.Duration // per call deadline for unary calls, 0 for none
+	Retries int           // extra attempts of calls failing with Unavailable
+}
+
+// Client is a connection to the ClusterAuditor service. It is safe for
+// concurrent use.
+type Client struct {
+	conn    *grpc.ClientConn
+	rpc     auditorpb.ClusterAuditorClient
+	timeout time.Duration
+}
+
+// tokenCredentials sends the bearer token as call metadata
+type tokenCredentials struct {
+	token  string
+	secure bool
+}
+
+func (t tokenCredentials) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	return map[string]string{"authorization": "Bearer " + t.token}, nil
+}
+
+// RequireTransportSecurity is false for plaintext connections, so tokens can be
+// used with servers behind a TLS terminating proxy or on localhost
+func (t tokenCredentials) RequireTransportSecurity() bool {
+	return t.secure
+}
+
+func (o Options) tlsEnabled() bool {
+	return o.TLS || o.CAFile != "" || o.CertFile != "" || o.InsecureSkipVerify
+}
+
+func (o Options) tlsConfig() (*tls.Config, error) {
+	cfg := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         o.ServerName,
+		InsecureSkipVerify: o.InsecureSkipVerify,
+	}
+	if o.CAFile != "" {
+		pem, err := os.ReadFile(o.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", o.CAFile)
+		}
+		cfg.RootCAs = pool
+	}
+	if o.CertFile != "" || o.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(o.CertFile, o.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	return cfg, nil
+}
+
+// retryServiceConfig retries calls that failed before reaching the server
+func retryServiceConfig(retries int) string {
+	return fmt.Sprintf(`{"methodConfig": [{
+		"name": [{"service": %q}],
+		"retryPolicy": {
+			"maxAttempts": %d,
+			"initialBackoff": "0.2s",
+			"maxBackoff": "5s",
+			"backoffMultiplier": 2,
+			"retryableStatusCodes": ["UNAVAILABLE"]
+		}
+	}]}`, auditorpb.ClusterAuditor_ServiceDesc.ServiceName, retries+1)
+}
+
+// New creates a client. The connection is established lazily on the first call.
+func New(opts Options) (*Client, error) {
+	if opts.Address == "" {
+		return nil, errors.New("server address is required")
+	}
+
+	dialOpts := []grpc.DialOption{}
+	if opts.tlsEnabled() {
+		cfg, err := opts.tlsConfig()
+		if err != nil {
+			return nil, err
+		}
+		dialOpts = append(dialOpts, grpc.WithTransportCredentials(credentials.NewTLS(cfg)))
+	} else {
+		dialOpts = append(dialOpts, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	}
+	if opts.Token != "" {
+		dialOpts = append(dialOpts, grpc.WithPerRPCCredentials(tokenCredentials{token: opts.Token, secure: opts.tlsEnabled()}))
+	}
+	if opts.Retries > 0 {
+		// gRPC caps attempts at 5
+		dialOpts = append(dialOpts, grpc.WithDefaultServiceConfig(retryServiceConfig(min(opts.Retries, 4))))
+	}
+
+	conn, err := grpc.NewClient(opts.Address, dialOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create client for %s: %w", opts.Address, err)
+	}
+	return &Client{conn: conn, rpc: auditorpb.NewClusterAuditorClient(conn), timeout: opts.Timeout}, nil
+}
+
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// RPC returns the generated client, for calls without a helper
+func (c *Client) RPC() auditorpb.ClusterAuditorClient {
+	return c.rpc
+}
+
+func (c *Client) callContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if c.timeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, c.timeout)
+}
+
+func (c *Client) HealthScore(ctx context.Context) (*auditorpb.HealthScore, error) {
+	ctx, cancel := c.callContext(ctx)
+	defer cancel()
+	return c.rpc.GetHealthScore(ctx, &auditorpb.Empty{})
+}
+
+// TeamScores returns the health score of the team, or of every team with open
+// findings when team is empty
+func (c *Client) TeamScores(ctx context.Context, team string) ([]*auditorpb.TeamScore, error) {
+	ctx, cancel := c.callContext(ctx)
+	defer cancel()
+	resp, err := c.rpc.GetTeamScores(ctx, &auditorpb.GetTeamScoresRequest{Team: team})
+	if err != nil {
+		return nil, err
+	}
+	return resp.GetScores(), nil
+}
+
+// TriggerAudit starts an audit of the namespace, empty for all namespaces
+func (c *Client) TriggerAudit(ctx context.Context, namespace string) (*auditorpb.TriggerAuditResponse, error) {
+	ctx, cancel := c.callContext(ctx)
+	defer cancel()
+	return c.rpc.TriggerAudit(ctx, &auditorpb.TriggerAuditRequest{Namespace: namespace})
+}
+
+// Filter selects findings; empty fields match everything
+type Filter struct {
+	Namespace   string
+	Cluster     string
+	RuleID      string
+	Team        string
+	MinSeverity findings.Severity
+}
+
+func (f Filter) request() *auditorpb.ListFindingsRequest {
+	return &auditorpb.ListFindingsRequest{
+		Namespace:   f.Namespace,
+		Cluster:     f.Cluster,
+		RuleId:      f.RuleID,
+		Team:        f.Team,
+		MinSeverity: string(f.MinSeverity),
+	}
+}
+
+// Pages calls fn with every page of matching findings, requesting pageSize
+// findings at a time (0 for the server default). The timeout applies per page.
+func (c *Client) Pages(ctx context.Context, filter Filter, pageSize int, fn func([]findings.Finding) error) error {
+	req := filter.request()
+	req.PageSize = int32(pageSize)
+	for {
+		callCtx, cancel := c.callContext(ctx)
+		resp, err := c.rpc.ListFindings(callCtx, req)
+		cancel()
+		if err != nil {
+			return err
+		}
+
+		page := make([]findings.Finding, 0, len(resp.GetFindings()))
+		for _, f := range resp.GetFindings() {
+			page = append(page, convert.FromProto(f))
+		}
+		if err := fn(page); err != nil {
+			return err
+		}
+		if resp.GetNextPageToken() == "" {
+			return nil
+		}
+		req.PageToken = resp.GetNextPageToken()
+	}
+}
+
+// Findings returns all matching findings, following the pages
+func (c *Client) Findings(ctx context.Context, filter Filter) ([]findings.Finding, error) {
+	var all []findings.Finding
+	err := c.Pages(ctx, filter, 0, func(page []findings.Finding) error {
+		all = append(all, page...)
+		return nil
+	})
+	return all, err
+}
+
+// Stream calls fn with each matching finding as it arrives from the server.
+// The stream is not bound by the call timeout; bound it through ctx.
+func (c *Client) Stream(ctx context.Context, filter Filter, fn func(findings.Finding) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	stream, err := c.rpc.StreamFindings(ctx, filter.request())
+	if err != nil {
+		return err
+	}
+	for {
+		f, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := fn(convert.FromProto(f)); err != nil {
+			return err
+		}
+	}
+}
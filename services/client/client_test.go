@@ -0,0 +1,110 @@
+package client_test
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"goprojects/findings"
+	"goprojects/services/client"
+	"goprojects/services/generated/auditorpb"
+	"goprojects/services/server"
+)
+
+// startServer serves n stored findings, in namespaces ns-0 and ns-1, with token auth
+func startServer(t *testing.T, n int) string {
+	t.Helper()
+	dir := t.TempDir()
+	db, err := server.InitDB(filepath.Join(dir, "audit.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	var fs []findings.Finding
+	for i := 0; i < n; i++ {
+		f := findings.Finding{
+			Namespace: fmt.Sprintf("ns-%d", i%2),
+			Resource:  fmt.Sprintf("web-%d", i),
+			Kind:      "Deployment",
+			Issue:     "Image tag is 'nginx:latest'",
+			RuleID:    "latest-image-tag",
+			Severity:  findings.SeverityMedium,
+		}
+		f.Fingerprint = findings.ComputeFingerprint(f)
+		fs = append(fs, f)
+	}
+	_, err = server.ReplaceFindings(db, "", "", "latest-image-tag", fs)
+	require.NoError(t, err)
+
+	tokens := filepath.Join(dir, "tokens.csv")
+	require.NoError(t, os.WriteFile(tokens, []byte("secret,dashboard,read\n"), 0600))
+	auth, err := server.LoadTokenFile(tokens)
+	require.NoError(t, err)
+
+	srv := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(auth.UnaryInterceptor()),
+		grpc.ChainStreamInterceptor(auth.StreamInterceptor()),
+	)
+	auditorpb.RegisterClusterAuditorServer(srv, &server.AuditorServer{DB: db})
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go srv.Serve(listener)
+	t.Cleanup(srv.Stop)
+	return listener.Addr().String()
+}
+
+func TestClientPagesAndStreams(t *testing.T) {
+	addr := startServer(t, 250)
+	ctx := context.Background()
+
+	c, err := client.New(client.Options{Address: addr, Token: "secret", Timeout: 5 * time.Second, Retries: 2})
+	require.NoError(t, err)
+	defer c.Close()
+
+	var pages []int
+	err = c.Pages(ctx, client.Filter{}, 100, func(page []findings.Finding) error {
+		pages = append(pages, len(page))
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, []int{100, 100, 50}, pages)
+
+	all, err := c.Findings(ctx, client.Filter{Namespace: "ns-1"})
+	require.NoError(t, err)
+	require.Len(t, all, 125)
+	require.Equal(t, "ns-1", all[0].Namespace)
+
+	streamed := 0
+	require.NoError(t, c.Stream(ctx, client.Filter{MinSeverity: findings.SeverityMedium}, func(findings.Finding) error {
+		streamed++
+		return nil
+	}))
+	require.Equal(t, 250, streamed)
+
+	none, err := c.Findings(ctx, client.Filter{MinSeverity: findings.SeverityHigh})
+	require.NoError(t, err)
+	require.Empty(t, none)
+
+	score, err := c.HealthScore(ctx)
+	require.NoError(t, err)
+	require.Less(t, score.Score, float32(100))
+}
+
+func TestClientWithoutToken(t *testing.T) {
+	addr := startServer(t, 1)
+
+	c, err := client.New(client.Options{Address: addr})
+	require.NoError(t, err)
+	defer c.Close()
+
+	_, err = c.HealthScore(context.Background())
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+}
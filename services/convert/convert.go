@@ -0,0 +1,172 @@
+// Package convert maps findings between the domain type, the database rows and
+// the protobuf messages served over gRPC
+package convert
+
+import (
+	"strings"
+	"time"
+
+	"goprojects/findings"
+	"goprojects/services/generated/auditorpb"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Row is a finding as stored in the findings table
+type Row struct {
+	Namespace   string
+	Resource    string
+	Kind        string
+	Container   string
+	Issue       string
+	Suggestion  string
+	Subjects    string // comma separated
+	RuleID      string
+	Severity    string
+	Cluster     string
+	Team        string
+	Fingerprint string
+	FirstSeen   time.Time
+	LastSeen    time.Time
+	Status      string
+}
+
+func joinSubjects(subjects []string) string {
+	return strings.Join(subjects, ",")
+}
+
+func splitSubjects(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, ",")
+}
+
+func ToRow(f findings.Finding) Row {
+	return Row{
+		Namespace:   f.Namespace,
+		Resource:    f.Resource,
+		Kind:        f.Kind,
+		Container:   f.Container,
+		Issue:       f.Issue,
+		Suggestion:  f.Suggestion,
+		Subjects:    joinSubjects(f.Subjects),
+		RuleID:      f.RuleID,
+		Severity:    string(f.Severity),
+		Cluster:     f.Cluster,
+		Team:        f.Team,
+		Fingerprint: f.Fingerprint,
+		FirstSeen:   f.FirstSeen,
+		LastSeen:    f.LastSeen,
+		Status:      string(f.Status),
+	}
+}
+
+func FromRow(r Row) findings.Finding {
+	return findings.Finding{
+		Namespace:   r.Namespace,
+		Resource:    r.Resource,
+		Kind:        r.Kind,
+		Container:   r.Container,
+		Issue:       r.Issue,
+		Suggestion:  r.Suggestion,
+		Subjects:    splitSubjects(r.Subjects),
+		RuleID:      r.RuleID,
+		Severity:    findings.Severity(r.Severity),
+		Cluster:     r.Cluster,
+		Team:        r.Team,
+		Fingerprint: r.Fingerprint,
+		FirstSeen:   r.FirstSeen,
+		LastSeen:    r.LastSeen,
+		Status:      findings.Status(r.Status),
+	}
+}
+
+func toTimestamp(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
+
+func fromTimestamp(ts *timestamppb.Timestamp) time.Time {
+	if ts == nil {
+		return time.Time{}
+	}
+	return ts.AsTime()
+}
+
+func ToProto(f findings.Finding) *auditorpb.Finding {
+	return &auditorpb.Finding{
+		Namespace:   f.Namespace,
+		Resource:    f.Resource,
+		Kind:        f.Kind,
+		Container:   f.Container,
+		Issue:       f.Issue,
+		Suggestion:  f.Suggestion,
+		Subjects:    f.Subjects,
+		Severity:    string(f.Severity),
+		RuleId:      f.RuleID,
+		Cluster:     f.Cluster,
+		Team:        f.Team,
+		Fingerprint: f.Fingerprint,
+		FirstSeen:   toTimestamp(f.FirstSeen),
+		LastSeen:    toTimestamp(f.LastSeen),
+		Status:      string(f.Status),
+	}
+}
+
+func FromProto(p *auditorpb.Finding) findings.Finding {
+	return findings.Finding{
+		Namespace:   p.GetNamespace(),
+		Resource:    p.GetResource(),
+		Kind:        p.GetKind(),
+		Container:   p.GetContainer(),
+		Issue:       p.GetIssue(),
+		Suggestion:  p.GetSuggestion(),
+		Subjects:    p.GetSubjects(),
+		RuleID:      p.GetRuleId(),
+		Severity:    findings.Severity(p.GetSeverity()),
+		Cluster:     p.GetCluster(),
+		Team:        p.GetTeam(),
+		Fingerprint: p.GetFingerprint(),
+		FirstSeen:   fromTimestamp(p.GetFirstSeen()),
+		LastSeen:    fromTimestamp(p.GetLastSeen()),
+		Status:      findings.Status(p.GetStatus()),
+	}
+}
+
+// RowToProto maps a stored row straight to its protobuf message
+func RowToProto(r Row) *auditorpb.Finding {
+	return ToProto(FromRow(r))
+}
+
+func ExceptionToProto(e findings.Exception) *auditorpb.Exception {
+	return &auditorpb.Exception{
+		Id:        e.ID,
+		Namespace: e.Namespace,
+		Kind:      e.Kind,
+		Resource:  e.Resource,
+		RuleId:    e.RuleID,
+		Reason:    e.Reason,
+		Owner:     e.Owner,
+		ExpiresAt: toTimestamp(e.ExpiresAt),
+		CreatedAt: toTimestamp(e.CreatedAt),
+		CreatedBy: e.CreatedBy,
+	}
+}
+
+func ExceptionFromProto(p *auditorpb.Exception) findings.Exception {
+	return findings.Exception{
+		ID:        p.GetId(),
+		Namespace: p.GetNamespace(),
+		Kind:      p.GetKind(),
+		Resource:  p.GetResource(),
+		RuleID:    p.GetRuleId(),
+		Reason:    p.GetReason(),
+		Owner:     p.GetOwner(),
+		ExpiresAt: fromTimestamp(p.GetExpiresAt()),
+		CreatedAt: fromTimestamp(p.GetCreatedAt()),
+		CreatedBy: p.GetCreatedBy(),
+	}
+}
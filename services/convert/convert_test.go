@@ -0,0 +1,66 @@
+package convert_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"goprojects/findings"
+	"goprojects/services/convert"
+	"goprojects/services/generated/auditorpb"
+)
+
+func sampleFinding() findings.Finding {
+	f := findings.Finding{
+		Namespace:  "payments",
+		Resource:   "pod-reader",
+		Kind:       "Role",
+		Issue:      "Role has risky permissions: Secrets read access (get/list/watch)",
+		Suggestion: "Restrict the permissions to only those necessary for this Role.",
+		Subjects:   []string{"Group:devs", "SA:payments/api"},
+		RuleID:     "risky-rbac",
+		Severity:   findings.SeverityHigh,
+		Cluster:    "prod-eu",
+		Team:       "payments",
+		FirstSeen:  time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC),
+		LastSeen:   time.Date(2026, 3, 4, 8, 0, 0, 0, time.UTC),
+		Status:     findings.StatusOpen,
+	}
+	f.Fingerprint = findings.ComputeFingerprint(f)
+	return f
+}
+
+func TestRowRoundTrip(t *testing.T) {
+	f := sampleFinding()
+
+	row := convert.ToRow(f)
+	require.Equal(t, "Group:devs,SA:payments/api", row.Subjects)
+	require.Equal(t, "high", row.Severity)
+	require.Equal(t, f, convert.FromRow(row))
+}
+
+func TestProtoRoundTrip(t *testing.T) {
+	f := sampleFinding()
+
+	p := convert.ToProto(f)
+	require.Equal(t, []string{"Group:devs", "SA:payments/api"}, p.Subjects)
+	require.Equal(t, "risky-rbac", p.RuleId)
+	require.Equal(t, "open", p.Status)
+	require.Equal(t, f.FirstSeen, p.FirstSeen.AsTime())
+	require.Equal(t, f, convert.FromProto(p))
+
+	require.Equal(t, p, convert.RowToProto(convert.ToRow(f)))
+}
+
+func TestEmptyValues(t *testing.T) {
+	f := findings.Finding{Namespace: "default", Resource: "web", Kind: "Deployment"}
+
+	require.Nil(t, convert.FromRow(convert.ToRow(f)).Subjects)
+
+	p := convert.ToProto(f)
+	require.Nil(t, p.FirstSeen, "zero times are left unset")
+	require.Nil(t, p.LastSeen)
+	require.Equal(t, f, convert.FromProto(p))
+	require.Equal(t, findings.Finding{}, convert.FromProto(&auditorpb.Finding{}))
+}
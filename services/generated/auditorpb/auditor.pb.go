@@ -7,8 +7,11 @@
 package auditorpb
 
 import (
+	_ "google.golang.org/genproto/googleapis/api/annotations"
 	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	durationpb "google.golang.org/protobuf/types/known/durationpb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
 	reflect "reflect"
 	sync "sync"
 	unsafe "unsafe"
@@ -117,6 +120,15 @@ type Finding struct {
 	Container     string                 `protobuf:"bytes,4,opt,name=container,proto3" json:"container,omitempty"`
 	Issue         string                 `protobuf:"bytes,5,opt,name=issue,proto3" json:"issue,omitempty"`
 	Suggestion    string                 `protobuf:"bytes,6,opt,name=suggestion,proto3" json:"suggestion,omitempty"`
+	Subjects      []string               `protobuf:"bytes,7,rep,name=subjects,proto3" json:"subjects,omitempty"` // RBAC subjects bound to the resource, e.g. "SA:ns/name"
+	Severity      string                 `protobuf:"bytes,8,opt,name=severity,proto3" json:"severity,omitempty"` // critical, high, medium, low or info
+	RuleId        string                 `protobuf:"bytes,9,opt,name=rule_id,json=ruleId,proto3" json:"rule_id,omitempty"`
+	Cluster       string                 `protobuf:"bytes,10,opt,name=cluster,proto3" json:"cluster,omitempty"`
+	Fingerprint   string                 `protobuf:"bytes,11,opt,name=fingerprint,proto3" json:"fingerprint,omitempty"` // stable identity of the finding across runs
+	FirstSeen     *timestamppb.Timestamp `protobuf:"bytes,12,opt,name=first_seen,json=firstSeen,proto3" json:"first_seen,omitempty"`
+	LastSeen      *timestamppb.Timestamp `protobuf:"bytes,13,opt,name=last_seen,json=lastSeen,proto3" json:"last_seen,omitempty"`
+	Status        string                 `protobuf:"bytes,14,opt,name=status,proto3" json:"status,omitempty"` // open or resolved
+	Team          string                 `protobuf:"bytes,15,opt,name=team,proto3" json:"team,omitempty"`     // team owning the namespace, empty if unowned
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -193,28 +205,747 @@ func (x *Finding) GetSuggestion() string {
 	return ""
 }
 
+func (x *Finding) GetSubjects() []string {
+	if x != nil {
+		return x.Subjects
+	}
+	return nil
+}
+
+func (x *Finding) GetSeverity() string {
+	if x != nil {
+		return x.Severity
+	}
+	return ""
+}
+
+func (x *Finding) GetRuleId() string {
+	if x != nil {
+		return x.RuleId
+	}
+	return ""
+}
+
+func (x *Finding) GetCluster() string {
+	if x != nil {
+		return x.Cluster
+	}
+	return ""
+}
+
+func (x *Finding) GetFingerprint() string {
+	if x != nil {
+		return x.Fingerprint
+	}
+	return ""
+}
+
+func (x *Finding) GetFirstSeen() *timestamppb.Timestamp {
+	if x != nil {
+		return x.FirstSeen
+	}
+	return nil
+}
+
+func (x *Finding) GetLastSeen() *timestamppb.Timestamp {
+	if x != nil {
+		return x.LastSeen
+	}
+	return nil
+}
+
+func (x *Finding) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *Finding) GetTeam() string {
+	if x != nil {
+		return x.Team
+	}
+	return ""
+}
+
 type FindingsResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Findings      []*Finding             `protobuf:"bytes,1,rep,name=findings,proto3" json:"findings,omitempty"`
+	Findings      []*Finding             `protobuf:"bytes,1,rep,name=findings,proto3" json:"findings,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FindingsResponse) Reset() {
+	*x = FindingsResponse{}
+	mi := &file_services_proto_auditor_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FindingsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FindingsResponse) ProtoMessage() {}
+
+func (x *FindingsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_services_proto_auditor_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FindingsResponse.ProtoReflect.Descriptor instead.
+func (*FindingsResponse) Descriptor() ([]byte, []int) {
+	return file_services_proto_auditor_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *FindingsResponse) GetFindings() []*Finding {
+	if x != nil {
+		return x.Findings
+	}
+	return nil
+}
+
+// ListFindingsRequest filters the open findings; empty fields match everything
+type ListFindingsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	PageSize      int32                  `protobuf:"varint,1,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`   // defaults to 100, at most 1000
+	PageToken     string                 `protobuf:"bytes,2,opt,name=page_token,json=pageToken,proto3" json:"page_token,omitempty"` // next_page_token of the previous page
+	Namespace     string                 `protobuf:"bytes,3,opt,name=namespace,proto3" json:"namespace,omitempty"`
+	Cluster       string                 `protobuf:"bytes,4,opt,name=cluster,proto3" json:"cluster,omitempty"`
+	RuleId        string                 `protobuf:"bytes,5,opt,name=rule_id,json=ruleId,proto3" json:"rule_id,omitempty"`
+	MinSeverity   string                 `protobuf:"bytes,6,opt,name=min_severity,json=minSeverity,proto3" json:"min_severity,omitempty"` // only findings at least this severe
+	Team          string                 `protobuf:"bytes,7,opt,name=team,proto3" json:"team,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListFindingsRequest) Reset() {
+	*x = ListFindingsRequest{}
+	mi := &file_services_proto_auditor_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListFindingsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListFindingsRequest) ProtoMessage() {}
+
+func (x *ListFindingsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_services_proto_auditor_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListFindingsRequest.ProtoReflect.Descriptor instead.
+func (*ListFindingsRequest) Descriptor() ([]byte, []int) {
+	return file_services_proto_auditor_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ListFindingsRequest) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *ListFindingsRequest) GetPageToken() string {
+	if x != nil {
+		return x.PageToken
+	}
+	return ""
+}
+
+func (x *ListFindingsRequest) GetNamespace() string {
+	if x != nil {
+		return x.Namespace
+	}
+	return ""
+}
+
+func (x *ListFindingsRequest) GetCluster() string {
+	if x != nil {
+		return x.Cluster
+	}
+	return ""
+}
+
+func (x *ListFindingsRequest) GetRuleId() string {
+	if x != nil {
+		return x.RuleId
+	}
+	return ""
+}
+
+func (x *ListFindingsRequest) GetMinSeverity() string {
+	if x != nil {
+		return x.MinSeverity
+	}
+	return ""
+}
+
+func (x *ListFindingsRequest) GetTeam() string {
+	if x != nil {
+		return x.Team
+	}
+	return ""
+}
+
+type ListFindingsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Findings      []*Finding             `protobuf:"bytes,1,rep,name=findings,proto3" json:"findings,omitempty"`
+	NextPageToken string                 `protobuf:"bytes,2,opt,name=next_page_token,json=nextPageToken,proto3" json:"next_page_token,omitempty"` // empty on the last page
+	TotalSize     int32                  `protobuf:"varint,3,opt,name=total_size,json=totalSize,proto3" json:"total_size,omitempty"`              // matching findings over all pages
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListFindingsResponse) Reset() {
+	*x = ListFindingsResponse{}
+	mi := &file_services_proto_auditor_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListFindingsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListFindingsResponse) ProtoMessage() {}
+
+func (x *ListFindingsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_services_proto_auditor_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListFindingsResponse.ProtoReflect.Descriptor instead.
+func (*ListFindingsResponse) Descriptor() ([]byte, []int) {
+	return file_services_proto_auditor_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *ListFindingsResponse) GetFindings() []*Finding {
+	if x != nil {
+		return x.Findings
+	}
+	return nil
+}
+
+func (x *ListFindingsResponse) GetNextPageToken() string {
+	if x != nil {
+		return x.NextPageToken
+	}
+	return ""
+}
+
+func (x *ListFindingsResponse) GetTotalSize() int32 {
+	if x != nil {
+		return x.TotalSize
+	}
+	return 0
+}
+
+type GetTeamScoresRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Team          string                 `protobuf:"bytes,1,opt,name=team,proto3" json:"team,omitempty"` // empty for every team with open findings
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetTeamScoresRequest) Reset() {
+	*x = GetTeamScoresRequest{}
+	mi := &file_services_proto_auditor_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetTeamScoresRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetTeamScoresRequest) ProtoMessage() {}
+
+func (x *GetTeamScoresRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_services_proto_auditor_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetTeamScoresRequest.ProtoReflect.Descriptor instead.
+func (*GetTeamScoresRequest) Descriptor() ([]byte, []int) {
+	return file_services_proto_auditor_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *GetTeamScoresRequest) GetTeam() string {
+	if x != nil {
+		return x.Team
+	}
+	return ""
+}
+
+// TeamScore is the health score over the findings of one team's namespaces
+type TeamScore struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Team          string                 `protobuf:"bytes,1,opt,name=team,proto3" json:"team,omitempty"`
+	Score         float32                `protobuf:"fixed32,2,opt,name=score,proto3" json:"score,omitempty"`
+	Status        string                 `protobuf:"bytes,3,opt,name=status,proto3" json:"status,omitempty"`
+	OpenFindings  int32                  `protobuf:"varint,4,opt,name=open_findings,json=openFindings,proto3" json:"open_findings,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TeamScore) Reset() {
+	*x = TeamScore{}
+	mi := &file_services_proto_auditor_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TeamScore) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TeamScore) ProtoMessage() {}
+
+func (x *TeamScore) ProtoReflect() protoreflect.Message {
+	mi := &file_services_proto_auditor_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TeamScore.ProtoReflect.Descriptor instead.
+func (*TeamScore) Descriptor() ([]byte, []int) {
+	return file_services_proto_auditor_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *TeamScore) GetTeam() string {
+	if x != nil {
+		return x.Team
+	}
+	return ""
+}
+
+func (x *TeamScore) GetScore() float32 {
+	if x != nil {
+		return x.Score
+	}
+	return 0
+}
+
+func (x *TeamScore) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *TeamScore) GetOpenFindings() int32 {
+	if x != nil {
+		return x.OpenFindings
+	}
+	return 0
+}
+
+type GetTeamScoresResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Scores        []*TeamScore           `protobuf:"bytes,1,rep,name=scores,proto3" json:"scores,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetTeamScoresResponse) Reset() {
+	*x = GetTeamScoresResponse{}
+	mi := &file_services_proto_auditor_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetTeamScoresResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetTeamScoresResponse) ProtoMessage() {}
+
+func (x *GetTeamScoresResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_services_proto_auditor_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetTeamScoresResponse.ProtoReflect.Descriptor instead.
+func (*GetTeamScoresResponse) Descriptor() ([]byte, []int) {
+	return file_services_proto_auditor_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *GetTeamScoresResponse) GetScores() []*TeamScore {
+	if x != nil {
+		return x.Scores
+	}
+	return nil
+}
+
+type TriggerAuditRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Namespace     string                 `protobuf:"bytes,1,opt,name=namespace,proto3" json:"namespace,omitempty"` // empty audits all namespaces
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TriggerAuditRequest) Reset() {
+	*x = TriggerAuditRequest{}
+	mi := &file_services_proto_auditor_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TriggerAuditRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TriggerAuditRequest) ProtoMessage() {}
+
+func (x *TriggerAuditRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_services_proto_auditor_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TriggerAuditRequest.ProtoReflect.Descriptor instead.
+func (*TriggerAuditRequest) Descriptor() ([]byte, []int) {
+	return file_services_proto_auditor_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *TriggerAuditRequest) GetNamespace() string {
+	if x != nil {
+		return x.Namespace
+	}
+	return ""
+}
+
+type TriggerAuditResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Accepted      bool                   `protobuf:"varint,1,opt,name=accepted,proto3" json:"accepted,omitempty"`
+	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TriggerAuditResponse) Reset() {
+	*x = TriggerAuditResponse{}
+	mi := &file_services_proto_auditor_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TriggerAuditResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TriggerAuditResponse) ProtoMessage() {}
+
+func (x *TriggerAuditResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_services_proto_auditor_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TriggerAuditResponse.ProtoReflect.Descriptor instead.
+func (*TriggerAuditResponse) Descriptor() ([]byte, []int) {
+	return file_services_proto_auditor_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *TriggerAuditResponse) GetAccepted() bool {
+	if x != nil {
+		return x.Accepted
+	}
+	return false
+}
+
+func (x *TriggerAuditResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+// Exception suppresses matching findings in GetFindings and GetHealthScore.
+// Empty matcher fields match anything; at least one must be set.
+type Exception struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Namespace     string                 `protobuf:"bytes,2,opt,name=namespace,proto3" json:"namespace,omitempty"`
+	Kind          string                 `protobuf:"bytes,3,opt,name=kind,proto3" json:"kind,omitempty"`
+	Resource      string                 `protobuf:"bytes,4,opt,name=resource,proto3" json:"resource,omitempty"`
+	RuleId        string                 `protobuf:"bytes,5,opt,name=rule_id,json=ruleId,proto3" json:"rule_id,omitempty"`
+	Reason        string                 `protobuf:"bytes,6,opt,name=reason,proto3" json:"reason,omitempty"`
+	Owner         string                 `protobuf:"bytes,7,opt,name=owner,proto3" json:"owner,omitempty"`
+	ExpiresAt     *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"` // unset never expires
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,9,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	CreatedBy     string                 `protobuf:"bytes,10,opt,name=created_by,json=createdBy,proto3" json:"created_by,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Exception) Reset() {
+	*x = Exception{}
+	mi := &file_services_proto_auditor_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Exception) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Exception) ProtoMessage() {}
+
+func (x *Exception) ProtoReflect() protoreflect.Message {
+	mi := &file_services_proto_auditor_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Exception.ProtoReflect.Descriptor instead.
+func (*Exception) Descriptor() ([]byte, []int) {
+	return file_services_proto_auditor_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *Exception) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Exception) GetNamespace() string {
+	if x != nil {
+		return x.Namespace
+	}
+	return ""
+}
+
+func (x *Exception) GetKind() string {
+	if x != nil {
+		return x.Kind
+	}
+	return ""
+}
+
+func (x *Exception) GetResource() string {
+	if x != nil {
+		return x.Resource
+	}
+	return ""
+}
+
+func (x *Exception) GetRuleId() string {
+	if x != nil {
+		return x.RuleId
+	}
+	return ""
+}
+
+func (x *Exception) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *Exception) GetOwner() string {
+	if x != nil {
+		return x.Owner
+	}
+	return ""
+}
+
+func (x *Exception) GetExpiresAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return nil
+}
+
+func (x *Exception) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *Exception) GetCreatedBy() string {
+	if x != nil {
+		return x.CreatedBy
+	}
+	return ""
+}
+
+type CreateExceptionRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Exception     *Exception             `protobuf:"bytes,1,opt,name=exception,proto3" json:"exception,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateExceptionRequest) Reset() {
+	*x = CreateExceptionRequest{}
+	mi := &file_services_proto_auditor_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateExceptionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateExceptionRequest) ProtoMessage() {}
+
+func (x *CreateExceptionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_services_proto_auditor_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateExceptionRequest.ProtoReflect.Descriptor instead.
+func (*CreateExceptionRequest) Descriptor() ([]byte, []int) {
+	return file_services_proto_auditor_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *CreateExceptionRequest) GetException() *Exception {
+	if x != nil {
+		return x.Exception
+	}
+	return nil
+}
+
+type ListExceptionsRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	IncludeExpired bool                   `protobuf:"varint,1,opt,name=include_expired,json=includeExpired,proto3" json:"include_expired,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *ListExceptionsRequest) Reset() {
+	*x = ListExceptionsRequest{}
+	mi := &file_services_proto_auditor_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListExceptionsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListExceptionsRequest) ProtoMessage() {}
+
+func (x *ListExceptionsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_services_proto_auditor_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListExceptionsRequest.ProtoReflect.Descriptor instead.
+func (*ListExceptionsRequest) Descriptor() ([]byte, []int) {
+	return file_services_proto_auditor_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *ListExceptionsRequest) GetIncludeExpired() bool {
+	if x != nil {
+		return x.IncludeExpired
+	}
+	return false
+}
+
+type ListExceptionsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Exceptions    []*Exception           `protobuf:"bytes,1,rep,name=exceptions,proto3" json:"exceptions,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *FindingsResponse) Reset() {
-	*x = FindingsResponse{}
-	mi := &file_services_proto_auditor_proto_msgTypes[3]
+func (x *ListExceptionsResponse) Reset() {
+	*x = ListExceptionsResponse{}
+	mi := &file_services_proto_auditor_proto_msgTypes[14]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *FindingsResponse) String() string {
+func (x *ListExceptionsResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*FindingsResponse) ProtoMessage() {}
+func (*ListExceptionsResponse) ProtoMessage() {}
 
-func (x *FindingsResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_services_proto_auditor_proto_msgTypes[3]
+func (x *ListExceptionsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_services_proto_auditor_proto_msgTypes[14]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -225,14 +956,331 @@ func (x *FindingsResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use FindingsResponse.ProtoReflect.Descriptor instead.
-func (*FindingsResponse) Descriptor() ([]byte, []int) {
-	return file_services_proto_auditor_proto_rawDescGZIP(), []int{3}
+// Deprecated: Use ListExceptionsResponse.ProtoReflect.Descriptor instead.
+func (*ListExceptionsResponse) Descriptor() ([]byte, []int) {
+	return file_services_proto_auditor_proto_rawDescGZIP(), []int{14}
 }
 
-func (x *FindingsResponse) GetFindings() []*Finding {
+func (x *ListExceptionsResponse) GetExceptions() []*Exception {
 	if x != nil {
-		return x.Findings
+		return x.Exceptions
+	}
+	return nil
+}
+
+type DeleteExceptionRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteExceptionRequest) Reset() {
+	*x = DeleteExceptionRequest{}
+	mi := &file_services_proto_auditor_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteExceptionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteExceptionRequest) ProtoMessage() {}
+
+func (x *DeleteExceptionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_services_proto_auditor_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteExceptionRequest.ProtoReflect.Descriptor instead.
+func (*DeleteExceptionRequest) Descriptor() ([]byte, []int) {
+	return file_services_proto_auditor_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *DeleteExceptionRequest) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+type GetTrendsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Start         *timestamppb.Timestamp `protobuf:"bytes,1,opt,name=start,proto3" json:"start,omitempty"`                    // defaults to 30 days before end
+	End           *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=end,proto3" json:"end,omitempty"`                        // defaults to now
+	Interval      string                 `protobuf:"bytes,3,opt,name=interval,proto3" json:"interval,omitempty"`              // hour, day (default) or week
+	GroupBy       string                 `protobuf:"bytes,4,opt,name=group_by,json=groupBy,proto3" json:"group_by,omitempty"` // severity (default), rule, namespace, cluster or team
+	Cluster       string                 `protobuf:"bytes,5,opt,name=cluster,proto3" json:"cluster,omitempty"`                // only findings of this cluster, empty for all
+	Team          string                 `protobuf:"bytes,6,opt,name=team,proto3" json:"team,omitempty"`                      // only findings of this team, empty for all
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetTrendsRequest) Reset() {
+	*x = GetTrendsRequest{}
+	mi := &file_services_proto_auditor_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetTrendsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetTrendsRequest) ProtoMessage() {}
+
+func (x *GetTrendsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_services_proto_auditor_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetTrendsRequest.ProtoReflect.Descriptor instead.
+func (*GetTrendsRequest) Descriptor() ([]byte, []int) {
+	return file_services_proto_auditor_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *GetTrendsRequest) GetStart() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Start
+	}
+	return nil
+}
+
+func (x *GetTrendsRequest) GetEnd() *timestamppb.Timestamp {
+	if x != nil {
+		return x.End
+	}
+	return nil
+}
+
+func (x *GetTrendsRequest) GetInterval() string {
+	if x != nil {
+		return x.Interval
+	}
+	return ""
+}
+
+func (x *GetTrendsRequest) GetGroupBy() string {
+	if x != nil {
+		return x.GroupBy
+	}
+	return ""
+}
+
+func (x *GetTrendsRequest) GetCluster() string {
+	if x != nil {
+		return x.Cluster
+	}
+	return ""
+}
+
+func (x *GetTrendsRequest) GetTeam() string {
+	if x != nil {
+		return x.Team
+	}
+	return ""
+}
+
+// TrendCounts are the finding counts of one group, or of all findings, in a bucket
+type TrendCounts struct {
+	state             protoimpl.MessageState `protogen:"open.v1"`
+	Group             string                 `protobuf:"bytes,1,opt,name=group,proto3" json:"group,omitempty"`                                                      // value of the group_by field, empty for bucket totals
+	Open              int64                  `protobuf:"varint,2,opt,name=open,proto3" json:"open,omitempty"`                                                       // open at the end of the bucket
+	Opened            int64                  `protobuf:"varint,3,opt,name=opened,proto3" json:"opened,omitempty"`                                                   // opened during the bucket
+	Resolved          int64                  `protobuf:"varint,4,opt,name=resolved,proto3" json:"resolved,omitempty"`                                               // resolved during the bucket
+	MeanTimeToResolve *durationpb.Duration   `protobuf:"bytes,5,opt,name=mean_time_to_resolve,json=meanTimeToResolve,proto3" json:"mean_time_to_resolve,omitempty"` // of the findings resolved during the bucket, unset if none
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *TrendCounts) Reset() {
+	*x = TrendCounts{}
+	mi := &file_services_proto_auditor_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TrendCounts) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TrendCounts) ProtoMessage() {}
+
+func (x *TrendCounts) ProtoReflect() protoreflect.Message {
+	mi := &file_services_proto_auditor_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TrendCounts.ProtoReflect.Descriptor instead.
+func (*TrendCounts) Descriptor() ([]byte, []int) {
+	return file_services_proto_auditor_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *TrendCounts) GetGroup() string {
+	if x != nil {
+		return x.Group
+	}
+	return ""
+}
+
+func (x *TrendCounts) GetOpen() int64 {
+	if x != nil {
+		return x.Open
+	}
+	return 0
+}
+
+func (x *TrendCounts) GetOpened() int64 {
+	if x != nil {
+		return x.Opened
+	}
+	return 0
+}
+
+func (x *TrendCounts) GetResolved() int64 {
+	if x != nil {
+		return x.Resolved
+	}
+	return 0
+}
+
+func (x *TrendCounts) GetMeanTimeToResolve() *durationpb.Duration {
+	if x != nil {
+		return x.MeanTimeToResolve
+	}
+	return nil
+}
+
+type TrendBucket struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Start         *timestamppb.Timestamp `protobuf:"bytes,1,opt,name=start,proto3" json:"start,omitempty"`
+	End           *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=end,proto3" json:"end,omitempty"`
+	Total         *TrendCounts           `protobuf:"bytes,3,opt,name=total,proto3" json:"total,omitempty"`
+	Groups        []*TrendCounts         `protobuf:"bytes,4,rep,name=groups,proto3" json:"groups,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TrendBucket) Reset() {
+	*x = TrendBucket{}
+	mi := &file_services_proto_auditor_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TrendBucket) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TrendBucket) ProtoMessage() {}
+
+func (x *TrendBucket) ProtoReflect() protoreflect.Message {
+	mi := &file_services_proto_auditor_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TrendBucket.ProtoReflect.Descriptor instead.
+func (*TrendBucket) Descriptor() ([]byte, []int) {
+	return file_services_proto_auditor_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *TrendBucket) GetStart() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Start
+	}
+	return nil
+}
+
+func (x *TrendBucket) GetEnd() *timestamppb.Timestamp {
+	if x != nil {
+		return x.End
+	}
+	return nil
+}
+
+func (x *TrendBucket) GetTotal() *TrendCounts {
+	if x != nil {
+		return x.Total
+	}
+	return nil
+}
+
+func (x *TrendBucket) GetGroups() []*TrendCounts {
+	if x != nil {
+		return x.Groups
+	}
+	return nil
+}
+
+type GetTrendsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Buckets       []*TrendBucket         `protobuf:"bytes,1,rep,name=buckets,proto3" json:"buckets,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetTrendsResponse) Reset() {
+	*x = GetTrendsResponse{}
+	mi := &file_services_proto_auditor_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetTrendsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetTrendsResponse) ProtoMessage() {}
+
+func (x *GetTrendsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_services_proto_auditor_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetTrendsResponse.ProtoReflect.Descriptor instead.
+func (*GetTrendsResponse) Descriptor() ([]byte, []int) {
+	return file_services_proto_auditor_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *GetTrendsResponse) GetBuckets() []*TrendBucket {
+	if x != nil {
+		return x.Buckets
 	}
 	return nil
 }
@@ -241,11 +1289,11 @@ var File_services_proto_auditor_proto protoreflect.FileDescriptor
 
 const file_services_proto_auditor_proto_rawDesc = "" +
 	"\n" +
-	"\x1cservices/proto/auditor.proto\x12\aauditor\"\a\n" +
+	"\x1cservices/proto/auditor.proto\x12\aauditor\x1a\x1cgoogle/api/annotations.proto\x1a\x1egoogle/protobuf/duration.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"\a\n" +
 	"\x05Empty\";\n" +
 	"\vHealthScore\x12\x14\n" +
 	"\x05score\x18\x01 \x01(\x02R\x05score\x12\x16\n" +
-	"\x06status\x18\x02 \x01(\tR\x06status\"\xab\x01\n" +
+	"\x06status\x18\x02 \x01(\tR\x06status\"\xd8\x03\n" +
 	"\aFinding\x12\x1c\n" +
 	"\tnamespace\x18\x01 \x01(\tR\tnamespace\x12\x1a\n" +
 	"\bresource\x18\x02 \x01(\tR\bresource\x12\x12\n" +
@@ -254,12 +1302,106 @@ const file_services_proto_auditor_proto_rawDesc = "" +
 	"\x05issue\x18\x05 \x01(\tR\x05issue\x12\x1e\n" +
 	"\n" +
 	"suggestion\x18\x06 \x01(\tR\n" +
-	"suggestion\"@\n" +
+	"suggestion\x12\x1a\n" +
+	"\bsubjects\x18\a \x03(\tR\bsubjects\x12\x1a\n" +
+	"\bseverity\x18\b \x01(\tR\bseverity\x12\x17\n" +
+	"\arule_id\x18\t \x01(\tR\x06ruleId\x12\x18\n" +
+	"\acluster\x18\n" +
+	" \x01(\tR\acluster\x12 \n" +
+	"\vfingerprint\x18\v \x01(\tR\vfingerprint\x129\n" +
+	"\n" +
+	"first_seen\x18\f \x01(\v2\x1a.google.protobuf.TimestampR\tfirstSeen\x127\n" +
+	"\tlast_seen\x18\r \x01(\v2\x1a.google.protobuf.TimestampR\blastSeen\x12\x16\n" +
+	"\x06status\x18\x0e \x01(\tR\x06status\x12\x12\n" +
+	"\x04team\x18\x0f \x01(\tR\x04team\"@\n" +
 	"\x10FindingsResponse\x12,\n" +
-	"\bfindings\x18\x01 \x03(\v2\x10.auditor.FindingR\bfindings2\x82\x01\n" +
-	"\x0eClusterAuditor\x126\n" +
-	"\x0eGetHealthScore\x12\x0e.auditor.Empty\x1a\x14.auditor.HealthScore\x128\n" +
-	"\vGetFindings\x12\x0e.auditor.Empty\x1a\x19.auditor.FindingsResponseB\x1eZ\x1cservices/generated/auditorpbb\x06proto3"
+	"\bfindings\x18\x01 \x03(\v2\x10.auditor.FindingR\bfindings\"\xd9\x01\n" +
+	"\x13ListFindingsRequest\x12\x1b\n" +
+	"\tpage_size\x18\x01 \x01(\x05R\bpageSize\x12\x1d\n" +
+	"\n" +
+	"page_token\x18\x02 \x01(\tR\tpageToken\x12\x1c\n" +
+	"\tnamespace\x18\x03 \x01(\tR\tnamespace\x12\x18\n" +
+	"\acluster\x18\x04 \x01(\tR\acluster\x12\x17\n" +
+	"\arule_id\x18\x05 \x01(\tR\x06ruleId\x12!\n" +
+	"\fmin_severity\x18\x06 \x01(\tR\vminSeverity\x12\x12\n" +
+	"\x04team\x18\a \x01(\tR\x04team\"\x8b\x01\n" +
+	"\x14ListFindingsResponse\x12,\n" +
+	"\bfindings\x18\x01 \x03(\v2\x10.auditor.FindingR\bfindings\x12&\n" +
+	"\x0fnext_page_token\x18\x02 \x01(\tR\rnextPageToken\x12\x1d\n" +
+	"\n" +
+	"total_size\x18\x03 \x01(\x05R\ttotalSize\"*\n" +
+	"\x14GetTeamScoresRequest\x12\x12\n" +
+	"\x04team\x18\x01 \x01(\tR\x04team\"r\n" +
+	"\tTeamScore\x12\x12\n" +
+	"\x04team\x18\x01 \x01(\tR\x04team\x12\x14\n" +
+	"\x05score\x18\x02 \x01(\x02R\x05score\x12\x16\n" +
+	"\x06status\x18\x03 \x01(\tR\x06status\x12#\n" +
+	"\ropen_findings\x18\x04 \x01(\x05R\fopenFindings\"C\n" +
+	"\x15GetTeamScoresResponse\x12*\n" +
+	"\x06scores\x18\x01 \x03(\v2\x12.auditor.TeamScoreR\x06scores\"3\n" +
+	"\x13TriggerAuditRequest\x12\x1c\n" +
+	"\tnamespace\x18\x01 \x01(\tR\tnamespace\"L\n" +
+	"\x14TriggerAuditResponse\x12\x1a\n" +
+	"\baccepted\x18\x01 \x01(\bR\baccepted\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\"\xc5\x02\n" +
+	"\tException\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x1c\n" +
+	"\tnamespace\x18\x02 \x01(\tR\tnamespace\x12\x12\n" +
+	"\x04kind\x18\x03 \x01(\tR\x04kind\x12\x1a\n" +
+	"\bresource\x18\x04 \x01(\tR\bresource\x12\x17\n" +
+	"\arule_id\x18\x05 \x01(\tR\x06ruleId\x12\x16\n" +
+	"\x06reason\x18\x06 \x01(\tR\x06reason\x12\x14\n" +
+	"\x05owner\x18\a \x01(\tR\x05owner\x129\n" +
+	"\n" +
+	"expires_at\x18\b \x01(\v2\x1a.google.protobuf.TimestampR\texpiresAt\x129\n" +
+	"\n" +
+	"created_at\x18\t \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x12\x1d\n" +
+	"\n" +
+	"created_by\x18\n" +
+	" \x01(\tR\tcreatedBy\"J\n" +
+	"\x16CreateExceptionRequest\x120\n" +
+	"\texception\x18\x01 \x01(\v2\x12.auditor.ExceptionR\texception\"@\n" +
+	"\x15ListExceptionsRequest\x12'\n" +
+	"\x0finclude_expired\x18\x01 \x01(\bR\x0eincludeExpired\"L\n" +
+	"\x16ListExceptionsResponse\x122\n" +
+	"\n" +
+	"exceptions\x18\x01 \x03(\v2\x12.auditor.ExceptionR\n" +
+	"exceptions\"(\n" +
+	"\x16DeleteExceptionRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\"\xd7\x01\n" +
+	"\x10GetTrendsRequest\x120\n" +
+	"\x05start\x18\x01 \x01(\v2\x1a.google.protobuf.TimestampR\x05start\x12,\n" +
+	"\x03end\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\x03end\x12\x1a\n" +
+	"\binterval\x18\x03 \x01(\tR\binterval\x12\x19\n" +
+	"\bgroup_by\x18\x04 \x01(\tR\agroupBy\x12\x18\n" +
+	"\acluster\x18\x05 \x01(\tR\acluster\x12\x12\n" +
+	"\x04team\x18\x06 \x01(\tR\x04team\"\xb7\x01\n" +
+	"\vTrendCounts\x12\x14\n" +
+	"\x05group\x18\x01 \x01(\tR\x05group\x12\x12\n" +
+	"\x04open\x18\x02 \x01(\x03R\x04open\x12\x16\n" +
+	"\x06opened\x18\x03 \x01(\x03R\x06opened\x12\x1a\n" +
+	"\bresolved\x18\x04 \x01(\x03R\bresolved\x12J\n" +
+	"\x14mean_time_to_resolve\x18\x05 \x01(\v2\x19.google.protobuf.DurationR\x11meanTimeToResolve\"\xc7\x01\n" +
+	"\vTrendBucket\x120\n" +
+	"\x05start\x18\x01 \x01(\v2\x1a.google.protobuf.TimestampR\x05start\x12,\n" +
+	"\x03end\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\x03end\x12*\n" +
+	"\x05total\x18\x03 \x01(\v2\x14.auditor.TrendCountsR\x05total\x12,\n" +
+	"\x06groups\x18\x04 \x03(\v2\x14.auditor.TrendCountsR\x06groups\"C\n" +
+	"\x11GetTrendsResponse\x12.\n" +
+	"\abuckets\x18\x01 \x03(\v2\x14.auditor.TrendBucketR\abuckets2\xd8\a\n" +
+	"\x0eClusterAuditor\x12P\n" +
+	"\x0eGetHealthScore\x12\x0e.auditor.Empty\x1a\x14.auditor.HealthScore\"\x18\x82\xd3\xe4\x93\x02\x12\x12\x10/v1/health-score\x12h\n" +
+	"\rGetTeamScores\x12\x1d.auditor.GetTeamScoresRequest\x1a\x1e.auditor.GetTeamScoresResponse\"\x18\x82\xd3\xe4\x93\x02\x12\x12\x10/v1/teams/scores\x12N\n" +
+	"\vGetFindings\x12\x0e.auditor.Empty\x1a\x19.auditor.FindingsResponse\"\x14\x82\xd3\xe4\x93\x02\x0e\x12\f/v1/findings\x12f\n" +
+	"\fListFindings\x12\x1c.auditor.ListFindingsRequest\x1a\x1d.auditor.ListFindingsResponse\"\x19\x82\xd3\xe4\x93\x02\x13\x12\x11/v1/findings:list\x12_\n" +
+	"\x0eStreamFindings\x12\x1c.auditor.ListFindingsRequest\x1a\x10.auditor.Finding\"\x1b\x82\xd3\xe4\x93\x02\x15\x12\x13/v1/findings:stream0\x01\x12b\n" +
+	"\fTriggerAudit\x12\x1c.auditor.TriggerAuditRequest\x1a\x1d.auditor.TriggerAuditResponse\"\x15\x82\xd3\xe4\x93\x02\x0f:\x01*\"\n" +
+	"/v1/audits\x12i\n" +
+	"\x0fCreateException\x12\x1f.auditor.CreateExceptionRequest\x1a\x12.auditor.Exception\"!\x82\xd3\xe4\x93\x02\x1b:\texception\"\x0e/v1/exceptions\x12i\n" +
+	"\x0eListExceptions\x12\x1e.auditor.ListExceptionsRequest\x1a\x1f.auditor.ListExceptionsResponse\"\x16\x82\xd3\xe4\x93\x02\x10\x12\x0e/v1/exceptions\x12_\n" +
+	"\x0fDeleteException\x12\x1f.auditor.DeleteExceptionRequest\x1a\x0e.auditor.Empty\"\x1b\x82\xd3\xe4\x93\x02\x15*\x13/v1/exceptions/{id}\x12V\n" +
+	"\tGetTrends\x12\x19.auditor.GetTrendsRequest\x1a\x1a.auditor.GetTrendsResponse\"\x12\x82\xd3\xe4\x93\x02\f\x12\n" +
+	"/v1/trendsB\x1eZ\x1cservices/generated/auditorpbb\x06proto3"
 
 var (
 	file_services_proto_auditor_proto_rawDescOnce sync.Once
@@ -273,24 +1415,74 @@ func file_services_proto_auditor_proto_rawDescGZIP() []byte {
 	return file_services_proto_auditor_proto_rawDescData
 }
 
-var file_services_proto_auditor_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
+var file_services_proto_auditor_proto_msgTypes = make([]protoimpl.MessageInfo, 20)
 var file_services_proto_auditor_proto_goTypes = []any{
-	(*Empty)(nil),            // 0: auditor.Empty
-	(*HealthScore)(nil),      // 1: auditor.HealthScore
-	(*Finding)(nil),          // 2: auditor.Finding
-	(*FindingsResponse)(nil), // 3: auditor.FindingsResponse
+	(*Empty)(nil),                  // 0: auditor.Empty
+	(*HealthScore)(nil),            // 1: auditor.HealthScore
+	(*Finding)(nil),                // 2: auditor.Finding
+	(*FindingsResponse)(nil),       // 3: auditor.FindingsResponse
+	(*ListFindingsRequest)(nil),    // 4: auditor.ListFindingsRequest
+	(*ListFindingsResponse)(nil),   // 5: auditor.ListFindingsResponse
+	(*GetTeamScoresRequest)(nil),   // 6: auditor.GetTeamScoresRequest
+	(*TeamScore)(nil),              // 7: auditor.TeamScore
+	(*GetTeamScoresResponse)(nil),  // 8: auditor.GetTeamScoresResponse
+	(*TriggerAuditRequest)(nil),    // 9: auditor.TriggerAuditRequest
+	(*TriggerAuditResponse)(nil),   // 10: auditor.TriggerAuditResponse
+	(*Exception)(nil),              // 11: auditor.Exception
+	(*CreateExceptionRequest)(nil), // 12: auditor.CreateExceptionRequest
+	(*ListExceptionsRequest)(nil),  // 13: auditor.ListExceptionsRequest
+	(*ListExceptionsResponse)(nil), // 14: auditor.ListExceptionsResponse
+	(*DeleteExceptionRequest)(nil), // 15: auditor.DeleteExceptionRequest
+	(*GetTrendsRequest)(nil),       // 16: auditor.GetTrendsRequest
+	(*TrendCounts)(nil),            // 17: auditor.TrendCounts
+	(*TrendBucket)(nil),            // 18: auditor.TrendBucket
+	(*GetTrendsResponse)(nil),      // 19: auditor.GetTrendsResponse
+	(*timestamppb.Timestamp)(nil),  // 20: google.protobuf.Timestamp
+	(*durationpb.Duration)(nil),    // 21: google.protobuf.Duration
 }
 var file_services_proto_auditor_proto_depIdxs = []int32{
-	2, // 0: auditor.FindingsResponse.findings:type_name -> auditor.Finding
-	0, // 1: auditor.ClusterAuditor.GetHealthScore:input_type -> auditor.Empty
-	0, // 2: auditor.ClusterAuditor.GetFindings:input_type -> auditor.Empty
-	1, // 3: auditor.ClusterAuditor.GetHealthScore:output_type -> auditor.HealthScore
-	3, // 4: auditor.ClusterAuditor.GetFindings:output_type -> auditor.FindingsResponse
-	3, // [3:5] is the sub-list for method output_type
-	1, // [1:3] is the sub-list for method input_type
-	1, // [1:1] is the sub-list for extension type_name
-	1, // [1:1] is the sub-list for extension extendee
-	0, // [0:1] is the sub-list for field type_name
+	20, // 0: auditor.Finding.first_seen:type_name -> google.protobuf.Timestamp
+	20, // 1: auditor.Finding.last_seen:type_name -> google.protobuf.Timestamp
+	2,  // 2: auditor.FindingsResponse.findings:type_name -> auditor.Finding
+	2,  // 3: auditor.ListFindingsResponse.findings:type_name -> auditor.Finding
+	7,  // 4: auditor.GetTeamScoresResponse.scores:type_name -> auditor.TeamScore
+	20, // 5: auditor.Exception.expires_at:type_name -> google.protobuf.Timestamp
+	20, // 6: auditor.Exception.created_at:type_name -> google.protobuf.Timestamp
+	11, // 7: auditor.CreateExceptionRequest.exception:type_name -> auditor.Exception
+	11, // 8: auditor.ListExceptionsResponse.exceptions:type_name -> auditor.Exception
+	20, // 9: auditor.GetTrendsRequest.start:type_name -> google.protobuf.Timestamp
+	20, // 10: auditor.GetTrendsRequest.end:type_name -> google.protobuf.Timestamp
+	21, // 11: auditor.TrendCounts.mean_time_to_resolve:type_name -> google.protobuf.Duration
+	20, // 12: auditor.TrendBucket.start:type_name -> google.protobuf.Timestamp
+	20, // 13: auditor.TrendBucket.end:type_name -> google.protobuf.Timestamp
+	17, // 14: auditor.TrendBucket.total:type_name -> auditor.TrendCounts
+	17, // 15: auditor.TrendBucket.groups:type_name -> auditor.TrendCounts
+	18, // 16: auditor.GetTrendsResponse.buckets:type_name -> auditor.TrendBucket
+	0,  // 17: auditor.ClusterAuditor.GetHealthScore:input_type -> auditor.Empty
+	6,  // 18: auditor.ClusterAuditor.GetTeamScores:input_type -> auditor.GetTeamScoresRequest
+	0,  // 19: auditor.ClusterAuditor.GetFindings:input_type -> auditor.Empty
+	4,  // 20: auditor.ClusterAuditor.ListFindings:input_type -> auditor.ListFindingsRequest
+	4,  // 21: auditor.ClusterAuditor.StreamFindings:input_type -> auditor.ListFindingsRequest
+	9,  // 22: auditor.ClusterAuditor.TriggerAudit:input_type -> auditor.TriggerAuditRequest
+	12, // 23: auditor.ClusterAuditor.CreateException:input_type -> auditor.CreateExceptionRequest
+	13, // 24: auditor.ClusterAuditor.ListExceptions:input_type -> auditor.ListExceptionsRequest
+	15, // 25: auditor.ClusterAuditor.DeleteException:input_type -> auditor.DeleteExceptionRequest
+	16, // 26: auditor.ClusterAuditor.GetTrends:input_type -> auditor.GetTrendsRequest
+	1,  // 27: auditor.ClusterAuditor.GetHealthScore:output_type -> auditor.HealthScore
+	8,  // 28: auditor.ClusterAuditor.GetTeamScores:output_type -> auditor.GetTeamScoresResponse
+	3,  // 29: auditor.ClusterAuditor.GetFindings:output_type -> auditor.FindingsResponse
+	5,  // 30: auditor.ClusterAuditor.ListFindings:output_type -> auditor.ListFindingsResponse
+	2,  // 31: auditor.ClusterAuditor.StreamFindings:output_type -> auditor.Finding
+	10, // 32: auditor.ClusterAuditor.TriggerAudit:output_type -> auditor.TriggerAuditResponse
+	11, // 33: auditor.ClusterAuditor.CreateException:output_type -> auditor.Exception
+	14, // 34: auditor.ClusterAuditor.ListExceptions:output_type -> auditor.ListExceptionsResponse
+	0,  // 35: auditor.ClusterAuditor.DeleteException:output_type -> auditor.Empty
+	19, // 36: auditor.ClusterAuditor.GetTrends:output_type -> auditor.GetTrendsResponse
+	27, // [27:37] is the sub-list for method output_type
+	17, // [17:27] is the sub-list for method input_type
+	17, // [17:17] is the sub-list for extension type_name
+	17, // [17:17] is the sub-list for extension extendee
+	0,  // [0:17] is the sub-list for field type_name
 }
 
 func init() { file_services_proto_auditor_proto_init() }
@@ -304,7 +1496,7 @@ func file_services_proto_auditor_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_services_proto_auditor_proto_rawDesc), len(file_services_proto_auditor_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   4,
+			NumMessages:   20,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
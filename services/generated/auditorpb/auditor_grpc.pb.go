@@ -19,8 +19,16 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	ClusterAuditor_GetHealthScore_FullMethodName = "/auditor.ClusterAuditor/GetHealthScore"
-	ClusterAuditor_GetFindings_FullMethodName    = "/auditor.ClusterAuditor/GetFindings"
+	ClusterAuditor_GetHealthScore_FullMethodName  = "/auditor.ClusterAuditor/GetHealthScore"
+	ClusterAuditor_GetTeamScores_FullMethodName   = "/auditor.ClusterAuditor/GetTeamScores"
+	ClusterAuditor_GetFindings_FullMethodName     = "/auditor.ClusterAuditor/GetFindings"
+	ClusterAuditor_ListFindings_FullMethodName    = "/auditor.ClusterAuditor/ListFindings"
+	ClusterAuditor_StreamFindings_FullMethodName  = "/auditor.ClusterAuditor/StreamFindings"
+	ClusterAuditor_TriggerAudit_FullMethodName    = "/auditor.ClusterAuditor/TriggerAudit"
+	ClusterAuditor_CreateException_FullMethodName = "/auditor.ClusterAuditor/CreateException"
+	ClusterAuditor_ListExceptions_FullMethodName  = "/auditor.ClusterAuditor/ListExceptions"
+	ClusterAuditor_DeleteException_FullMethodName = "/auditor.ClusterAuditor/DeleteException"
+	ClusterAuditor_GetTrends_FullMethodName       = "/auditor.ClusterAuditor/GetTrends"
 )
 
 // ClusterAuditorClient is the client API for ClusterAuditor service.
@@ -28,7 +36,17 @@ const (
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type ClusterAuditorClient interface {
 	GetHealthScore(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*HealthScore, error)
+	// GetTeamScores scores each team only on the findings of its own namespaces
+	GetTeamScores(ctx context.Context, in *GetTeamScoresRequest, opts ...grpc.CallOption) (*GetTeamScoresResponse, error)
 	GetFindings(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*FindingsResponse, error)
+	ListFindings(ctx context.Context, in *ListFindingsRequest, opts ...grpc.CallOption) (*ListFindingsResponse, error)
+	// StreamFindings sends the matching findings one by one; paging fields are ignored
+	StreamFindings(ctx context.Context, in *ListFindingsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Finding], error)
+	TriggerAudit(ctx context.Context, in *TriggerAuditRequest, opts ...grpc.CallOption) (*TriggerAuditResponse, error)
+	CreateException(ctx context.Context, in *CreateExceptionRequest, opts ...grpc.CallOption) (*Exception, error)
+	ListExceptions(ctx context.Context, in *ListExceptionsRequest, opts ...grpc.CallOption) (*ListExceptionsResponse, error)
+	DeleteException(ctx context.Context, in *DeleteExceptionRequest, opts ...grpc.CallOption) (*Empty, error)
+	GetTrends(ctx context.Context, in *GetTrendsRequest, opts ...grpc.CallOption) (*GetTrendsResponse, error)
 }
 
 type clusterAuditorClient struct {
@@ -49,6 +67,16 @@ func (c *clusterAuditorClient) GetHealthScore(ctx context.Context, in *Empty, op
 	return out, nil
 }
 
+func (c *clusterAuditorClient) GetTeamScores(ctx context.Context, in *GetTeamScoresRequest, opts ...grpc.CallOption) (*GetTeamScoresResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetTeamScoresResponse)
+	err := c.cc.Invoke(ctx, ClusterAuditor_GetTeamScores_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *clusterAuditorClient) GetFindings(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*FindingsResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(FindingsResponse)
@@ -59,12 +87,101 @@ func (c *clusterAuditorClient) GetFindings(ctx context.Context, in *Empty, opts
 	return out, nil
 }
 
+func (c *clusterAuditorClient) ListFindings(ctx context.Context, in *ListFindingsRequest, opts ...grpc.CallOption) (*ListFindingsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListFindingsResponse)
+	err := c.cc.Invoke(ctx, ClusterAuditor_ListFindings_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *clusterAuditorClient) StreamFindings(ctx context.Context, in *ListFindingsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Finding], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &ClusterAuditor_ServiceDesc.Streams[0], ClusterAuditor_StreamFindings_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ListFindingsRequest, Finding]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ClusterAuditor_StreamFindingsClient = grpc.ServerStreamingClient[Finding]
+
+func (c *clusterAuditorClient) TriggerAudit(ctx context.Context, in *TriggerAuditRequest, opts ...grpc.CallOption) (*TriggerAuditResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(TriggerAuditResponse)
+	err := c.cc.Invoke(ctx, ClusterAuditor_TriggerAudit_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *clusterAuditorClient) CreateException(ctx context.Context, in *CreateExceptionRequest, opts ...grpc.CallOption) (*Exception, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Exception)
+	err := c.cc.Invoke(ctx, ClusterAuditor_CreateException_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *clusterAuditorClient) ListExceptions(ctx context.Context, in *ListExceptionsRequest, opts ...grpc.CallOption) (*ListExceptionsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListExceptionsResponse)
+	err := c.cc.Invoke(ctx, ClusterAuditor_ListExceptions_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *clusterAuditorClient) DeleteException(ctx context.Context, in *DeleteExceptionRequest, opts ...grpc.CallOption) (*Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, ClusterAuditor_DeleteException_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *clusterAuditorClient) GetTrends(ctx context.Context, in *GetTrendsRequest, opts ...grpc.CallOption) (*GetTrendsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetTrendsResponse)
+	err := c.cc.Invoke(ctx, ClusterAuditor_GetTrends_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // ClusterAuditorServer is the server API for ClusterAuditor service.
 // All implementations must embed UnimplementedClusterAuditorServer
 // for forward compatibility.
 type ClusterAuditorServer interface {
 	GetHealthScore(context.Context, *Empty) (*HealthScore, error)
+	// GetTeamScores scores each team only on the findings of its own namespaces
+	GetTeamScores(context.Context, *GetTeamScoresRequest) (*GetTeamScoresResponse, error)
 	GetFindings(context.Context, *Empty) (*FindingsResponse, error)
+	ListFindings(context.Context, *ListFindingsRequest) (*ListFindingsResponse, error)
+	// StreamFindings sends the matching findings one by one; paging fields are ignored
+	StreamFindings(*ListFindingsRequest, grpc.ServerStreamingServer[Finding]) error
+	TriggerAudit(context.Context, *TriggerAuditRequest) (*TriggerAuditResponse, error)
+	CreateException(context.Context, *CreateExceptionRequest) (*Exception, error)
+	ListExceptions(context.Context, *ListExceptionsRequest) (*ListExceptionsResponse, error)
+	DeleteException(context.Context, *DeleteExceptionRequest) (*Empty, error)
+	GetTrends(context.Context, *GetTrendsRequest) (*GetTrendsResponse, error)
 	mustEmbedUnimplementedClusterAuditorServer()
 }
 
@@ -78,9 +195,33 @@ type UnimplementedClusterAuditorServer struct{}
 func (UnimplementedClusterAuditorServer) GetHealthScore(context.Context, *Empty) (*HealthScore, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetHealthScore not implemented")
 }
+func (UnimplementedClusterAuditorServer) GetTeamScores(context.Context, *GetTeamScoresRequest) (*GetTeamScoresResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetTeamScores not implemented")
+}
 func (UnimplementedClusterAuditorServer) GetFindings(context.Context, *Empty) (*FindingsResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetFindings not implemented")
 }
+func (UnimplementedClusterAuditorServer) ListFindings(context.Context, *ListFindingsRequest) (*ListFindingsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListFindings not implemented")
+}
+func (UnimplementedClusterAuditorServer) StreamFindings(*ListFindingsRequest, grpc.ServerStreamingServer[Finding]) error {
+	return status.Errorf(codes.Unimplemented, "method StreamFindings not implemented")
+}
+func (UnimplementedClusterAuditorServer) TriggerAudit(context.Context, *TriggerAuditRequest) (*TriggerAuditResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method TriggerAudit not implemented")
+}
+func (UnimplementedClusterAuditorServer) CreateException(context.Context, *CreateExceptionRequest) (*Exception, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateException not implemented")
+}
+func (UnimplementedClusterAuditorServer) ListExceptions(context.Context, *ListExceptionsRequest) (*ListExceptionsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListExceptions not implemented")
+}
+func (UnimplementedClusterAuditorServer) DeleteException(context.Context, *DeleteExceptionRequest) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteException not implemented")
+}
+func (UnimplementedClusterAuditorServer) GetTrends(context.Context, *GetTrendsRequest) (*GetTrendsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetTrends not implemented")
+}
 func (UnimplementedClusterAuditorServer) mustEmbedUnimplementedClusterAuditorServer() {}
 func (UnimplementedClusterAuditorServer) testEmbeddedByValue()                        {}
 
@@ -120,6 +261,24 @@ func _ClusterAuditor_GetHealthScore_Handler(srv interface{}, ctx context.Context
 	return interceptor(ctx, in, info, handler)
 }
 
+func _ClusterAuditor_GetTeamScores_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetTeamScoresRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ClusterAuditorServer).GetTeamScores(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ClusterAuditor_GetTeamScores_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ClusterAuditorServer).GetTeamScores(ctx, req.(*GetTeamScoresRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _ClusterAuditor_GetFindings_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(Empty)
 	if err := dec(in); err != nil {
@@ -138,6 +297,125 @@ func _ClusterAuditor_GetFindings_Handler(srv interface{}, ctx context.Context, d
 	return interceptor(ctx, in, info, handler)
 }
 
+func _ClusterAuditor_ListFindings_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListFindingsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ClusterAuditorServer).ListFindings(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ClusterAuditor_ListFindings_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ClusterAuditorServer).ListFindings(ctx, req.(*ListFindingsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ClusterAuditor_StreamFindings_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ListFindingsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ClusterAuditorServer).StreamFindings(m, &grpc.GenericServerStream[ListFindingsRequest, Finding]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ClusterAuditor_StreamFindingsServer = grpc.ServerStreamingServer[Finding]
+
+func _ClusterAuditor_TriggerAudit_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TriggerAuditRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ClusterAuditorServer).TriggerAudit(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ClusterAuditor_TriggerAudit_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ClusterAuditorServer).TriggerAudit(ctx, req.(*TriggerAuditRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ClusterAuditor_CreateException_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateExceptionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ClusterAuditorServer).CreateException(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ClusterAuditor_CreateException_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ClusterAuditorServer).CreateException(ctx, req.(*CreateExceptionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ClusterAuditor_ListExceptions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListExceptionsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ClusterAuditorServer).ListExceptions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ClusterAuditor_ListExceptions_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ClusterAuditorServer).ListExceptions(ctx, req.(*ListExceptionsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ClusterAuditor_DeleteException_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteExceptionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ClusterAuditorServer).DeleteException(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ClusterAuditor_DeleteException_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ClusterAuditorServer).DeleteException(ctx, req.(*DeleteExceptionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ClusterAuditor_GetTrends_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetTrendsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ClusterAuditorServer).GetTrends(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ClusterAuditor_GetTrends_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ClusterAuditorServer).GetTrends(ctx, req.(*GetTrendsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // ClusterAuditor_ServiceDesc is the grpc.ServiceDesc for ClusterAuditor service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -149,11 +427,45 @@ var ClusterAuditor_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetHealthScore",
 			Handler:    _ClusterAuditor_GetHealthScore_Handler,
 		},
+		{
+			MethodName: "GetTeamScores",
+			Handler:    _ClusterAuditor_GetTeamScores_Handler,
+		},
 		{
 			MethodName: "GetFindings",
 			Handler:    _ClusterAuditor_GetFindings_Handler,
 		},
+		{
+			MethodName: "ListFindings",
+			Handler:    _ClusterAuditor_ListFindings_Handler,
+		},
+		{
+			MethodName: "TriggerAudit",
+			Handler:    _ClusterAuditor_TriggerAudit_Handler,
+		},
+		{
+			MethodName: "CreateException",
+			Handler:    _ClusterAuditor_CreateException_Handler,
+		},
+		{
+			MethodName: "ListExceptions",
+			Handler:    _ClusterAuditor_ListExceptions_Handler,
+		},
+		{
+			MethodName: "DeleteException",
+			Handler:    _ClusterAuditor_DeleteException_Handler,
+		},
+		{
+			MethodName: "GetTrends",
+			Handler:    _ClusterAuditor_GetTrends_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamFindings",
+			Handler:       _ClusterAuditor_StreamFindings_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "services/proto/auditor.proto",
 }
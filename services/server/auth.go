@@ -0,0 +1,233 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"strings"
+
+	"goprojects/services/generated/auditorpb"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Role is the permission level granted to an API token. Each role includes the
+// permissions of the roles before it.
+type Role int
+
+const (
+	RoleRead    Role = iota + 1 // read findings and scores
+	RoleTrigger                 // additionally start audits
+	RoleAdmin                   // everything, including managing the server's data
+)
+
+func ParseRole(s string) (Role, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "read", "readonly", "read-only":
+		return RoleRead, nil
+	case "trigger":
+		return RoleTrigger, nil
+	case "admin":
+		return RoleAdmin, nil
+	}
+	return 0, fmt.Errorf("unknown role %q (want read, trigger or admin)", s)
+}
+
+func (r Role) String() string {
+	switch r {
+	case RoleRead:
+		return "read"
+	case RoleTrigger:
+		return "trigger"
+	case RoleAdmin:
+		return "admin"
+	}
+	return "none"
+}
+
+// MethodRoles lists the minimum role for each RPC. Methods that are not listed
+// require RoleAdmin, so new RPCs are locked down until they are classified.
+var MethodRoles = map[string]Role{
+	auditorpb.ClusterAuditor_GetHealthScore_FullMethodName:           RoleRead,
+	auditorpb.ClusterAuditor_GetTeamScores_FullMethodName:            RoleRead,
+	auditorpb.ClusterAuditor_GetFindings_FullMethodName:              RoleRead,
+	auditorpb.ClusterAuditor_ListFindings_FullMethodName:             RoleRead,
+	auditorpb.ClusterAuditor_StreamFindings_FullMethodName:           RoleRead,
+	auditorpb.ClusterAuditor_TriggerAudit_FullMethodName:             RoleTrigger,
+	auditorpb.ClusterAuditor_GetTrends_FullMethodName:                RoleRead,
+	auditorpb.ClusterAuditor_ListExceptions_FullMethodName:           RoleRead,
+	auditorpb.ClusterAuditor_CreateException_FullMethodName:          RoleAdmin,
+	auditorpb.ClusterAuditor_DeleteException_FullMethodName:          RoleAdmin,
+	"/grpc.reflection.v1.ServerReflection/ServerReflectionInfo":      RoleRead,
+	"/grpc.reflection.v1alpha.ServerReflection/ServerReflectionInfo": RoleRead,
+}
+
+// PublicMethods can be called without credentials, so kubelet probes keep working
+var PublicMethods = map[string]bool{
+	healthpb.Health_Check_FullMethodName: true,
+	healthpb.Health_List_FullMethodName:  true,
+	healthpb.Health_Watch_FullMethodName: true,
+}
+
+// Principal is the authenticated caller of an RPC
+type Principal struct {
+	Name string
+	Role Role
+	Team string // when set, the caller only sees the findings of this team
+}
+
+type principalKey struct{}
+
+// PrincipalFromContext returns the caller authenticated by the TokenAuth interceptors
+func PrincipalFromContext(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(Principal)
+	return p, ok
+}
+
+// TokenAuth authenticates callers by bearer token or API key and authorizes
+// them per method using MethodRoles
+type TokenAuth struct {
+	tokens map[[sha256.Size]byte]Principal
+}
+
+// LoadTokenFile reads a static token file with one "token,name,role[,team]" entry
+// per line. Empty lines and lines starting with # are ignored.
+func LoadTokenFile(path string) (*TokenAuth, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open token file: %w", err)
+	}
+	defer file.Close()
+
+	auth := &TokenAuth{tokens: map[[sha256.Size]byte]Principal{}}
+	scanner := bufio.NewScanner(file)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		parts := strings.Split(text, ",")
+		token := strings.TrimSpace(parts[0])
+		if len(parts) < 3 || len(parts) > 4 || token == "" {
+			return nil, fmt.Errorf("token file line %d: want token,name,role[,team]", line)
+		}
+		role, err := ParseRole(parts[2])
+		if err != nil {
+			return nil, fmt.Errorf("token file line %d: %w", line, err)
+		}
+		p := Principal{Name: strings.TrimSpace(parts[1]), Role: role}
+		if len(parts) == 4 {
+			p.Team = strings.TrimSpace(parts[3])
+		}
+		auth.tokens[sha256.Sum256([]byte(token))] = p
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read token file: %w", err)
+	}
+	return auth, nil
+}
+
+func tokenFromMetadata(md metadata.MD) string {
+	for _, v := range md.Get("authorization") {
+		scheme, token, ok := strings.Cut(strings.TrimSpace(v), " ")
+		if ok && strings.EqualFold(scheme, "Bearer") {
+			return strings.TrimSpace(token)
+		}
+	}
+	if keys := md.Get("x-api-key"); len(keys) > 0 {
+		return strings.TrimSpace(keys[0])
+	}
+	return ""
+}
+
+// Authorize authenticates the caller in ctx and checks it may call fullMethod
+func (t *TokenAuth) Authorize(ctx context.Context, fullMethod string) (context.Context, error) {
+	if PublicMethods[fullMethod] {
+		return ctx, nil
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	token := tokenFromMetadata(md)
+	if token == "" {
+		return nil, status.Error(codes.Unauthenticated, "missing bearer token or API key")
+	}
+	principal, ok := t.tokens[sha256.Sum256([]byte(token))]
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "invalid token")
+	}
+
+	required, ok := MethodRoles[fullMethod]
+	if !ok {
+		required = RoleAdmin
+	}
+	if principal.Role < required {
+		return nil, status.Errorf(codes.PermissionDenied, "%s requires the %s role", fullMethod, required)
+	}
+	return context.WithValue(ctx, principalKey{}, principal), nil
+}
+
+func (t *TokenAuth) UnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx, err := t.Authorize(ctx, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+func (t *TokenAuth) StreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := t.Authorize(ss.Context(), info.FullMethod)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &authedStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+type authedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authedStream) Context() context.Context {
+	return s.ctx
+}
+
+// ServerTLSConfig loads the server certificate from PEM files. When clientCAFile is
+// set, clients must present a certificate signed by that CA (mutual TLS).
+func ServerTLSConfig(certFile, keyFile, clientCAFile string) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load server certificate: %w", err)
+	}
+	cfg := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+
+	if clientCAFile != "" {
+		pem, err := os.ReadFile(clientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read client CA: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", clientCAFile)
+		}
+		cfg.ClientCAs = pool
+		cfg.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	return cfg, nil
+}
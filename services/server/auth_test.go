@@ -0,0 +1,69 @@
+package server_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"goprojects/services/generated/auditorpb"
+	"goprojects/services/server"
+)
+
+func loadTokens(t *testing.T) *server.TokenAuth {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "tokens.csv")
+	content := "# token,name,role\nreader-token,dashboard,read\n admin-token ,ops,admin\r\n"
+	require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+
+	auth, err := server.LoadTokenFile(path)
+	require.NoError(t, err)
+	return auth
+}
+
+func withMD(kv ...string) context.Context {
+	return metadata.NewIncomingContext(context.Background(), metadata.Pairs(kv...))
+}
+
+func TestTokenAuthorize(t *testing.T) {
+	auth := loadTokens(t)
+	read := auditorpb.ClusterAuditor_GetFindings_FullMethodName
+
+	ctx, err := auth.Authorize(withMD("authorization", "Bearer reader-token"), read)
+	require.NoError(t, err)
+	p, ok := server.PrincipalFromContext(ctx)
+	require.True(t, ok)
+	require.Equal(t, "dashboard", p.Name)
+	require.Equal(t, server.RoleRead, p.Role)
+
+	_, err = auth.Authorize(withMD("x-api-key", "admin-token"), read)
+	require.NoError(t, err)
+	// The scheme is case-insensitive
+	_, err = auth.Authorize(withMD("authorization", "bearer reader-token"), read)
+	require.NoError(t, err)
+
+	_, err = auth.Authorize(context.Background(), read)
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	_, err = auth.Authorize(withMD("authorization", "Bearer wrong"), read)
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	// Methods without an explicit role require admin
+	_, err = auth.Authorize(withMD("authorization", "Bearer reader-token"), "/auditor.ClusterAuditor/Unknown")
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+	_, err = auth.Authorize(withMD("authorization", "Bearer admin-token"), "/auditor.ClusterAuditor/Unknown")
+	require.NoError(t, err)
+}
+
+func TestLoadTokenFileRejectsBadRole(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tokens.csv")
+	require.NoError(t, os.WriteFile(path, []byte("tok,someone,root\n"), 0600))
+
+	_, err := server.LoadTokenFile(path)
+	require.Error(t, err)
+}
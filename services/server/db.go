@@ -1,10 +1,13 @@
 package server
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
+	"time"
 
 	"goprojects/findings"
+	"goprojects/services/convert"
 
 	_ "github.com/mattn/go-sqlite3"
 )
@@ -33,14 +36,311 @@ func InitDB(path string) (*sql.DB, error) {
 		return nil, fmt.Errorf("failed to create table: %w", err)
 	}
 
+	// Columns added after the initial schema, for databases created by older versions
+	columns := []struct{ name, colType string }{
+		{"rule_id", "TEXT"},
+		{"severity", "TEXT"},
+		{"cluster", "TEXT"},
+		{"fingerprint", "TEXT"},
+		{"first_seen", "DATETIME"},
+		{"last_seen", "DATETIME"},
+		{"status", "TEXT DEFAULT 'open'"},
+		{"resolved_at", "DATETIME"},
+		{"team", "TEXT"},
+	}
+	for _, c := range columns {
+		if err := addColumnIfMissing(db, "findings", c.name, c.colType); err != nil {
+			return nil, err
+		}
+	}
+
+	_, err = db.Exec(`
+	CREATE INDEX IF NOT EXISTS idx_findings_fingerprint ON findings (fingerprint);
+	CREATE INDEX IF NOT EXISTS idx_findings_scope ON findings (rule_id, cluster, namespace);
+	CREATE INDEX IF NOT EXISTS idx_findings_team ON findings (team);
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create indexes: %w", err)
+	}
+
+	_, err = db.Exec(`
+	CREATE TABLE IF NOT EXISTS exceptions (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		namespace TEXT NOT NULL DEFAULT '',
+		kind TEXT NOT NULL DEFAULT '',
+		resource TEXT NOT NULL DEFAULT '',
+		rule_id TEXT NOT NULL DEFAULT '',
+		reason TEXT NOT NULL,
+		owner TEXT NOT NULL,
+		expires_at DATETIME,
+		created_at DATETIME NOT NULL,
+		created_by TEXT NOT NULL
+	);
+	CREATE TABLE IF NOT EXISTS exception_events (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		exception_id INTEGER NOT NULL,
+		action TEXT NOT NULL,
+		actor TEXT NOT NULL,
+		at DATETIME NOT NULL,
+		exception TEXT NOT NULL
+	);
+	CREATE TABLE IF NOT EXISTS seeds (
+		name TEXT PRIMARY KEY,
+		at DATETIME NOT NULL
+	);
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create exception tables: %w", err)
+	}
+	if err := seedDefaultExceptions(db); err != nil {
+		return nil, err
+	}
+
+	if err := createRunTables(db); err != nil {
+		return nil, err
+	}
+
 	return db, nil
 }
 
-func InsertFinding(db *sql.DB, f findings.Finding) error {
+func addColumnIfMissing(db *sql.DB, table, column, colType string) error {
+	rows, err := db.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return fmt.Errorf("failed to inspect table %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			cid       int
+			name      string
+			typ       string
+			notNull   int
+			dfltValue sql.NullString
+			pk        int
+		)
+		if err := rows.Scan(&cid, &name, &typ, &notNull, &dfltValue, &pk); err != nil {
+			return err
+		}
+		if name == column {
+			return nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	_, err = db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, colType))
+	if err != nil {
+		return fmt.Errorf("failed to add column %s.%s: %w", table, column, err)
+	}
+	return nil
+}
+
+// findingColumns is the column list matching scanFinding
+const findingColumns = `COALESCE(namespace, ''), COALESCE(resource, ''), COALESCE(kind, ''), COALESCE(container, ''),
+	COALESCE(issue, ''), COALESCE(suggestion, ''), COALESCE(subjects, ''), COALESCE(rule_id, ''),
+	COALESCE(severity, ''), COALESCE(cluster, ''), COALESCE(fingerprint, ''), first_seen, last_seen,
+	COALESCE(status, 'open'), COALESCE(team, '')`
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanFinding(s scanner) (findings.Finding, error) {
+	var (
+		r                   convert.Row
+		firstSeen, lastSeen sql.NullTime
+	)
+	err := s.Scan(&r.Namespace, &r.Resource, &r.Kind, &r.Container, &r.Issue, &r.Suggestion, &r.Subjects,
+		&r.RuleID, &r.Severity, &r.Cluster, &r.Fingerprint, &firstSeen, &lastSeen, &r.Status, &r.Team)
+	if err != nil {
+		return findings.Finding{}, err
+	}
+	r.FirstSeen = firstSeen.Time
+	r.LastSeen = lastSeen.Time
+	return convert.FromRow(r), nil
+}
+
+// FindingFilter selects stored findings; empty fields match everything
+type FindingFilter struct {
+	Namespace string
+	Cluster   string
+	RuleID    string
+	Team      string
+}
+
+// ListOpenFindings returns all open findings, most recently seen first
+func ListOpenFindings(ctx context.Context, db *sql.DB) ([]findings.Finding, error) {
+	return QueryOpenFindings(ctx, db, FindingFilter{})
+}
+
+// QueryOpenFindings returns the open findings matching the filter, most recently
+// seen first
+func QueryOpenFindings(ctx context.Context, db *sql.DB, filter FindingFilter) ([]findings.Finding, error) {
+	query := `SELECT ` + findingColumns + ` FROM findings WHERE COALESCE(status, 'open') = 'open'`
+	var args []any
+	for _, c := range []struct{ column, value string }{
+		{"namespace", filter.Namespace},
+		{"cluster", filter.Cluster},
+		{"rule_id", filter.RuleID},
+		{"team", filter.Team},
+	} {
+		if c.value != "" {
+			query += ` AND COALESCE(` + c.column + `, '') = ?`
+			args = append(args, c.value)
+		}
+	}
+
+	rows, err := db.QueryContext(ctx, query+` ORDER BY last_seen DESC, created_at DESC`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []findings.Finding
+	for rows.Next() {
+		f, err := scanFinding(rows)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, f)
+	}
+	return results, rows.Err()
+}
+
+// execer is satisfied by both *sql.DB and *sql.Tx
+type execer interface {
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
+func insertFinding(db execer, f findings.Finding) error {
+	r := convert.ToRow(f)
 	_, err := db.Exec(`
-		INSERT INTO findings (namespace, resource, kind, container, issue, suggestion, subjects)
-		VALUES (?, ?, ?, ?, ?, ?)`,
-		f.Namespace, f.Resource, f.Kind, f.Container, f.Issue, f.Suggestion, f.Subjects,
+		INSERT INTO findings (namespace, resource, kind, container, issue, suggestion, subjects,
+			rule_id, severity, cluster, fingerprint, first_seen, last_seen, status, team)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		r.Namespace, r.Resource, r.Kind, r.Container, r.Issue, r.Suggestion, r.Subjects,
+		r.RuleID, r.Severity, r.Cluster, r.Fingerprint, r.FirstSeen, r.LastSeen, r.Status, r.Team,
 	)
 	return err
 }
+
+// InsertFinding stores a single finding as newly opened
+func InsertFinding(db *sql.DB, f findings.Finding) error {
+	now := time.Now().UTC().Truncate(time.Second)
+	if f.Fingerprint == "" {
+		f.Fingerprint = findings.ComputeFingerprint(f)
+	}
+	f.FirstSeen, f.LastSeen, f.Status = now, now, findings.StatusOpen
+	return insertFinding(db, f)
+}
+
+// ReplaceFindings records the complete, current set of findings of one rule and
+// returns the findings that were not open before. Findings seen again keep their
+// first_seen time, findings that disappeared are marked resolved. Every row is one
+// open-to-resolved lifecycle of a finding, which is the history GetTrends reads.
+//
+// An empty namespace covers the rule's findings everywhere in the cluster; otherwise
+// only the findings of that namespace and the cluster-scoped ones are covered,
+// which is what a check run against that namespace reports.
+func ReplaceFindings(db *sql.DB, cluster, namespace, ruleID string, fs []findings.Finding) ([]findings.Finding, error) {
+	now := time.Now().UTC().Truncate(time.Second)
+
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `SELECT id, COALESCE(fingerprint, ''), COALESCE(status, 'open') FROM findings WHERE rule_id = ? AND COALESCE(cluster, '') = ?`
+	args := []any{ruleID, cluster}
+	if namespace != "" {
+		query += ` AND (namespace = ? OR namespace = '')`
+		args = append(args, namespace)
+	}
+	// Ordered by id so the latest lifecycle of a fingerprint wins
+	rows, err := tx.Query(query+` ORDER BY id`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load stored findings: %w", err)
+	}
+	type stored struct {
+		id     int64
+		status string
+		seen   bool
+	}
+	existing := map[string]*stored{}
+	var resolve []int64 // includes rows stored before fingerprints existed
+	for rows.Next() {
+		var (
+			s           stored
+			fingerprint string
+		)
+		if err := rows.Scan(&s.id, &fingerprint, &s.status); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if fingerprint == "" {
+			resolve = append(resolve, s.id)
+			continue
+		}
+		existing[fingerprint] = &s
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var opened []findings.Finding
+	for _, f := range fs {
+		if f.Fingerprint == "" {
+			f.Fingerprint = findings.ComputeFingerprint(f)
+		}
+		r := convert.ToRow(f)
+
+		s, ok := existing[f.Fingerprint]
+		switch {
+		case !ok:
+			f.FirstSeen, f.LastSeen, f.Status = now, now, findings.StatusOpen
+			if err := insertFinding(tx, f); err != nil {
+				return nil, fmt.Errorf("failed to insert finding: %w", err)
+			}
+			existing[f.Fingerprint] = &stored{status: string(findings.StatusOpen), seen: true}
+			opened = append(opened, f)
+		case s.seen:
+			// duplicate finding within the same run
+		case s.status == string(findings.StatusOpen):
+			// Ownership may move between teams while the finding stays the same
+			_, err = tx.Exec(`UPDATE findings SET last_seen = ?, suggestion = ?, subjects = ?, severity = ?, team = ? WHERE id = ?`,
+				now, r.Suggestion, r.Subjects, r.Severity, r.Team, s.id)
+			s.seen = true
+		default:
+			// Reopened findings start a new lifecycle in a new row, the resolved one
+			// stays as history
+			f.FirstSeen, f.LastSeen, f.Status = now, now, findings.StatusOpen
+			err = insertFinding(tx, f)
+			s.seen = true
+			opened = append(opened, f)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to update finding: %w", err)
+		}
+	}
+
+	for _, s := range existing {
+		if !s.seen && s.status == string(findings.StatusOpen) {
+			resolve = append(resolve, s.id)
+		}
+	}
+	for _, id := range resolve {
+		_, err := tx.Exec(`UPDATE findings SET status = 'resolved', resolved_at = ? WHERE id = ? AND COALESCE(status, 'open') = 'open'`, now, id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve finding: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return opened, nil
+}
@@ -0,0 +1,123 @@
+package server_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"goprojects/findings"
+	"goprojects/services/server"
+)
+
+func finding(ns, resource string) findings.Finding {
+	f := findings.Finding{
+		Namespace: ns,
+		Resource:  resource,
+		Kind:      "Deployment",
+		Container: "app",
+		Issue:     "Image tag is 'nginx:latest'",
+		RuleID:    "latest-image-tag",
+		Severity:  findings.SeverityMedium,
+		Cluster:   "test",
+	}
+	f.Fingerprint = findings.ComputeFingerprint(f)
+	return f
+}
+
+func TestReplaceFindingsLifecycle(t *testing.T) {
+	db, err := server.InitDB(filepath.Join(t.TempDir(), "audit.db"))
+	require.NoError(t, err)
+	defer db.Close()
+	ctx := context.Background()
+
+	opened, err := server.ReplaceFindings(db, "test", "", "latest-image-tag", []findings.Finding{finding("a", "web"), finding("b", "api")})
+	require.NoError(t, err)
+	require.Len(t, opened, 2)
+
+	// Seen again: nothing new, first_seen is kept
+	opened, err = server.ReplaceFindings(db, "test", "", "latest-image-tag", []findings.Finding{finding("a", "web"), finding("b", "api")})
+	require.NoError(t, err)
+	require.Empty(t, opened)
+
+	// Namespace scoped run only resolves findings of that namespace
+	_, err = server.ReplaceFindings(db, "test", "b", "latest-image-tag", nil)
+	require.NoError(t, err)
+
+	open, err := server.ListOpenFindings(ctx, db)
+	require.NoError(t, err)
+	require.Len(t, open, 1)
+	require.Equal(t, "web", open[0].Resource)
+	require.Equal(t, findings.StatusOpen, open[0].Status)
+	require.Equal(t, findings.SeverityMedium, open[0].Severity)
+	require.False(t, open[0].FirstSeen.IsZero())
+
+	// A resolved finding that comes back is reported as opened again
+	opened, err = server.ReplaceFindings(db, "test", "b", "latest-image-tag", []findings.Finding{finding("b", "api")})
+	require.NoError(t, err)
+	require.Len(t, opened, 1)
+
+	// and keeps its resolved lifecycle as history
+	var lifecycles int
+	require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM findings WHERE resource = 'api'`).Scan(&lifecycles))
+	require.Equal(t, 2, lifecycles)
+}
+
+func TestRunFindings(t *testing.T) {
+	db, err := server.InitDB(filepath.Join(t.TempDir(), "audit.db"))
+	require.NoError(t, err)
+	defer db.Close()
+	ctx := context.Background()
+
+	audit := func(fs ...findings.Finding) int64 {
+		run := server.Run{Cluster: "test", StartedAt: time.Now()}
+		require.NoError(t, server.BeginRun(ctx, db, &run))
+		_, err := server.ReplaceFindings(db, "test", "", "latest-image-tag", fs)
+		require.NoError(t, err)
+		require.NoError(t, server.RecordRunFindings(ctx, db, run.ID, "test", fs))
+		run.FinishedAt = time.Now()
+		run.Checks = []server.CheckRun{{CheckID: "latest-image-tag", Findings: len(fs)}}
+		require.NoError(t, server.FinishRun(ctx, db, run))
+		return run.ID
+	}
+	first := audit(finding("a", "web"), finding("b", "api"))
+	second := audit(finding("a", "web"))
+	third := audit(finding("a", "web"), finding("b", "api")) // reopened in a new row
+
+	resources := func(id int64) []string {
+		fs, err := server.RunFindings(ctx, db, id)
+		require.NoError(t, err)
+		var out []string
+		for _, f := range fs {
+			out = append(out, f.Namespace+"/"+f.Resource)
+		}
+		return out
+	}
+	require.Equal(t, []string{"a/web", "b/api"}, resources(first))
+	require.Equal(t, []string{"a/web"}, resources(second))
+	require.Equal(t, []string{"a/web", "b/api"}, resources(third))
+
+	// Later changes to a finding's severity and team leave earlier runs alone
+	escalated := finding("a", "web")
+	escalated.Severity, escalated.Team = findings.SeverityHigh, "payments"
+	fourth := audit(escalated)
+	fs, err := server.RunFindings(ctx, db, third)
+	require.NoError(t, err)
+	require.Equal(t, findings.SeverityMedium, fs[0].Severity)
+	require.Empty(t, fs[0].Team)
+	fs, err = server.RunFindings(ctx, db, fourth)
+	require.NoError(t, err)
+	require.Equal(t, findings.SeverityHigh, fs[0].Severity)
+	require.Equal(t, "payments", fs[0].Team)
+	require.False(t, fs[0].FirstSeen.IsZero())
+
+	run, err := server.GetRun(ctx, db, second)
+	require.NoError(t, err)
+	require.Equal(t, "test", run.Cluster)
+	require.Equal(t, []server.CheckRun{{CheckID: "latest-image-tag", Findings: 1}}, run.Checks)
+
+	_, err = server.GetRun(ctx, db, 42)
+	require.ErrorIs(t, err, server.ErrRunNotFound)
+}
@@ -0,0 +1,210 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"goprojects/findings"
+)
+
+var (
+	// ErrExceptionNotFound is returned when deleting an exception that does not exist
+	ErrExceptionNotFound = errors.New("exception not found")
+	// ErrInvalidException wraps the reason CreateException rejected an exception
+	ErrInvalidException = errors.New("invalid exception")
+)
+
+// ExceptionEvent is one entry of the exceptions audit trail
+type ExceptionEvent struct {
+	ExceptionID int64
+	Action      string // created or deleted
+	Actor       string
+	At          time.Time
+	Exception   findings.Exception // the exception as it was at that time
+}
+
+const exceptionColumns = `id, namespace, kind, resource, rule_id, reason, owner, expires_at, created_at, created_by`
+
+func scanException(s scanner) (findings.Exception, error) {
+	var (
+		e         findings.Exception
+		expiresAt sql.NullTime
+	)
+	err := s.Scan(&e.ID, &e.Namespace, &e.Kind, &e.Resource, &e.RuleID, &e.Reason, &e.Owner,
+		&expiresAt, &e.CreatedAt, &e.CreatedBy)
+	e.ExpiresAt = expiresAt.Time
+	return e, err
+}
+
+func recordExceptionEvent(tx *sql.Tx, action, actor string, at time.Time, e findings.Exception) error {
+	snapshot, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(`INSERT INTO exception_events (exception_id, action, actor, at, exception) VALUES (?, ?, ?, ?, ?)`,
+		e.ID, action, actor, at, string(snapshot))
+	if err != nil {
+		return fmt.Errorf("failed to record exception event: %w", err)
+	}
+	return nil
+}
+
+func nullTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t, Valid: !t.IsZero()}
+}
+
+// CreateException validates and stores an exception on behalf of actor and
+// returns it with its ID and creation fields set. Exceptions that are invalid or
+// already expired are rejected with ErrInvalidException.
+func CreateException(ctx context.Context, db *sql.DB, e findings.Exception, actor string) (findings.Exception, error) {
+	if err := e.Validate(); err != nil {
+		return findings.Exception{}, fmt.Errorf("%w: %w", ErrInvalidException, err)
+	}
+	now := time.Now().UTC().Truncate(time.Second)
+	if !e.Active(now) {
+		return findings.Exception{}, fmt.Errorf("%w: expiry %s is in the past", ErrInvalidException, e.ExpiresAt.Format(time.RFC3339))
+	}
+	e.CreatedAt, e.CreatedBy = now, actor
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return findings.Exception{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if e, err = insertException(tx, e); err != nil {
+		return findings.Exception{}, err
+	}
+	return e, tx.Commit()
+}
+
+// insertException stores an exception with its creation fields set and records
+// its creation in the audit trail
+func insertException(tx *sql.Tx, e findings.Exception) (findings.Exception, error) {
+	res, err := tx.Exec(`
+		INSERT INTO exceptions (namespace, kind, resource, rule_id, reason, owner, expires_at, created_at, created_by)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		e.Namespace, e.Kind, e.Resource, e.RuleID, e.Reason, e.Owner, nullTime(e.ExpiresAt), e.CreatedAt, e.CreatedBy,
+	)
+	if err != nil {
+		return findings.Exception{}, fmt.Errorf("failed to insert exception: %w", err)
+	}
+	if e.ID, err = res.LastInsertId(); err != nil {
+		return findings.Exception{}, err
+	}
+	if err := recordExceptionEvent(tx, "created", e.CreatedBy, e.CreatedAt, e); err != nil {
+		return findings.Exception{}, err
+	}
+	return e, nil
+}
+
+// defaultExceptionsActor is the creator of the default exceptions
+const defaultExceptionsActor = "cluster-auditor"
+
+// seedDefaultExceptions stores findings.DefaultExceptions the first time a
+// database is initialized, including databases created by older versions. Deleted
+// default exceptions are not stored again.
+func seedDefaultExceptions(db *sql.DB) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now().UTC().Truncate(time.Second)
+	res, err := tx.Exec(`INSERT OR IGNORE INTO seeds (name, at) VALUES ('default-exceptions', ?)`, now)
+	if err != nil {
+		return fmt.Errorf("failed to record seed: %w", err)
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		return err // already seeded
+	}
+	for _, e := range findings.DefaultExceptions {
+		e.CreatedAt, e.CreatedBy = now, defaultExceptionsActor
+		if _, err := insertException(tx, e); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// ListExceptions returns the stored exceptions, oldest first. Expired exceptions
+// are only included when includeExpired is set.
+func ListExceptions(ctx context.Context, db *sql.DB, includeExpired bool) ([]findings.Exception, error) {
+	query := `SELECT ` + exceptionColumns + ` FROM exceptions`
+	var args []any
+	if !includeExpired {
+		// julianday() compares the instants, whatever format they were stored in
+		query += ` WHERE expires_at IS NULL OR julianday(expires_at) > julianday(?)`
+		args = append(args, time.Now().UTC())
+	}
+	rows, err := db.QueryContext(ctx, query+` ORDER BY id`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []findings.Exception
+	for rows.Next() {
+		e, err := scanException(rows)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, e)
+	}
+	return results, rows.Err()
+}
+
+// DeleteException removes an exception on behalf of actor. The audit trail keeps
+// a copy of it.
+func DeleteException(ctx context.Context, db *sql.DB, id int64, actor string) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	e, err := scanException(tx.QueryRow(`SELECT `+exceptionColumns+` FROM exceptions WHERE id = ?`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrExceptionNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM exceptions WHERE id = ?`, id); err != nil {
+		return fmt.Errorf("failed to delete exception: %w", err)
+	}
+	if err := recordExceptionEvent(tx, "deleted", actor, time.Now().UTC().Truncate(time.Second), e); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ListExceptionEvents returns the exceptions audit trail, oldest first
+func ListExceptionEvents(ctx context.Context, db *sql.DB) ([]ExceptionEvent, error) {
+	rows, err := db.QueryContext(ctx, `SELECT exception_id, action, actor, at, exception FROM exception_events ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []ExceptionEvent
+	for rows.Next() {
+		var (
+			ev       ExceptionEvent
+			snapshot string
+		)
+		if err := rows.Scan(&ev.ExceptionID, &ev.Action, &ev.Actor, &ev.At, &snapshot); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(snapshot), &ev.Exception); err != nil {
+			return nil, fmt.Errorf("corrupt exception event: %w", err)
+		}
+		events = append(events, ev)
+	}
+	return events, rows.Err()
+}
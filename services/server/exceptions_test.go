@@ -0,0 +1,120 @@
+package server_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"goprojects/findings"
+	"goprojects/services/generated/auditorpb"
+	"goprojects/services/server"
+)
+
+func TestExceptionsApplyToFindingsAndScore(t *testing.T) {
+	db, err := server.InitDB(filepath.Join(t.TempDir(), "audit.db"))
+	require.NoError(t, err)
+	defer db.Close()
+	ctx := context.Background()
+	srv := &server.AuditorServer{DB: db}
+
+	_, err = server.ReplaceFindings(db, "test", "", "latest-image-tag", []findings.Finding{finding("a", "web"), finding("b", "api")})
+	require.NoError(t, err)
+
+	before, err := srv.GetHealthScore(ctx, &auditorpb.Empty{})
+	require.NoError(t, err)
+
+	_, err = srv.CreateException(ctx, &auditorpb.CreateExceptionRequest{Exception: &auditorpb.Exception{Namespace: "a"}})
+	require.Equal(t, codes.InvalidArgument, status.Code(err), "reason and owner are required")
+
+	created, err := srv.CreateException(ctx, &auditorpb.CreateExceptionRequest{Exception: &auditorpb.Exception{
+		Namespace: "a",
+		RuleId:    "latest-image-tag",
+		Reason:    "pinned by the vendor chart",
+		Owner:     "team-a",
+		ExpiresAt: timestamppb.New(time.Now().Add(time.Hour)),
+	}})
+	require.NoError(t, err)
+	require.NotZero(t, created.Id)
+
+	// Exceptions cannot be created already expired
+	_, err = server.CreateException(ctx, db, findings.Exception{Namespace: "b", Reason: "old", Owner: "team-b", ExpiresAt: time.Now().Add(-time.Hour)}, "ops")
+	require.ErrorIs(t, err, server.ErrInvalidException)
+	_, err = srv.CreateException(ctx, &auditorpb.CreateExceptionRequest{Exception: &auditorpb.Exception{
+		Namespace: "b", Reason: "old", Owner: "team-b", ExpiresAt: timestamppb.New(time.Now().Add(-time.Hour)),
+	}})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	resp, err := srv.GetFindings(ctx, &auditorpb.Empty{})
+	require.NoError(t, err)
+	require.Len(t, resp.Findings, 1)
+	require.Equal(t, "b", resp.Findings[0].Namespace)
+
+	after, err := srv.GetHealthScore(ctx, &auditorpb.Empty{})
+	require.NoError(t, err)
+	require.Greater(t, after.Score, before.Score)
+
+	_, err = srv.DeleteException(ctx, &auditorpb.DeleteExceptionRequest{Id: created.Id})
+	require.NoError(t, err)
+	_, err = srv.DeleteException(ctx, &auditorpb.DeleteExceptionRequest{Id: created.Id})
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	list, err := srv.ListExceptions(ctx, &auditorpb.ListExceptionsRequest{})
+	require.NoError(t, err)
+	require.Len(t, list.Exceptions, len(findings.DefaultExceptions))
+
+	events, err := server.ListExceptionEvents(ctx, db)
+	require.NoError(t, err)
+	events = events[len(findings.DefaultExceptions):]
+	require.Len(t, events, 2)
+	require.Equal(t, "created", events[0].Action)
+	require.Equal(t, "deleted", events[1].Action)
+	require.Equal(t, "anonymous", events[1].Actor)
+	require.Equal(t, "pinned by the vendor chart", events[1].Exception.Reason)
+}
+
+func TestDefaultExceptionsSeededOnce(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.db")
+	db, err := server.InitDB(path)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	stored, err := server.ListExceptions(ctx, db, false)
+	require.NoError(t, err)
+	require.Len(t, stored, len(findings.DefaultExceptions))
+	require.Equal(t, "kube-system", stored[0].Namespace)
+	require.Equal(t, "cluster-auditor", stored[0].CreatedBy)
+	require.NoError(t, server.DeleteException(ctx, db, stored[0].ID, "ops"))
+	require.NoError(t, db.Close())
+
+	// Reopening neither duplicates the defaults nor restores deleted ones
+	db, err = server.InitDB(path)
+	require.NoError(t, err)
+	defer db.Close()
+	stored, err = server.ListExceptions(ctx, db, false)
+	require.NoError(t, err)
+	require.Len(t, stored, len(findings.DefaultExceptions)-1)
+}
+
+func TestListExceptionsComparesExpiryInstants(t *testing.T) {
+	db, err := server.InitDB(filepath.Join(t.TempDir(), "audit.db"))
+	require.NoError(t, err)
+	defer db.Close()
+	ctx := context.Background()
+
+	// Stored with a zone behind UTC, the expiry sorts before now as text
+	honolulu := time.FixedZone("HST", -10*60*60)
+	_, err = server.CreateException(ctx, db, findings.Exception{
+		Namespace: "a", Reason: "migrating", Owner: "team-a", ExpiresAt: time.Now().Add(time.Hour).In(honolulu),
+	}, "ops")
+	require.NoError(t, err)
+
+	active, err := server.ListExceptions(ctx, db, false)
+	require.NoError(t, err)
+	require.Len(t, active, len(findings.DefaultExceptions)+1)
+}
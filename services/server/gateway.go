@@ -0,0 +1,62 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/textproto"
+
+	"goprojects/services/generated/auditorpb"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// NewGateway serves the ClusterAuditor service as REST/JSON. Requests are passed to
+// an in-process gRPC server over an in-memory listener, so they go through the same
+// interceptors as network clients. The returned func stops the inner server.
+func NewGateway(ctx context.Context, srv auditorpb.ClusterAuditorServer, opts ...grpc.ServerOption) (http.Handler, func(), error) {
+	inner := grpc.NewServer(opts...)
+	auditorpb.RegisterClusterAuditorServer(inner, srv)
+
+	listener := bufconn.Listen(1 << 20)
+	go inner.Serve(listener)
+
+	conn, err := grpc.NewClient("passthrough:///gateway",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithStatsHandler(otelgrpc.NewClientHandler()),
+	)
+	if err != nil {
+		inner.Stop()
+		return nil, nil, fmt.Errorf("failed to connect gateway: %w", err)
+	}
+
+	mux := runtime.NewServeMux(runtime.WithIncomingHeaderMatcher(gatewayHeaderMatcher))
+	if err := auditorpb.RegisterClusterAuditorHandler(ctx, mux, conn); err != nil {
+		conn.Close()
+		inner.Stop()
+		return nil, nil, fmt.Errorf("failed to register gateway: %w", err)
+	}
+
+	return otelhttp.NewHandler(mux, "gateway"), func() {
+		conn.Close()
+		inner.GracefulStop()
+	}, nil
+}
+
+// gatewayHeaderMatcher forwards the X-Api-Key header as the x-api-key metadata
+// TokenAuth reads, on top of the headers the gateway forwards by default
+func gatewayHeaderMatcher(key string) (string, bool) {
+	if textproto.CanonicalMIMEHeaderKey(key) == "X-Api-Key" {
+		return "x-api-key", true
+	}
+	return runtime.DefaultHeaderMatcher(key)
+}
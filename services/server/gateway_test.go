@@ -0,0 +1,40 @@
+package server_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+
+	"goprojects/services/server"
+)
+
+func TestGatewayForwardsCredentials(t *testing.T) {
+	db, err := server.InitDB(filepath.Join(t.TempDir(), "audit.db"))
+	require.NoError(t, err)
+	defer db.Close()
+
+	auth := loadTokens(t)
+	gateway, closeGateway, err := server.NewGateway(context.Background(), &server.AuditorServer{DB: db},
+		grpc.ChainUnaryInterceptor(auth.UnaryInterceptor()), grpc.ChainStreamInterceptor(auth.StreamInterceptor()))
+	require.NoError(t, err)
+	defer closeGateway()
+
+	get := func(header, value string) int {
+		req := httptest.NewRequest(http.MethodGet, "/v1/health-score", nil)
+		if header != "" {
+			req.Header.Set(header, value)
+		}
+		rec := httptest.NewRecorder()
+		gateway.ServeHTTP(rec, req)
+		return rec.Code
+	}
+	require.Equal(t, http.StatusOK, get("X-Api-Key", "reader-token"))
+	require.Equal(t, http.StatusOK, get("Authorization", "Bearer reader-token"))
+	require.Equal(t, http.StatusUnauthorized, get("X-Api-Key", "wrong"))
+	require.Equal(t, http.StatusUnauthorized, get("", ""))
+}
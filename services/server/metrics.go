@@ -0,0 +1,240 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"goprojects/findings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	openFindingsDesc = prometheus.NewDesc("auditor_open_findings",
+		"Open findings that are not covered by an exception.",
+		[]string{"cluster", "namespace", "team", "rule", "severity"}, nil)
+	healthScoreDesc = prometheus.NewDesc("auditor_health_score",
+		"Health score from 0 to 100 over the open findings of each audited cluster.",
+		[]string{"cluster"}, nil)
+	teamHealthScoreDesc = prometheus.NewDesc("auditor_team_health_score",
+		"Health score from 0 to 100 over the open findings of each team's namespaces in each cluster.",
+		[]string{"cluster", "team"}, nil)
+	lastSuccessDesc = prometheus.NewDesc("auditor_last_successful_run_timestamp_seconds",
+		"Finish time of the last audit run in which every check succeeded.",
+		[]string{"cluster"}, nil)
+	checkDurationDesc = prometheus.NewDesc("auditor_check_duration_seconds",
+		"Duration of each check in the latest audit run of all namespaces of each cluster.",
+		[]string{"cluster", "check"}, nil)
+	checkErrorsDesc = prometheus.NewDesc("auditor_check_errors_total",
+		"Check executions that failed, over all recorded audit runs.",
+		[]string{"check"}, nil)
+)
+
+// MetricsCollector exposes the stored findings and runs as Prometheus metrics. The
+// values are read from the database on every scrape, so they reflect audits run by
+// any process sharing the database.
+type MetricsCollector struct {
+	DB      *sql.DB
+	Timeout time.Duration // per scrape, defaults to 10s
+}
+
+func (c *MetricsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- openFindingsDesc
+	ch <- healthScoreDesc
+	ch <- teamHealthScoreDesc
+	ch <- lastSuccessDesc
+	ch <- checkDurationDesc
+	ch <- checkErrorsDesc
+}
+
+func (c *MetricsCollector) Collect(ch chan<- prometheus.Metric) {
+	timeout := c.Timeout
+	if timeout == 0 {
+		timeout = 10 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if err := c.collectFindings(ctx, ch); err != nil {
+		ch <- prometheus.NewInvalidMetric(openFindingsDesc, err)
+	}
+	if err := c.collectRuns(ctx, ch); err != nil {
+		ch <- prometheus.NewInvalidMetric(lastSuccessDesc, err)
+	}
+}
+
+func (c *MetricsCollector) collectFindings(ctx context.Context, ch chan<- prometheus.Metric) error {
+	open, err := ListOpenFindings(ctx, c.DB)
+	if err != nil {
+		return err
+	}
+	exceptions, err := ListExceptions(ctx, c.DB, false)
+	if err != nil {
+		return err
+	}
+	current := findings.ApplyExceptions(open, exceptions, time.Now())
+
+	// Clusters audited without open findings score 100
+	byCluster, err := c.auditedClusters(ctx)
+	if err != nil {
+		return err
+	}
+	type key struct{ cluster, namespace, team, rule, severity string }
+	type teamKey struct{ cluster, team string }
+	counts := map[key]int{}
+	byTeam := map[teamKey][]findings.Finding{}
+	for _, f := range current {
+		counts[key{f.Cluster, f.Namespace, f.Team, f.RuleID, string(f.Severity)}]++
+		byCluster[f.Cluster] = append(byCluster[f.Cluster], f)
+		if f.Team != "" {
+			byTeam[teamKey{f.Cluster, f.Team}] = append(byTeam[teamKey{f.Cluster, f.Team}], f)
+		}
+	}
+	for k, n := range counts {
+		ch <- prometheus.MustNewConstMetric(openFindingsDesc, prometheus.GaugeValue, float64(n), k.cluster, k.namespace, k.team, k.rule, k.severity)
+	}
+
+	for cluster, fs := range byCluster {
+		score, _ := findings.HealthScore(fs)
+		ch <- prometheus.MustNewConstMetric(healthScoreDesc, prometheus.GaugeValue, score, cluster)
+	}
+	for k, fs := range byTeam {
+		score, _ := findings.HealthScore(fs)
+		ch <- prometheus.MustNewConstMetric(teamHealthScoreDesc, prometheus.GaugeValue, score, k.cluster, k.team)
+	}
+	return nil
+}
+
+// auditedClusters returns the clusters of the recorded runs, with no findings
+func (c *MetricsCollector) auditedClusters(ctx context.Context) (map[string][]findings.Finding, error) {
+	rows, err := c.DB.QueryContext(ctx, `SELECT DISTINCT cluster FROM runs`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	clusters := map[string][]findings.Finding{}
+	for rows.Next() {
+		var cluster string
+		if err := rows.Scan(&cluster); err != nil {
+			return nil, err
+		}
+		clusters[cluster] = nil
+	}
+	return clusters, rows.Err()
+}
+
+func (c *MetricsCollector) collectRuns(ctx context.Context, ch chan<- prometheus.Metric) error {
+	rows, err := c.DB.QueryContext(ctx, `SELECT cluster, MAX(CAST(strftime('%s', finished_at) AS INTEGER)) FROM runs WHERE status = 'succeeded' GROUP BY cluster`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			cluster  string
+			finished float64
+		)
+		if err := rows.Scan(&cluster, &finished); err != nil {
+			return err
+		}
+		ch <- prometheus.MustNewConstMetric(lastSuccessDesc, prometheus.GaugeValue, finished, cluster)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	// Runs of a single namespace would replace the durations over the whole cluster
+	rows, err = c.DB.QueryContext(ctx, `SELECT runs.cluster, run_checks.check_id, run_checks.duration_seconds
+		FROM run_checks JOIN runs ON runs.id = run_checks.run_id
+		WHERE runs.id IN (SELECT MAX(id) FROM runs WHERE status != 'running' AND namespace = '' GROUP BY cluster)`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			cluster, check string
+			duration       float64
+		)
+		if err := rows.Scan(&cluster, &check, &duration); err != nil {
+			return err
+		}
+		ch <- prometheus.MustNewConstMetric(checkDurationDesc, prometheus.GaugeValue, duration, cluster, check)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	rows, err = c.DB.QueryContext(ctx, `SELECT check_id, SUM(CASE WHEN error != '' THEN 1 ELSE 0 END) FROM run_checks GROUP BY check_id`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			check    string
+			failures float64
+		)
+		if err := rows.Scan(&check, &failures); err != nil {
+			return err
+		}
+		ch <- prometheus.MustNewConstMetric(checkErrorsDesc, prometheus.CounterValue, failures, check)
+	}
+	return rows.Err()
+}
+
+// GRPCMetrics counts and times the RPCs handled by a server
+type GRPCMetrics struct {
+	handled  *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+}
+
+func NewGRPCMetrics() *GRPCMetrics {
+	return &GRPCMetrics{
+		handled: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "grpc_server_handled_total",
+			Help: "RPCs completed on the server, by method and status code.",
+		}, []string{"grpc_method", "grpc_code"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "grpc_server_handling_seconds",
+			Help:    "Time taken to handle RPCs, by method.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"grpc_method"}),
+	}
+}
+
+func (m *GRPCMetrics) Describe(ch chan<- *prometheus.Desc) {
+	m.handled.Describe(ch)
+	m.duration.Describe(ch)
+}
+
+func (m *GRPCMetrics) Collect(ch chan<- prometheus.Metric) {
+	m.handled.Collect(ch)
+	m.duration.Collect(ch)
+}
+
+func (m *GRPCMetrics) observe(method string, start time.Time, err error) {
+	m.handled.WithLabelValues(method, status.Code(err).String()).Inc()
+	m.duration.WithLabelValues(method).Observe(time.Since(start).Seconds())
+}
+
+func (m *GRPCMetrics) UnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		m.observe(info.FullMethod, start, err)
+		return resp, err
+	}
+}
+
+func (m *GRPCMetrics) StreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		err := handler(srv, ss)
+		m.observe(info.FullMethod, start, err)
+		return err
+	}
+}
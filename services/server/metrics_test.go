@@ -0,0 +1,78 @@
+package server_test
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+
+	"goprojects/findings"
+	"goprojects/services/server"
+)
+
+func TestMetricsCollector(t *testing.T) {
+	db, err := server.InitDB(filepath.Join(t.TempDir(), "audit.db"))
+	require.NoError(t, err)
+	defer db.Close()
+	ctx := context.Background()
+
+	_, err = server.ReplaceFindings(db, "test", "", "latest-image-tag", []findings.Finding{finding("a", "web"), finding("a", "api")})
+	require.NoError(t, err)
+
+	record := func(r server.Run) {
+		require.NoError(t, server.BeginRun(ctx, db, &r))
+		require.NoError(t, server.FinishRun(ctx, db, r))
+	}
+	finished := time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC)
+	record(server.Run{
+		Cluster:    "test",
+		StartedAt:  finished.Add(-time.Minute),
+		FinishedAt: finished,
+		Checks: []server.CheckRun{
+			{CheckID: "latest-image-tag", Duration: 1500 * time.Millisecond, Findings: 2},
+			{CheckID: "risky-rbac", Duration: time.Second, Error: "forbidden"},
+		},
+	})
+	record(server.Run{Cluster: "test", StartedAt: finished, FinishedAt: finished,
+		Checks: []server.CheckRun{{CheckID: "latest-image-tag", Duration: 2 * time.Second}}})
+	// A run of one namespace does not replace the durations of the whole cluster
+	record(server.Run{Cluster: "test", Namespace: "a", StartedAt: finished, FinishedAt: finished,
+		Checks: []server.CheckRun{{CheckID: "latest-image-tag", Duration: 3 * time.Second}}})
+	record(server.Run{Cluster: "other", StartedAt: finished, FinishedAt: finished,
+		Checks: []server.CheckRun{{CheckID: "latest-image-tag", Duration: time.Second}}})
+	// A run in progress is neither successful nor the latest with check results
+	require.NoError(t, server.BeginRun(ctx, db, &server.Run{Cluster: "test", StartedAt: finished}))
+	// A cluster without findings is healthy
+	require.NoError(t, server.BeginRun(ctx, db, &server.Run{Cluster: "clean", StartedAt: finished}))
+
+	collector := &server.MetricsCollector{DB: db}
+	expected := `
+# HELP auditor_open_findings Open findings that are not covered by an exception.
+# TYPE auditor_open_findings gauge
+auditor_open_findings{cluster="test",namespace="a",rule="latest-image-tag",severity="medium",team=""} 2
+# HELP auditor_health_score Health score from 0 to 100 over the open findings of each audited cluster.
+# TYPE auditor_health_score gauge
+auditor_health_score{cluster="clean"} 100
+auditor_health_score{cluster="other"} 100
+auditor_health_score{cluster="test"} 96.1
+# HELP auditor_last_successful_run_timestamp_seconds Finish time of the last audit run in which every check succeeded.
+# TYPE auditor_last_successful_run_timestamp_seconds gauge
+auditor_last_successful_run_timestamp_seconds{cluster="other"} 1.7723520e+09
+auditor_last_successful_run_timestamp_seconds{cluster="test"} 1.7723520e+09
+# HELP auditor_check_duration_seconds Duration of each check in the latest audit run of all namespaces of each cluster.
+# TYPE auditor_check_duration_seconds gauge
+auditor_check_duration_seconds{check="latest-image-tag",cluster="other"} 1
+auditor_check_duration_seconds{check="latest-image-tag",cluster="test"} 2
+# HELP auditor_check_errors_total Check executions that failed, over all recorded audit runs.
+# TYPE auditor_check_errors_total counter
+auditor_check_errors_total{check="latest-image-tag"} 0
+auditor_check_errors_total{check="risky-rbac"} 1
+`
+	require.NoError(t, testutil.CollectAndCompare(collector, strings.NewReader(expected),
+		"auditor_open_findings", "auditor_health_score", "auditor_last_successful_run_timestamp_seconds",
+		"auditor_check_duration_seconds", "auditor_check_errors_total"))
+}
@@ -0,0 +1,206 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"goprojects/findings"
+)
+
+// ErrRunNotFound is returned for an unknown run ID
+var ErrRunNotFound = errors.New("run not found")
+
+// Run is one full audit of a cluster or namespace
+type Run struct {
+	ID         int64
+	Cluster    string
+	Namespace  string // empty for all namespaces
+	StartedAt  time.Time
+	FinishedAt time.Time
+	Checks     []CheckRun
+}
+
+// CheckRun is the outcome of one check within a run
+type CheckRun struct {
+	CheckID  string
+	Duration time.Duration
+	Findings int
+	Error    string // empty if the check succeeded
+}
+
+// Succeeded reports whether every check of the run succeeded
+func (r Run) Succeeded() bool {
+	for _, c := range r.Checks {
+		if c.Error != "" {
+			return false
+		}
+	}
+	return true
+}
+
+func createRunTables(db *sql.DB) error {
+	_, err := db.Exec(`
+	CREATE TABLE IF NOT EXISTS runs (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		cluster TEXT NOT NULL DEFAULT '',
+		namespace TEXT NOT NULL DEFAULT '',
+		started_at DATETIME NOT NULL,
+		finished_at DATETIME NOT NULL,
+		status TEXT NOT NULL
+	);
+	CREATE TABLE IF NOT EXISTS run_checks (
+		run_id INTEGER NOT NULL REFERENCES runs (id),
+		check_id TEXT NOT NULL,
+		duration_seconds REAL NOT NULL,
+		findings INTEGER NOT NULL,
+		error TEXT NOT NULL DEFAULT ''
+	);
+	CREATE INDEX IF NOT EXISTS idx_run_checks_run ON run_checks (run_id);
+	CREATE TABLE IF NOT EXISTS run_findings (
+		run_id INTEGER NOT NULL REFERENCES runs (id),
+		finding_id INTEGER NOT NULL REFERENCES findings (id),
+		PRIMARY KEY (run_id, finding_id)
+	);
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create run tables: %w", err)
+	}
+
+	// The finding fields that can change after a run, as they were in the run
+	for _, column := range []string{"severity", "team"} {
+		if err := addColumnIfMissing(db, "run_findings", column, "TEXT"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// BeginRun stores a run as running and sets its ID, so the ID can be logged and
+// traced while the run is in progress
+func BeginRun(ctx context.Context, db *sql.DB, r *Run) error {
+	res, err := db.ExecContext(ctx, `INSERT INTO runs (cluster, namespace, started_at, finished_at, status) VALUES (?, ?, ?, ?, 'running')`,
+		r.Cluster, r.Namespace, r.StartedAt.UTC(), r.StartedAt.UTC())
+	if err != nil {
+		return fmt.Errorf("failed to insert run: %w", err)
+	}
+	r.ID, err = res.LastInsertId()
+	return err
+}
+
+// FinishRun records the outcome of a run started with BeginRun
+func FinishRun(ctx context.Context, db *sql.DB, r Run) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	status := "succeeded"
+	if !r.Succeeded() {
+		status = "failed"
+	}
+	_, err = tx.Exec(`UPDATE runs SET finished_at = ?, status = ? WHERE id = ?`, r.FinishedAt.UTC(), status, r.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update run: %w", err)
+	}
+
+	for _, c := range r.Checks {
+		_, err := tx.Exec(`INSERT INTO run_checks (run_id, check_id, duration_seconds, findings, error) VALUES (?, ?, ?, ?, ?)`,
+			r.ID, c.CheckID, c.Duration.Seconds(), c.Findings, c.Error)
+		if err != nil {
+			return fmt.Errorf("failed to insert check result: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// RecordRunFindings links a run to the open stored findings it reported, so
+// RunFindings can list them later. Call it after the findings were stored with
+// ReplaceFindings.
+func RecordRunFindings(ctx context.Context, db *sql.DB, runID int64, cluster string, fs []findings.Finding) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, f := range fs {
+		fingerprint := f.Fingerprint
+		if fingerprint == "" {
+			fingerprint = findings.ComputeFingerprint(f)
+		}
+		_, err := tx.ExecContext(ctx, `
+			INSERT OR IGNORE INTO run_findings (run_id, finding_id, severity, team)
+			SELECT ?, id, severity, team FROM findings
+			WHERE fingerprint = ? AND COALESCE(cluster, '') = ? AND COALESCE(status, 'open') = 'open'
+			ORDER BY id DESC LIMIT 1`, runID, fingerprint, cluster)
+		if err != nil {
+			return fmt.Errorf("failed to link finding to run: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// GetRun returns a stored run with its per-check results
+func GetRun(ctx context.Context, db *sql.DB, id int64) (Run, error) {
+	r := Run{ID: id}
+	err := db.QueryRowContext(ctx, `SELECT cluster, namespace, started_at, finished_at FROM runs WHERE id = ?`, id).
+		Scan(&r.Cluster, &r.Namespace, &r.StartedAt, &r.FinishedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Run{}, fmt.Errorf("%w: %d", ErrRunNotFound, id)
+	}
+	if err != nil {
+		return Run{}, fmt.Errorf("failed to load run: %w", err)
+	}
+
+	rows, err := db.QueryContext(ctx, `SELECT check_id, duration_seconds, findings, error FROM run_checks WHERE run_id = ? ORDER BY rowid`, id)
+	if err != nil {
+		return Run{}, fmt.Errorf("failed to load run checks: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			c       CheckRun
+			seconds float64
+		)
+		if err := rows.Scan(&c.CheckID, &seconds, &c.Findings, &c.Error); err != nil {
+			return Run{}, err
+		}
+		c.Duration = time.Duration(seconds * float64(time.Second))
+		r.Checks = append(r.Checks, c)
+	}
+	return r, rows.Err()
+}
+
+// RunFindings returns the findings a run reported, as recorded by
+// RecordRunFindings. Their severity and team are the ones at the time of the run,
+// their lifecycle fields are the current ones.
+func RunFindings(ctx context.Context, db *sql.DB, runID int64) ([]findings.Finding, error) {
+	// Runs recorded before the snapshot columns existed fall back to the current values
+	rows, err := db.QueryContext(ctx, `
+		SELECT `+findingColumns+` FROM (
+			SELECT f.id, f.namespace, f.resource, f.kind, f.container, f.issue, f.suggestion, f.subjects, f.rule_id,
+				COALESCE(rf.severity, f.severity) AS severity, f.cluster, f.fingerprint, f.first_seen, f.last_seen,
+				f.status, COALESCE(rf.team, f.team) AS team
+			FROM run_findings rf JOIN findings f ON f.id = rf.finding_id
+			WHERE rf.run_id = ?
+		)
+		ORDER BY id`, runID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query run findings: %w", err)
+	}
+	defer rows.Close()
+
+	results := []findings.Finding{}
+	for rows.Next() {
+		f, err := scanFinding(rows)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, f)
+	}
+	return results, rows.Err()
+}
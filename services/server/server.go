@@ -2,45 +2,369 @@ package server
 
 import (
 	"database/sql"
+	"encoding/base64"
+	"errors"
+	"log/slog"
+	"maps"
+	"slices"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"context"
+	"goprojects/findings"
+	"goprojects/services/convert"
 	"goprojects/services/generated/auditorpb"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 type AuditorServer struct {
 	auditorpb.UnimplementedClusterAuditorServer
 	DB *sql.DB
+
+	// RunAudit audits the namespace ("" for all) and stores the findings. It is
+	// provided by the binary that owns the Kubernetes client; when nil,
+	// TriggerAudit is unavailable.
+	RunAudit func(ctx context.Context, namespace string) error
+
+	auditRunning atomic.Bool
+	audits       sync.WaitGroup
+}
+
+// teamScope returns the team whose findings the caller may see, given the team it
+// asked for. Callers bound to a team are limited to it; others see what they ask for.
+func teamScope(ctx context.Context, requested string) (string, error) {
+	p, ok := PrincipalFromContext(ctx)
+	if !ok || p.Team == "" {
+		return requested, nil
+	}
+	if requested != "" && requested != p.Team {
+		return "", status.Errorf(codes.PermissionDenied, "%s may only access the findings of team %s", p.Name, p.Team)
+	}
+	return p.Team, nil
+}
+
+// currentFindings returns the open findings matching the filter that are not
+// covered by an active exception. The filter's team is narrowed to the caller's.
+func (s *AuditorServer) currentFindings(ctx context.Context, filter FindingFilter) ([]findings.Finding, error) {
+	team, err := teamScope(ctx, filter.Team)
+	if err != nil {
+		return nil, err
+	}
+	filter.Team = team
+
+	open, err := QueryOpenFindings(ctx, s.DB, filter)
+	if err != nil {
+		return nil, err
+	}
+	exceptions, err := ListExceptions(ctx, s.DB, false)
+	if err != nil {
+		return nil, err
+	}
+	return findings.ApplyExceptions(open, exceptions, time.Now()), nil
 }
 
-// Dummy values. Logic for calculating health score yet to be implemented.
 func (s *AuditorServer) GetHealthScore(ctx context.Context, in *auditorpb.Empty) (*auditorpb.HealthScore, error) {
+	current, err := s.currentFindings(ctx, FindingFilter{})
+	if err != nil {
+		return nil, err
+	}
+
+	score, state := findings.HealthScore(current)
 	return &auditorpb.HealthScore{
-		Score:  87.3,
-		Status: "Healthy",
+		Score:  float32(score),
+		Status: state,
 	}, nil
 }
 
+// GetTeamScores scores every team on the findings of its own namespaces. Findings
+// in namespaces without an owner are not part of any team's score.
+func (s *AuditorServer) GetTeamScores(ctx context.Context, in *auditorpb.GetTeamScoresRequest) (*auditorpb.GetTeamScoresResponse, error) {
+	current, err := s.currentFindings(ctx, FindingFilter{Team: in.GetTeam()})
+	if err != nil {
+		return nil, err
+	}
+
+	byTeam := map[string][]findings.Finding{}
+	for _, f := range current {
+		if f.Team != "" {
+			byTeam[f.Team] = append(byTeam[f.Team], f)
+		}
+	}
+	// A requested team without open findings is perfectly healthy, not missing
+	if team, _ := teamScope(ctx, in.GetTeam()); team != "" {
+		if _, ok := byTeam[team]; !ok {
+			byTeam[team] = nil
+		}
+	}
+
+	resp := &auditorpb.GetTeamScoresResponse{Scores: make([]*auditorpb.TeamScore, 0, len(byTeam))}
+	for _, team := range slices.Sorted(maps.Keys(byTeam)) {
+		score, state := findings.HealthScore(byTeam[team])
+		resp.Scores = append(resp.Scores, &auditorpb.TeamScore{
+			Team:         team,
+			Score:        float32(score),
+			Status:       state,
+			OpenFindings: int32(len(byTeam[team])),
+		})
+	}
+	return resp, nil
+}
+
 func (s *AuditorServer) GetFindings(ctx context.Context, in *auditorpb.Empty) (*auditorpb.FindingsResponse, error) {
-	rows, err := s.DB.QueryContext(ctx, `
-		SELECT namespace, resource, kind, container, issue, suggestion
-		FROM findings
-		ORDER BY created_at DESC
-	`)
+	current, err := s.currentFindings(ctx, FindingFilter{})
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
 
-	var results []*auditorpb.Finding
+	results := make([]*auditorpb.Finding, 0, len(current))
+	for _, f := range current {
+		results = append(results, convert.ToProto(f))
+	}
+
+	return &auditorpb.FindingsResponse{Findings: results}, nil
+}
+
+const (
+	defaultPageSize = 100
+	maxPageSize     = 1000
+)
 
-	for rows.Next() {
-		var f auditorpb.Finding
-		err := rows.Scan(&f.Namespace, &f.Resource, &f.Kind, &f.Container, &f.Issue, &f.Suggestion)
+// filteredFindings returns the current findings matching the request's filters
+func (s *AuditorServer) filteredFindings(ctx context.Context, in *auditorpb.ListFindingsRequest) ([]findings.Finding, error) {
+	var minRank int
+	if in.GetMinSeverity() != "" {
+		sev, err := findings.ParseSeverity(in.GetMinSeverity())
 		if err != nil {
-			return nil, err
+			return nil, status.Error(codes.InvalidArgument, err.Error())
 		}
-		results = append(results, &f)
+		minRank = sev.Rank()
 	}
 
-	return &auditorpb.FindingsResponse{Findings: results}, nil
+	current, err := s.currentFindings(ctx, FindingFilter{
+		Namespace: in.GetNamespace(),
+		Cluster:   in.GetCluster(),
+		RuleID:    in.GetRuleId(),
+		Team:      in.GetTeam(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	matching := current[:0]
+	for _, f := range current {
+		if f.Severity.Rank() >= minRank {
+			matching = append(matching, f)
+		}
+	}
+	return matching, nil
+}
+
+// Page tokens encode the offset of the next page. They are opaque to clients so
+// the scheme can change without breaking them.
+func encodePageToken(offset int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.Itoa(offset)))
+}
+
+func decodePageToken(token string) (int, error) {
+	if token == "" {
+		return 0, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return 0, errors.New("invalid page token")
+	}
+	offset, err := strconv.Atoi(string(raw))
+	if err != nil || offset < 0 {
+		return 0, errors.New("invalid page token")
+	}
+	return offset, nil
+}
+
+func (s *AuditorServer) ListFindings(ctx context.Context, in *auditorpb.ListFindingsRequest) (*auditorpb.ListFindingsResponse, error) {
+	pageSize := int(in.GetPageSize())
+	switch {
+	case pageSize < 0:
+		return nil, status.Error(codes.InvalidArgument, "page_size must not be negative")
+	case pageSize == 0:
+		pageSize = defaultPageSize
+	case pageSize > maxPageSize:
+		pageSize = maxPageSize
+	}
+	offset, err := decodePageToken(in.GetPageToken())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	matching, err := s.filteredFindings(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &auditorpb.ListFindingsResponse{TotalSize: int32(len(matching))}
+	end := min(offset+pageSize, len(matching))
+	for i := offset; i < end; i++ {
+		resp.Findings = append(resp.Findings, convert.ToProto(matching[i]))
+	}
+	if end < len(matching) {
+		resp.NextPageToken = encodePageToken(end)
+	}
+	return resp, nil
+}
+
+func (s *AuditorServer) StreamFindings(in *auditorpb.ListFindingsRequest, stream auditorpb.ClusterAuditor_StreamFindingsServer) error {
+	matching, err := s.filteredFindings(stream.Context(), in)
+	if err != nil {
+		return err
+	}
+	for _, f := range matching {
+		if err := stream.Send(convert.ToProto(f)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// TriggerAudit starts an audit in the background. Only one audit runs at a time.
+func (s *AuditorServer) TriggerAudit(ctx context.Context, in *auditorpb.TriggerAuditRequest) (*auditorpb.TriggerAuditResponse, error) {
+	if s.RunAudit == nil {
+		return nil, status.Error(codes.Unimplemented, "this server is not configured to run audits")
+	}
+	if !s.auditRunning.CompareAndSwap(false, true) {
+		return &auditorpb.TriggerAuditResponse{Accepted: false, Message: "an audit is already running"}, nil
+	}
+
+	// The audit outlives the RPC, but keeps the request's context values
+	auditCtx := context.WithoutCancel(ctx)
+	s.audits.Add(1)
+	go func() {
+		defer s.audits.Done()
+		defer s.auditRunning.Store(false)
+		if err := s.RunAudit(auditCtx, in.GetNamespace()); err != nil {
+			slog.ErrorContext(auditCtx, "Triggered audit failed", "namespace", in.GetNamespace(), "err", err)
+		}
+	}()
+
+	return &auditorpb.TriggerAuditResponse{Accepted: true, Message: "audit started"}, nil
+}
+
+// Wait waits for the audits started by TriggerAudit to finish, e.g. before
+// closing the DB they store their findings in
+func (s *AuditorServer) Wait() {
+	s.audits.Wait()
+}
+
+// actor names the caller for the exceptions audit trail
+func actor(ctx context.Context) string {
+	if p, ok := PrincipalFromContext(ctx); ok {
+		return p.Name
+	}
+	return "anonymous"
+}
+
+func (s *AuditorServer) CreateException(ctx context.Context, in *auditorpb.CreateExceptionRequest) (*auditorpb.Exception, error) {
+	if in.GetException() == nil {
+		return nil, status.Error(codes.InvalidArgument, "exception is required")
+	}
+	created, err := CreateException(ctx, s.DB, convert.ExceptionFromProto(in.GetException()), actor(ctx))
+	if errors.Is(err, ErrInvalidException) {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if err != nil {
+		return nil, err
+	}
+	slog.InfoContext(ctx, "Exception created", "exception_id", created.ID, "actor", created.CreatedBy, "reason", created.Reason)
+	return convert.ExceptionToProto(created), nil
+}
+
+func (s *AuditorServer) ListExceptions(ctx context.Context, in *auditorpb.ListExceptionsRequest) (*auditorpb.ListExceptionsResponse, error) {
+	exceptions, err := ListExceptions(ctx, s.DB, in.GetIncludeExpired())
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]*auditorpb.Exception, 0, len(exceptions))
+	for _, e := range exceptions {
+		results = append(results, convert.ExceptionToProto(e))
+	}
+	return &auditorpb.ListExceptionsResponse{Exceptions: results}, nil
+}
+
+func (s *AuditorServer) DeleteException(ctx context.Context, in *auditorpb.DeleteExceptionRequest) (*auditorpb.Empty, error) {
+	err := DeleteException(ctx, s.DB, in.GetId(), actor(ctx))
+	if errors.Is(err, ErrExceptionNotFound) {
+		return nil, status.Errorf(codes.NotFound, "exception %d not found", in.GetId())
+	}
+	if err != nil {
+		return nil, err
+	}
+	slog.InfoContext(ctx, "Exception deleted", "exception_id", in.GetId(), "actor", actor(ctx))
+	return &auditorpb.Empty{}, nil
+}
+
+// GetTrends aggregates the stored findings history into time buckets
+func (s *AuditorServer) GetTrends(ctx context.Context, in *auditorpb.GetTrendsRequest) (*auditorpb.GetTrendsResponse, error) {
+	q := TrendQuery{
+		End:      time.Now().UTC(),
+		Interval: in.GetInterval(),
+		GroupBy:  in.GetGroupBy(),
+		Cluster:  in.GetCluster(),
+	}
+	team, err := teamScope(ctx, in.GetTeam())
+	if err != nil {
+		return nil, err
+	}
+	q.Team = team
+	if in.GetEnd() != nil {
+		q.End = in.GetEnd().AsTime()
+	}
+	q.Start = q.End.AddDate(0, 0, -30)
+	if in.GetStart() != nil {
+		q.Start = in.GetStart().AsTime()
+	}
+	if q.Interval == "" {
+		q.Interval = "day"
+	}
+	if q.GroupBy == "" {
+		q.GroupBy = "severity"
+	}
+
+	buckets, err := Trends(ctx, s.DB, q)
+	if errors.Is(err, ErrInvalidTrendQuery) {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &auditorpb.GetTrendsResponse{Buckets: make([]*auditorpb.TrendBucket, 0, len(buckets))}
+	for _, b := range buckets {
+		pb := &auditorpb.TrendBucket{
+			Start: timestamppb.New(b.Start),
+			End:   timestamppb.New(b.End),
+			Total: trendCountsToProto(b.Total),
+		}
+		for _, g := range b.Groups {
+			pb.Groups = append(pb.Groups, trendCountsToProto(g))
+		}
+		resp.Buckets = append(resp.Buckets, pb)
+	}
+	return resp, nil
+}
+
+func trendCountsToProto(c TrendCounts) *auditorpb.TrendCounts {
+	pb := &auditorpb.TrendCounts{
+		Group:    c.Group,
+		Open:     c.Open,
+		Opened:   c.Opened,
+		Resolved: c.Resolved,
+	}
+	if c.Resolved > 0 {
+		pb.MeanTimeToResolve = durationpb.New(c.MeanTimeToResolve)
+	}
+	return pb
 }
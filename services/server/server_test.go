@@ -0,0 +1,33 @@
+package server_test
+
+import (
+	"context"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"goprojects/services/generated/auditorpb"
+	"goprojects/services/server"
+)
+
+func TestTriggerAuditWait(t *testing.T) {
+	db, err := server.InitDB(filepath.Join(t.TempDir(), "audit.db"))
+	require.NoError(t, err)
+	defer db.Close()
+
+	var finished atomic.Bool
+	srv := &server.AuditorServer{DB: db, RunAudit: func(ctx context.Context, namespace string) error {
+		time.Sleep(50 * time.Millisecond)
+		finished.Store(true)
+		return nil
+	}}
+	resp, err := srv.TriggerAudit(context.Background(), &auditorpb.TriggerAuditRequest{})
+	require.NoError(t, err)
+	require.True(t, resp.Accepted)
+
+	srv.Wait()
+	require.True(t, finished.Load())
+}
@@ -0,0 +1,87 @@
+package server_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"goprojects/findings"
+	"goprojects/services/generated/auditorpb"
+	"goprojects/services/server"
+)
+
+func TestTeamScopedFindingsAndScores(t *testing.T) {
+	db, err := server.InitDB(filepath.Join(t.TempDir(), "audit.db"))
+	require.NoError(t, err)
+	defer db.Close()
+	srv := &server.AuditorServer{DB: db}
+
+	owned := func(ns, resource, team string) findings.Finding {
+		f := finding(ns, resource)
+		f.Team = team
+		return f
+	}
+	_, err = server.ReplaceFindings(db, "test", "", "latest-image-tag", []findings.Finding{
+		owned("payments", "api", "payments"),
+		owned("payments-jobs", "billing", "payments"),
+		owned("search", "indexer", "search"),
+		finding("default", "web"),
+	})
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "tokens.csv")
+	require.NoError(t, os.WriteFile(path, []byte("ops-token,ops,read\npay-token,payments-ci,read,payments\n"), 0600))
+	auth, err := server.LoadTokenFile(path)
+	require.NoError(t, err)
+	method := auditorpb.ClusterAuditor_ListFindings_FullMethodName
+	opsCtx, err := auth.Authorize(withMD("authorization", "Bearer ops-token"), method)
+	require.NoError(t, err)
+	payCtx, err := auth.Authorize(withMD("authorization", "Bearer pay-token"), method)
+	require.NoError(t, err)
+
+	all, err := srv.GetTeamScores(opsCtx, &auditorpb.GetTeamScoresRequest{})
+	require.NoError(t, err)
+	require.Len(t, all.Scores, 2)
+	require.Equal(t, "payments", all.Scores[0].Team)
+	require.EqualValues(t, 2, all.Scores[0].OpenFindings)
+	require.Equal(t, "search", all.Scores[1].Team)
+	require.Greater(t, all.Scores[1].Score, all.Scores[0].Score)
+
+	// Teams without open findings are scored as healthy
+	none, err := srv.GetTeamScores(opsCtx, &auditorpb.GetTeamScoresRequest{Team: "storage"})
+	require.NoError(t, err)
+	require.Len(t, none.Scores, 1)
+	require.EqualValues(t, 100, none.Scores[0].Score)
+
+	resp, err := srv.ListFindings(opsCtx, &auditorpb.ListFindingsRequest{Team: "search"})
+	require.NoError(t, err)
+	require.Len(t, resp.Findings, 1)
+	require.Equal(t, "search", resp.Findings[0].Team)
+
+	// Tokens bound to a team only see and are scored on that team's namespaces
+	resp, err = srv.ListFindings(payCtx, &auditorpb.ListFindingsRequest{})
+	require.NoError(t, err)
+	require.EqualValues(t, 2, resp.TotalSize)
+	for _, f := range resp.Findings {
+		require.Equal(t, "payments", f.Team)
+	}
+	_, err = srv.ListFindings(payCtx, &auditorpb.ListFindingsRequest{Team: "search"})
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	score, err := srv.GetHealthScore(payCtx, &auditorpb.Empty{})
+	require.NoError(t, err)
+	require.Equal(t, all.Scores[0].Score, score.Score)
+
+	// A finding keeps its lifecycle when its namespace changes owner
+	_, err = server.ReplaceFindings(db, "test", "search", "latest-image-tag", []findings.Finding{owned("search", "indexer", "discovery")})
+	require.NoError(t, err)
+	moved, err := server.QueryOpenFindings(context.Background(), db, server.FindingFilter{Team: "discovery"})
+	require.NoError(t, err)
+	require.Len(t, moved, 1)
+	require.Equal(t, "indexer", moved[0].Resource)
+}
@@ -0,0 +1,160 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrInvalidTrendQuery is wrapped by the errors about unsupported trend queries
+var ErrInvalidTrendQuery = errors.New("invalid trend query")
+
+// maxTrendBuckets bounds the size of a single trends query
+const maxTrendBuckets = 1000
+
+// trendGroupColumns maps the supported group_by values to their column
+var trendGroupColumns = map[string]string{
+	"severity":  "COALESCE(severity, '')",
+	"rule":      "COALESCE(rule_id, '')",
+	"namespace": "COALESCE(namespace, '')",
+	"cluster":   "COALESCE(cluster, '')",
+	"team":      "COALESCE(team, '')",
+}
+
+var trendIntervals = map[string]time.Duration{
+	"hour": time.Hour,
+	"day":  24 * time.Hour,
+	"week": 7 * 24 * time.Hour,
+}
+
+// TrendQuery selects the findings history to aggregate
+type TrendQuery struct {
+	Start    time.Time
+	End      time.Time
+	Interval string // hour, day or week
+	GroupBy  string // severity, rule, namespace, cluster or team
+	Cluster  string // empty for all clusters
+	Team     string // empty for all teams
+}
+
+// TrendCounts are the counts of one group, or of all findings when Group is empty
+type TrendCounts struct {
+	Group             string
+	Open              int64 // open at the end of the bucket
+	Opened            int64
+	Resolved          int64
+	MeanTimeToResolve time.Duration // zero when nothing was resolved
+}
+
+type TrendBucket struct {
+	Start  time.Time
+	End    time.Time
+	Total  TrendCounts
+	Groups []TrendCounts
+}
+
+// trendBuckets splits [start, end) into intervals aligned to UTC hours, days or
+// weeks starting on Monday
+func trendBuckets(start, end time.Time, interval time.Duration) ([]TrendBucket, error) {
+	var buckets []TrendBucket
+	for t := start.UTC().Truncate(interval); t.Before(end); t = t.Add(interval) {
+		if len(buckets) == maxTrendBuckets {
+			return nil, fmt.Errorf("%w: time range spans more than %d buckets, use a larger interval", ErrInvalidTrendQuery, maxTrendBuckets)
+		}
+		buckets = append(buckets, TrendBucket{Start: t, End: t.Add(interval)})
+	}
+	return buckets, nil
+}
+
+// Trends aggregates the findings lifecycle into time buckets. A finding counts as
+// open in a bucket if it was opened before and not resolved until the bucket's
+// end; opened, resolved and the mean time to resolve cover the events inside it.
+func Trends(ctx context.Context, db *sql.DB, q TrendQuery) ([]TrendBucket, error) {
+	groupColumn, ok := trendGroupColumns[q.GroupBy]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown group %q (want severity, rule, namespace, cluster or team)", ErrInvalidTrendQuery, q.GroupBy)
+	}
+	interval, ok := trendIntervals[q.Interval]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown interval %q (want hour, day or week)", ErrInvalidTrendQuery, q.Interval)
+	}
+	if !q.Start.Before(q.End) {
+		return nil, fmt.Errorf("%w: start must be before end", ErrInvalidTrendQuery)
+	}
+	buckets, err := trendBuckets(q.Start, q.End, interval)
+	if err != nil {
+		return nil, err
+	}
+
+	// Totals are queried separately, since the mean of group means is not the mean
+	totals, err := queryTrends(ctx, db, buckets, "''", q)
+	if err != nil {
+		return nil, err
+	}
+	groups, err := queryTrends(ctx, db, buckets, groupColumn, q)
+	if err != nil {
+		return nil, err
+	}
+	for i := range buckets {
+		if counts := totals[i]; len(counts) > 0 {
+			buckets[i].Total = counts[0]
+		}
+		buckets[i].Groups = groups[i]
+	}
+	return buckets, nil
+}
+
+// queryTrends returns the counts per bucket index and group. Times are compared
+// through julianday() so they don't depend on how they were formatted.
+func queryTrends(ctx context.Context, db *sql.DB, buckets []TrendBucket, groupColumn string, q TrendQuery) (map[int][]TrendCounts, error) {
+	values := make([]string, len(buckets))
+	args := make([]any, 0, 3*len(buckets)+4)
+	for i, b := range buckets {
+		values[i] = "(?, julianday(?), julianday(?))"
+		args = append(args, i, b.Start, b.End)
+	}
+
+	query := `
+		WITH buckets(idx, bstart, bend) AS (VALUES ` + strings.Join(values, ", ") + `),
+		lifecycles AS (
+			SELECT ` + groupColumn + ` AS grp, julianday(first_seen) AS opened_at, julianday(resolved_at) AS resolved_at
+			FROM findings
+			WHERE first_seen IS NOT NULL AND (? = '' OR COALESCE(cluster, '') = ?) AND (? = '' OR COALESCE(team, '') = ?)
+		)
+		SELECT b.idx, l.grp,
+			SUM(CASE WHEN l.opened_at < b.bend AND (l.resolved_at IS NULL OR l.resolved_at >= b.bend) THEN 1 ELSE 0 END),
+			SUM(CASE WHEN l.opened_at >= b.bstart AND l.opened_at < b.bend THEN 1 ELSE 0 END),
+			SUM(CASE WHEN l.resolved_at >= b.bstart AND l.resolved_at < b.bend THEN 1 ELSE 0 END),
+			AVG(CASE WHEN l.resolved_at >= b.bstart AND l.resolved_at < b.bend THEN (l.resolved_at - l.opened_at) * 86400 END)
+		FROM buckets b
+		JOIN lifecycles l ON l.opened_at < b.bend AND (l.resolved_at IS NULL OR l.resolved_at >= b.bstart)
+		GROUP BY b.idx, l.grp
+		ORDER BY b.idx, l.grp`
+	args = append(args, q.Cluster, q.Cluster, q.Team, q.Team)
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query trends: %w", err)
+	}
+	defer rows.Close()
+
+	results := map[int][]TrendCounts{}
+	for rows.Next() {
+		var (
+			idx  int
+			c    TrendCounts
+			mttr sql.NullFloat64
+		)
+		if err := rows.Scan(&idx, &c.Group, &c.Open, &c.Opened, &c.Resolved, &mttr); err != nil {
+			return nil, err
+		}
+		if mttr.Valid {
+			c.MeanTimeToResolve = time.Duration(mttr.Float64 * float64(time.Second)).Round(time.Second)
+		}
+		results[idx] = append(results[idx], c)
+	}
+	return results, rows.Err()
+}
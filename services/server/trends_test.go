@@ -0,0 +1,70 @@
+package server_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"goprojects/findings"
+	"goprojects/services/server"
+)
+
+func TestTrends(t *testing.T) {
+	db, err := server.InitDB(filepath.Join(t.TempDir(), "audit.db"))
+	require.NoError(t, err)
+	defer db.Close()
+	ctx := context.Background()
+
+	day := func(d, hour int) time.Time { return time.Date(2026, 3, d, hour, 0, 0, 0, time.UTC) }
+	insert := func(f findings.Finding, opened, resolved time.Time) {
+		require.NoError(t, server.InsertFinding(db, f))
+		var resolvedAt any
+		if !resolved.IsZero() {
+			resolvedAt = resolved
+		}
+		_, err := db.Exec(`UPDATE findings SET first_seen = ?, resolved_at = ?, status = CASE WHEN ? IS NULL THEN 'open' ELSE 'resolved' END
+			WHERE id = (SELECT MAX(id) FROM findings)`, opened, resolvedAt, resolvedAt)
+		require.NoError(t, err)
+	}
+
+	critical := finding("a", "web")
+	critical.Severity = findings.SeverityCritical
+	insert(critical, day(1, 10), day(2, 16))            // resolved after 30h
+	insert(finding("a", "api"), day(1, 12), day(2, 18)) // resolved after 30h
+	owned := finding("b", "db")
+	owned.Team = "payments"
+	insert(owned, day(2, 9), time.Time{})
+
+	buckets, err := server.Trends(ctx, db, server.TrendQuery{
+		Start: day(1, 0), End: day(4, 0), Interval: "day", GroupBy: "severity",
+	})
+	require.NoError(t, err)
+	require.Len(t, buckets, 3)
+
+	first := buckets[0]
+	require.Equal(t, day(1, 0), first.Start)
+	require.Equal(t, server.TrendCounts{Open: 2, Opened: 2}, first.Total)
+	require.Equal(t, []server.TrendCounts{
+		{Group: "critical", Open: 1, Opened: 1},
+		{Group: "medium", Open: 1, Opened: 1},
+	}, first.Groups)
+
+	second := buckets[1]
+	require.Equal(t, server.TrendCounts{Open: 1, Opened: 1, Resolved: 2, MeanTimeToResolve: 30 * time.Hour}, second.Total)
+
+	third := buckets[2]
+	require.Equal(t, server.TrendCounts{Open: 1}, third.Total)
+	require.Equal(t, []server.TrendCounts{{Group: "medium", Open: 1}}, third.Groups)
+
+	teams, err := server.Trends(ctx, db, server.TrendQuery{
+		Start: day(3, 0), End: day(4, 0), Interval: "day", GroupBy: "team", Team: "payments",
+	})
+	require.NoError(t, err)
+	require.Equal(t, []server.TrendCounts{{Group: "payments", Open: 1}}, teams[0].Groups)
+
+	_, err = server.Trends(ctx, db, server.TrendQuery{Start: day(1, 0), End: day(4, 0), Interval: "day", GroupBy: "owner"})
+	require.ErrorIs(t, err, server.ErrInvalidTrendQuery)
+}